@@ -1,24 +1,73 @@
 package main
 
 import (
+	"context"
 	"fmt"
+	"math"
 	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
 
-	"github.com/arjunaayasa/sasaklang/pkg/sasaklang/evaluator"
-	"github.com/arjunaayasa/sasaklang/pkg/sasaklang/lexer"
-	"github.com/arjunaayasa/sasaklang/pkg/sasaklang/object"
-	"github.com/arjunaayasa/sasaklang/pkg/sasaklang/parser"
+	"github.com/arjunaayasa/sasaklang/pkg/sasaklang"
 	"github.com/arjunaayasa/sasaklang/pkg/sasaklang/repl"
 )
 
 const Version = "1.0.0"
 
+// options are the flags that apply to running a file. --max-memory and
+// --seed also apply to the REPL.
+type options struct {
+	useVM     bool   // --vm: bytecode VM instead of the tree-walking evaluator
+	maxMemory int64  // --max-memory: memory limit of the program, in bytes
+	seed      *int64 // --seed: seed of the random numbers, for reproducible runs
+}
+
 func main() {
-	args := os.Args[1:]
+	var opts options
+	var args []string
+	osArgs := os.Args[1:]
+	for i := 0; i < len(osArgs); i++ {
+		arg := osArgs[i]
+		switch {
+		case arg == "--vm":
+			opts.useVM = true
+		case arg == "--max-memory" || strings.HasPrefix(arg, "--max-memory="):
+			value, ok := strings.CutPrefix(arg, "--max-memory=")
+			if !ok && i+1 < len(osArgs) {
+				i++
+				value = osArgs[i]
+			}
+			size, err := parseSize(value)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Nilai --max-memory tidak valid: %s\n", err)
+				os.Exit(1)
+			}
+			opts.maxMemory = size
+		case arg == "--seed" || strings.HasPrefix(arg, "--seed="):
+			value, ok := strings.CutPrefix(arg, "--seed=")
+			if !ok && i+1 < len(osArgs) {
+				i++
+				value = osArgs[i]
+			}
+			seed, err := strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Nilai --seed tidak valid: harus bilangan bulat, dapat %q\n", value)
+				os.Exit(1)
+			}
+			opts.seed = &seed
+		default:
+			args = append(args, arg)
+		}
+	}
 
 	if len(args) == 0 {
-		// Start REPL
-		repl.Start(os.Stdin, os.Stdout)
+		// Start REPL, which always runs on the evaluator
+		if opts.useVM {
+			fmt.Fprintln(os.Stderr, "--vm hanya bisa dipakai untuk menjalankan file, tidak untuk REPL")
+			os.Exit(1)
+		}
+		repl.StartWithOptions(os.Stdin, os.Stdout, repl.Options{MaxMemory: opts.maxMemory, Seed: opts.seed})
 		return
 	}
 
@@ -30,13 +79,13 @@ func main() {
 			fmt.Fprintln(os.Stderr, "Penggunaan: sasaklang run <file>")
 			os.Exit(1)
 		}
-		runFile(args[1])
+		runFile(args[1], opts)
 	case "help", "--help", "-h":
 		printHelp()
 	default:
 		// Treat as file to run (for convenience)
 		if _, err := os.Stat(args[0]); err == nil {
-			runFile(args[0])
+			runFile(args[0], opts)
 		} else {
 			fmt.Fprintf(os.Stderr, "Perintah tidak dikenal: %s\n", args[0])
 			printHelp()
@@ -45,32 +94,53 @@ func main() {
 	}
 }
 
-func runFile(filename string) {
+func runFile(filename string, opts options) {
 	content, err := os.ReadFile(filename)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Gagal membaca file: %s\n", err)
 		os.Exit(1)
 	}
 
-	l := lexer.New(string(content))
-	p := parser.New(l)
+	absPath, err := filepath.Abs(filename)
+	if err != nil {
+		absPath = ""
+	}
+
+	interp := sasaklang.New()
+	interp.Stdin, interp.Stdout, interp.Stderr = os.Stdin, os.Stdout, os.Stderr
+	interp.File = absPath
+	interp.AllowImports = true
+	interp.UseVM = opts.useVM
+	interp.MaxMemory = opts.maxMemory
+	if opts.seed != nil {
+		interp.SetSeed(*opts.seed)
+	}
 
-	program := p.ParseProgram()
-	if len(p.Errors()) != 0 {
-		fmt.Fprintln(os.Stderr, "Ada error saat parsing:")
-		for _, msg := range p.Errors() {
-			fmt.Fprintf(os.Stderr, "  %s\n", msg)
-		}
+	// The interpreter reports errors to Stderr
+	if _, err := interp.Run(context.Background(), string(content)); err != nil {
 		os.Exit(1)
 	}
+}
 
-	env := object.NewEnvironment()
-	result := evaluator.Eval(program, env)
+// parseSize parses a size in bytes such as "1048576", "512K", "64M" or "1G"
+func parseSize(s string) (int64, error) {
+	s = strings.TrimSuffix(strings.ToUpper(strings.TrimSpace(s)), "B")
+	multiplier := int64(1)
+	for suffix, m := range map[string]int64{"K": 1 << 10, "M": 1 << 20, "G": 1 << 30} {
+		if rest, ok := strings.CutSuffix(s, suffix); ok {
+			s, multiplier = rest, m
+			break
+		}
+	}
 
-	if result != nil && result.Type() == object.ERROR_OBJ {
-		fmt.Fprintln(os.Stderr, result.Inspect())
-		os.Exit(1)
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("ukuran harus angka positif, misalnya 64M")
+	}
+	if n > math.MaxInt64/multiplier {
+		return 0, fmt.Errorf("ukuran terlalu besar, paling banyak %d byte", int64(math.MaxInt64))
 	}
+	return n * multiplier, nil
 }
 
 func printHelp() {
@@ -80,11 +150,17 @@ Penggunaan:
   sasaklang                    Masuk ke mode REPL
   sasaklang run <file>         Jalankan file .sl
   sasaklang <file>             Jalankan file .sl (shortcut)
+  sasaklang --vm run <file>    Jalankan file dengan bytecode VM (lebih cepat)
+  sasaklang --max-memory 64M run <file>
+                               Batasi memori string, daftar, dan peta program
+  sasaklang --seed 42 run <file>
+                               Angka acak yang sama setiap kali dijalankan
   sasaklang version            Tampilkan versi
   sasaklang help               Tampilkan bantuan ini
 
 Contoh:
   sasaklang                    # Masuk REPL
+  sasaklang --seed 42          # Masuk REPL dengan angka acak yang sama
   sasaklang run hello.sl       # Jalankan file
   sasaklang hello.sl           # Jalankan file (shortcut)
 
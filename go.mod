@@ -1,3 +1,7 @@
 module github.com/arjunaayasa/sasaklang
 
 go 1.22
+
+require golang.org/x/term v0.29.0
+
+require golang.org/x/sys v0.30.0 // indirect
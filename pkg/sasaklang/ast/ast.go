@@ -2,6 +2,7 @@ package ast
 
 import (
 	"bytes"
+	"math/big"
 	"strings"
 
 	"github.com/arjunaayasa/sasaklang/pkg/sasaklang/token"
@@ -150,6 +151,84 @@ func (cs *ContinueStatement) statementNode()       {}
 func (cs *ContinueStatement) TokenLiteral() string { return cs.Token.Literal }
 func (cs *ContinueStatement) String() string       { return cs.Token.Literal + ";" }
 
+// TryStatement represents a coba/tangkep/akhirne (try/catch/finally) statement
+type TryStatement struct {
+	Token      token.Token // the 'coba' token
+	Block      *BlockStatement
+	CatchParam *Identifier // optional name bound to the caught error
+	Catch      *BlockStatement
+	Finally    *BlockStatement
+}
+
+func (ts *TryStatement) statementNode()       {}
+func (ts *TryStatement) TokenLiteral() string { return ts.Token.Literal }
+
+func (ts *TryStatement) String() string {
+	var out bytes.Buffer
+
+	out.WriteString("coba ")
+	out.WriteString(ts.Block.String())
+
+	if ts.Catch != nil {
+		out.WriteString(" tangkep ")
+		if ts.CatchParam != nil {
+			out.WriteString("(" + ts.CatchParam.String() + ") ")
+		}
+		out.WriteString(ts.Catch.String())
+	}
+
+	if ts.Finally != nil {
+		out.WriteString(" akhirne ")
+		out.WriteString(ts.Finally.String())
+	}
+
+	return out.String()
+}
+
+// ThrowStatement represents a lempar (throw) statement
+type ThrowStatement struct {
+	Token token.Token // the 'lempar' token
+	Value Expression
+}
+
+func (ts *ThrowStatement) statementNode()       {}
+func (ts *ThrowStatement) TokenLiteral() string { return ts.Token.Literal }
+
+func (ts *ThrowStatement) String() string {
+	var out bytes.Buffer
+
+	out.WriteString(ts.TokenLiteral() + " ")
+	if ts.Value != nil {
+		out.WriteString(ts.Value.String())
+	}
+	out.WriteString(";")
+
+	return out.String()
+}
+
+// ImportStatement represents an impor statement, e.g. impor "util.ssk" sebagai u
+type ImportStatement struct {
+	Token token.Token // the 'impor' token
+	Path  string
+	Alias *Identifier // optional; defaults to the file name without extension
+}
+
+func (is *ImportStatement) statementNode()       {}
+func (is *ImportStatement) TokenLiteral() string { return is.Token.Literal }
+
+func (is *ImportStatement) String() string {
+	var out bytes.Buffer
+
+	out.WriteString(is.TokenLiteral() + " ")
+	out.WriteString(`"` + is.Path + `"`)
+	if is.Alias != nil {
+		out.WriteString(" sebagai " + is.Alias.String())
+	}
+	out.WriteString(";")
+
+	return out.String()
+}
+
 // ExpressionStatement represents an expression statement
 type ExpressionStatement struct {
 	Token      token.Token // the first token of the expression
@@ -166,16 +245,28 @@ func (es *ExpressionStatement) String() string {
 	return ""
 }
 
-// IntegerLiteral represents an integer literal
+// IntegerLiteral represents an integer literal. A literal too large for
+// int64 has its value in Big instead of Value.
 type IntegerLiteral struct {
 	Token token.Token
 	Value int64
+	Big   *big.Int
 }
 
 func (il *IntegerLiteral) expressionNode()      {}
 func (il *IntegerLiteral) TokenLiteral() string { return il.Token.Literal }
 func (il *IntegerLiteral) String() string       { return il.Token.Literal }
 
+// FloatLiteral represents a floating-point literal
+type FloatLiteral struct {
+	Token token.Token
+	Value float64
+}
+
+func (fl *FloatLiteral) expressionNode()      {}
+func (fl *FloatLiteral) TokenLiteral() string { return fl.Token.Literal }
+func (fl *FloatLiteral) String() string       { return fl.Token.Literal }
+
 // StringLiteral represents a string literal
 type StringLiteral struct {
 	Token token.Token
@@ -186,6 +277,35 @@ func (sl *StringLiteral) expressionNode()      {}
 func (sl *StringLiteral) TokenLiteral() string { return sl.Token.Literal }
 func (sl *StringLiteral) String() string       { return `"` + sl.Token.Literal + `"` }
 
+// TemplateLiteral represents an interpolated string such as "Halo, ${nama}!".
+// Parts holds the text between the interpolations as StringLiterals and
+// the interpolated expressions, in source order; empty text is left out.
+type TemplateLiteral struct {
+	Token token.Token // the TEMPLATE_START token
+	Parts []Expression
+}
+
+func (tl *TemplateLiteral) expressionNode()      {}
+func (tl *TemplateLiteral) TokenLiteral() string { return tl.Token.Literal }
+
+func (tl *TemplateLiteral) String() string {
+	var out bytes.Buffer
+
+	out.WriteString(`"`)
+	for _, part := range tl.Parts {
+		if text, ok := part.(*StringLiteral); ok {
+			out.WriteString(text.Value)
+			continue
+		}
+		out.WriteString("${")
+		out.WriteString(part.String())
+		out.WriteString("}")
+	}
+	out.WriteString(`"`)
+
+	return out.String()
+}
+
 // PrefixExpression represents a prefix expression
 type PrefixExpression struct {
 	Token    token.Token // The prefix token, e.g. !
@@ -207,6 +327,27 @@ func (pe *PrefixExpression) String() string {
 	return out.String()
 }
 
+// PostfixExpression represents an increment or decrement, e.g. i++
+type PostfixExpression struct {
+	Token    token.Token // The postfix token, e.g. ++
+	Target   Expression  // *Identifier, *IndexExpression or *MemberExpression
+	Operator string
+}
+
+func (pe *PostfixExpression) expressionNode()      {}
+func (pe *PostfixExpression) TokenLiteral() string { return pe.Token.Literal }
+
+func (pe *PostfixExpression) String() string {
+	var out bytes.Buffer
+
+	out.WriteString("(")
+	out.WriteString(pe.Target.String())
+	out.WriteString(pe.Operator)
+	out.WriteString(")")
+
+	return out.String()
+}
+
 // InfixExpression represents an infix expression
 type InfixExpression struct {
 	Token    token.Token // The operator token, e.g. +
@@ -398,6 +539,28 @@ func (ie *IndexExpression) String() string {
 	return out.String()
 }
 
+// MemberExpression represents property access with a dot, e.g. modul.nama
+type MemberExpression struct {
+	Token    token.Token // The . token
+	Left     Expression
+	Property *Identifier
+}
+
+func (me *MemberExpression) expressionNode()      {}
+func (me *MemberExpression) TokenLiteral() string { return me.Token.Literal }
+
+func (me *MemberExpression) String() string {
+	var out bytes.Buffer
+
+	out.WriteString("(")
+	out.WriteString(me.Left.String())
+	out.WriteString(".")
+	out.WriteString(me.Property.String())
+	out.WriteString(")")
+
+	return out.String()
+}
+
 // WhileStatement represents a while loop
 type WhileStatement struct {
 	Token     token.Token // The 'salama' token
@@ -452,10 +615,45 @@ func (fs *ForStatement) String() string {
 	return out.String()
 }
 
+// ForInStatement represents an ojok ... dalem loop over the elements of a
+// value. With two variables the first one gets the index or key.
+type ForInStatement struct {
+	Token     token.Token   // The 'ojok' token
+	Variables []*Identifier // one or two
+	Iterable  Expression
+	Body      *BlockStatement
+}
+
+func (fs *ForInStatement) statementNode()       {}
+func (fs *ForInStatement) TokenLiteral() string { return fs.Token.Literal }
+
+func (fs *ForInStatement) String() string {
+	var out bytes.Buffer
+
+	names := make([]string, len(fs.Variables))
+	for i, v := range fs.Variables {
+		names[i] = v.String()
+	}
+	out.WriteString("ojok (")
+	out.WriteString(strings.Join(names, ", "))
+	out.WriteString(" dalem ")
+	out.WriteString(fs.Iterable.String())
+	out.WriteString(") ")
+	out.WriteString(fs.Body.String())
+
+	return out.String()
+}
+
 // MapLiteral represents a hash map
 type MapLiteral struct {
 	Token token.Token // the '{' token
-	Pairs map[Expression]Expression
+	Pairs []MapLiteralPair
+}
+
+// MapLiteralPair is one key: value entry of a map literal
+type MapLiteralPair struct {
+	Key   Expression
+	Value Expression
 }
 
 func (ml *MapLiteral) expressionNode()      {}
@@ -465,8 +663,8 @@ func (ml *MapLiteral) String() string {
 	var out bytes.Buffer
 
 	pairs := []string{}
-	for key, value := range ml.Pairs {
-		pairs = append(pairs, key.String()+":"+value.String())
+	for _, pair := range ml.Pairs {
+		pairs = append(pairs, pair.Key.String()+":"+pair.Value.String())
 	}
 
 	out.WriteString("{")
@@ -478,9 +676,10 @@ func (ml *MapLiteral) String() string {
 
 // AssignmentExpression represents an assignment expression
 type AssignmentExpression struct {
-	Token token.Token // the '=' token
-	Name  *Identifier
-	Value Expression
+	Token    token.Token // the '=' token, or a compound one such as '+='
+	Target   Expression  // *Identifier, *IndexExpression or *MemberExpression
+	Operator string      // arithmetic operator of a compound assignment, empty for '='
+	Value    Expression
 }
 
 func (ae *AssignmentExpression) expressionNode()      {}
@@ -489,9 +688,82 @@ func (ae *AssignmentExpression) TokenLiteral() string { return ae.Token.Literal
 func (ae *AssignmentExpression) String() string {
 	var out bytes.Buffer
 
-	out.WriteString(ae.Name.String())
-	out.WriteString(" = ")
+	out.WriteString(ae.Target.String())
+	out.WriteString(" " + ae.Operator + "= ")
 	out.WriteString(ae.Value.String())
 
 	return out.String()
 }
+
+// Position returns the line and column errors in node are reported at: the
+// position of the node's token, such as the operator of an infix
+// expression. It returns 0, 0 for a Program.
+func Position(node Node) (line, column int) {
+	var tok token.Token
+	switch node := node.(type) {
+	case *Identifier:
+		tok = node.Token
+	case *LetStatement:
+		tok = node.Token
+	case *ConstStatement:
+		tok = node.Token
+	case *ReturnStatement:
+		tok = node.Token
+	case *BreakStatement:
+		tok = node.Token
+	case *ContinueStatement:
+		tok = node.Token
+	case *TryStatement:
+		tok = node.Token
+	case *ThrowStatement:
+		tok = node.Token
+	case *ImportStatement:
+		tok = node.Token
+	case *ExpressionStatement:
+		tok = node.Token
+	case *BlockStatement:
+		tok = node.Token
+	case *WhileStatement:
+		tok = node.Token
+	case *ForStatement:
+		tok = node.Token
+	case *ForInStatement:
+		tok = node.Token
+	case *IntegerLiteral:
+		tok = node.Token
+	case *FloatLiteral:
+		tok = node.Token
+	case *StringLiteral:
+		tok = node.Token
+	case *TemplateLiteral:
+		tok = node.Token
+	case *Boolean:
+		tok = node.Token
+	case *NullLiteral:
+		tok = node.Token
+	case *PrefixExpression:
+		tok = node.Token
+	case *PostfixExpression:
+		tok = node.Token
+	case *InfixExpression:
+		tok = node.Token
+	case *AssignmentExpression:
+		tok = node.Token
+	case *IfExpression:
+		tok = node.Token
+	case *FunctionLiteral:
+		tok = node.Token
+	case *CallExpression:
+		tok = node.Token
+	case *ArrayLiteral:
+		tok = node.Token
+	case *IndexExpression:
+		tok = node.Token
+	case *MemberExpression:
+		// Point at the member name rather than the dot
+		tok = node.Property.Token
+	case *MapLiteral:
+		tok = node.Token
+	}
+	return tok.Line, tok.Column
+}
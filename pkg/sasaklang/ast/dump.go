@@ -0,0 +1,85 @@
+package ast
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+var nodeType = reflect.TypeOf((*Node)(nil)).Elem()
+
+// Dump renders node as an indented tree with one node per line, showing
+// each node's type, its literal fields and, below it, its children:
+//
+//	LetStatement
+//	  Name: Identifier Value="x"
+//	  Value: InfixExpression Operator="+"
+//	    Left: IntegerLiteral Value=1
+//	    Right: IntegerLiteral Value=2
+func Dump(node Node) string {
+	var out bytes.Buffer
+	dumpNode(&out, "", reflect.ValueOf(node), 0)
+	return out.String()
+}
+
+func dumpNode(out *bytes.Buffer, label string, v reflect.Value, depth int) {
+	for v.Kind() == reflect.Interface || v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return
+	}
+
+	t := v.Type()
+	var attrs []string
+	type child struct {
+		label string
+		value reflect.Value
+	}
+	var children []child
+
+	for i := 0; i < t.NumField(); i++ {
+		name := t.Field(i).Name
+		field := v.Field(i)
+		if name == "Token" || !t.Field(i).IsExported() {
+			continue
+		}
+
+		switch field.Kind() {
+		case reflect.String:
+			if field.String() != "" {
+				attrs = append(attrs, fmt.Sprintf("%s=%q", name, field.String()))
+			}
+		case reflect.Bool, reflect.Int, reflect.Int64, reflect.Float64:
+			attrs = append(attrs, fmt.Sprintf("%s=%v", name, field.Interface()))
+		case reflect.Slice:
+			for j := 0; j < field.Len(); j++ {
+				children = append(children, child{fmt.Sprintf("%s[%d]", name, j), field.Index(j)})
+			}
+		default:
+			if field.Type().Implements(nodeType) {
+				children = append(children, child{name, field})
+			} else if s, ok := field.Interface().(fmt.Stringer); ok && field.Kind() == reflect.Ptr && !field.IsNil() {
+				attrs = append(attrs, fmt.Sprintf("%s=%s", name, s))
+			}
+		}
+	}
+
+	out.WriteString(strings.Repeat("  ", depth))
+	if label != "" {
+		out.WriteString(label + ": ")
+	}
+	out.WriteString(t.Name())
+	if len(attrs) > 0 {
+		out.WriteString(" " + strings.Join(attrs, " "))
+	}
+	out.WriteString("\n")
+
+	for _, c := range children {
+		dumpNode(out, c.label, c.value, depth+1)
+	}
+}
@@ -0,0 +1,111 @@
+package builtins
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/arjunaayasa/sasaklang/pkg/sasaklang/object"
+)
+
+// Random functions. They all draw from the runtime's one source of random
+// numbers, so after benih(n), or with the --seed flag, a program gets the
+// same numbers every run.
+
+// builtinAcak returns a random number between 0 and n-1
+func builtinAcak(rt *object.Runtime, args ...object.Object) object.Object {
+	if len(args) != 1 {
+		return &object.Error{Message: fmt.Sprintf("acak() butuh 1 argumen (max), dapat %d", len(args))}
+	}
+
+	arg, ok := args[0].(*object.Integer)
+	if !ok {
+		return &object.Error{Message: "argumen acak() harus angka"}
+	}
+
+	if arg.Value <= 0 {
+		return &object.Error{Message: "argumen acak() harus lebih besar dari 0"}
+	}
+
+	return &object.Integer{Value: rt.Rand().Int63n(arg.Value)}
+}
+
+// builtinBenih seeds the random numbers (seed)
+func builtinBenih(rt *object.Runtime, args ...object.Object) object.Object {
+	if errObj := checkArgCount("benih", args, 1, 1); errObj != nil {
+		return errObj
+	}
+	seed, errObj := intArg("benih", args, 0)
+	if errObj != nil {
+		return errObj
+	}
+	rt.SetSeed(seed)
+	return object.NULL
+}
+
+// builtinAcakAntara returns a random integer from a to b, both included
+func builtinAcakAntara(rt *object.Runtime, args ...object.Object) object.Object {
+	if errObj := checkArgCount("acak_antara", args, 2, 2); errObj != nil {
+		return errObj
+	}
+	lo, errObj := integerArg("acak_antara", args, 0)
+	if errObj != nil {
+		return errObj
+	}
+	hi, errObj := integerArg("acak_antara", args, 1)
+	if errObj != nil {
+		return errObj
+	}
+	if lo.Cmp(hi) > 0 {
+		return &object.Error{Message: fmt.Sprintf("batas bawah acak_antara() lebih besar dari batas atas: %s > %s", lo, hi)}
+	}
+
+	// Big integers, so that ranges wider than int64 work too
+	span := new(big.Int).Sub(hi, lo)
+	span.Add(span, big.NewInt(1))
+	n := new(big.Int).Rand(rt.Rand(), span)
+	return object.NewInteger(n.Add(n, lo))
+}
+
+// builtinAcakPecahan returns a random float from 0 up to, but not
+// including, 1
+func builtinAcakPecahan(rt *object.Runtime, args ...object.Object) object.Object {
+	if errObj := checkArgCount("acak_pecahan", args, 0, 0); errObj != nil {
+		return errObj
+	}
+	return &object.Float{Value: rt.Rand().Float64()}
+}
+
+// builtinPilihAcak returns a random element of an array (choice)
+func builtinPilihAcak(rt *object.Runtime, args ...object.Object) object.Object {
+	if errObj := checkArgCount("pilih_acak", args, 1, 1); errObj != nil {
+		return errObj
+	}
+	arr, errObj := arrayArg("pilih_acak", args, 0)
+	if errObj != nil {
+		return errObj
+	}
+	if len(arr.Elements) == 0 {
+		return &object.Error{Message: "pilih_acak() tidak bisa dipakai pada daftar kosong"}
+	}
+	return arr.Elements[rt.Rand().Intn(len(arr.Elements))]
+}
+
+// builtinKocok returns the elements of an array in random order (shuffle).
+// Like the other array functions it leaves the array itself alone.
+func builtinKocok(rt *object.Runtime, args ...object.Object) object.Object {
+	if errObj := checkArgCount("kocok", args, 1, 1); errObj != nil {
+		return errObj
+	}
+	arr, errObj := arrayArg("kocok", args, 0)
+	if errObj != nil {
+		return errObj
+	}
+
+	shuffled := newArray(rt, arr.Elements)
+	if elements, ok := shuffled.(*object.Array); ok {
+		rt.Rand().Shuffle(len(elements.Elements), func(i, j int) {
+			elements.Elements[i], elements.Elements[j] = elements.Elements[j], elements.Elements[i]
+		})
+	}
+	return shuffled
+}
@@ -1,10 +1,8 @@
 package builtins
 
 import (
-	"bufio"
 	"fmt"
-	"math/rand"
-	"os"
+	"math"
 	"strings"
 	"time"
 
@@ -12,7 +10,7 @@ import (
 )
 
 // builtinTedem sleeps for n milliseconds
-func builtinTedem(args ...object.Object) object.Object {
+func builtinTedem(rt *object.Runtime, args ...object.Object) object.Object {
 	if len(args) != 1 {
 		return &object.Error{Message: fmt.Sprintf("tedem() butuh 1 argumen (ms), dapat %d", len(args))}
 	}
@@ -22,73 +20,158 @@ func builtinTedem(args ...object.Object) object.Object {
 		return &object.Error{Message: "argumen tedem() harus angka"}
 	}
 
-	time.Sleep(time.Duration(arg.Value) * time.Millisecond)
-	return &object.Null{}
+	if errObj := rt.Sleep(time.Duration(arg.Value) * time.Millisecond); errObj != nil {
+		return errObj
+	}
+	return object.NULL
 }
 
-// builtinAcak returns a random number between 0 and n-1
-func builtinAcak(args ...object.Object) object.Object {
-	if len(args) != 1 {
-		return &object.Error{Message: fmt.Sprintf("acak() butuh 1 argumen (max), dapat %d", len(args))}
+// builtinRentang returns the integers from a up to, but not including, b,
+// going by step: rentang(b), rentang(a, b) or rentang(a, b, step). The
+// numbers are made one at a time as an ojok ... dalem loop goes through
+// them, so no array is built.
+func builtinRentang(rt *object.Runtime, args ...object.Object) object.Object {
+	if errObj := checkArgCount("rentang", args, 1, 3); errObj != nil {
+		return errObj
 	}
-
-	arg, ok := args[0].(*object.Integer)
-	if !ok {
-		return &object.Error{Message: "argumen acak() harus angka"}
+	nums := [3]int64{0, 0, 1}
+	for i := range args {
+		n, errObj := intArg("rentang", args, i)
+		if errObj != nil {
+			return errObj
+		}
+		nums[i] = n
 	}
-
-	if arg.Value <= 0 {
-		return &object.Error{Message: "argumen acak() harus lebih besar dari 0"}
+	if len(args) == 1 {
+		nums[0], nums[1] = 0, nums[0]
 	}
-
-	return &object.Integer{Value: int64(rand.Int63n(arg.Value))}
+	if nums[2] == 0 {
+		return &object.Error{Message: "langkah rentang() tidak boleh nol"}
+	}
+	return &object.Range{Start: nums[0], End: nums[1], Step: nums[2]}
 }
 
 // Builtins contains all builtin functions
 var Builtins = map[string]*object.Builtin{
-	"cetak":  {Fn: builtinCetak},
-	"isik":   {Fn: builtinIsik},
-	"belong": {Fn: builtinBelong},
-	"jenis":  {Fn: builtinJenis},
-	"waktu":  {Fn: builtinWaktu},
-	"sorong": {Fn: builtinSorong},
-	"bait":   {Fn: builtinBait},   // get -> bait
-	"ngatur": {Fn: builtinNgatur}, // set -> ngatur
-	"tedem":  {Fn: builtinTedem},
-	"acak":   {Fn: builtinAcak},
+	"cetak":   {Fn: builtinCetak},
+	"isik":    {Fn: builtinIsik},
+	"belong":  {Fn: builtinBelong},
+	"jenis":   {Fn: builtinJenis},
+	"waktu":   {Fn: builtinWaktu},
+	"sorong":  {Fn: builtinSorong},
+	"bait":    {Fn: builtinBait},   // get -> bait
+	"ngatur":  {Fn: builtinNgatur}, // set -> ngatur
+	"tedem":   {Fn: builtinTedem},
+	"rentang": {Fn: builtinRentang}, // range
+
+	// Teks (string) functions, see teks.go
+	"pisah":        {Fn: builtinPisah},                                   // split
+	"gabung":       {Fn: builtinGabung},                                  // join
+	"rapikan":      {Fn: builtinRapikan},                                 // trim
+	"huruf_besar":  {Fn: builtinHurufBesar},                              // upper
+	"huruf_kecil":  {Fn: builtinHurufKecil},                              // lower
+	"berisi":       {Fn: stringPredicate("berisi", strings.Contains)},    // contains
+	"diawali":      {Fn: stringPredicate("diawali", strings.HasPrefix)},  // starts with
+	"diakhiri":     {Fn: stringPredicate("diakhiri", strings.HasSuffix)}, // ends with
+	"posisi":       {Fn: builtinPosisi},                                  // index of
+	"ganti":        {Fn: builtinGanti},                                   // replace
+	"iris":         {Fn: builtinIris},                                    // substring
+	"ulang":        {Fn: builtinUlang},                                   // repeat
+	"jumlah_huruf": {Fn: builtinJumlahHuruf},                             // length in characters
+
+	// Daftar (array) functions, see daftar.go
+	"petakan": {Fn: builtinPetakan}, // map
+	"saring":  {Fn: builtinSaring},  // filter
+	"lipat":   {Fn: builtinLipat},   // reduce
+	"temukan": {Fn: builtinTemukan}, // find
+	"ada":     {Fn: builtinAda},     // any
+	"semua":   {Fn: builtinSemua},   // all
+	"urutkan": {Fn: builtinUrutkan}, // sort
+	"balik":   {Fn: builtinBalik},   // reverse
+	"sambung": {Fn: builtinSambung}, // concat
+
+	// Peta (map) functions, see peta.go
+	"kunci":       {Fn: builtinKunci},      // keys
+	"nilai":       {Fn: builtinNilai},      // values
+	"pasangan":    {Fn: builtinPasangan},   // entries
+	"punya_kunci": {Fn: builtinPunyaKunci}, // has key
+	"hapus":       {Fn: builtinHapus},      // delete
+
+	// Matematika (math) functions, see matematika.go
+	"mutlak":      {Fn: builtinMutlak},                       // abs
+	"terkecil":    {Fn: extremum("terkecil", -1)},            // min
+	"terbesar":    {Fn: extremum("terbesar", 1)},             // max
+	"pangkat":     {Fn: builtinPangkat},                      // pow
+	"akar":        {Fn: floatFunction("akar", math.Sqrt)},    // sqrt
+	"bulat_bawah": {Fn: rounding("bulat_bawah", math.Floor)}, // floor
+	"bulat_atas":  {Fn: rounding("bulat_atas", math.Ceil)},   // ceil
+	"bulatkan":    {Fn: rounding("bulatkan", math.Round)},    // round
+	"sin":         {Fn: floatFunction("sin", math.Sin)},
+	"cos":         {Fn: floatFunction("cos", math.Cos)},
+	"tan":         {Fn: floatFunction("tan", math.Tan)},
+	"asin":        {Fn: floatFunction("asin", math.Asin)},
+	"acos":        {Fn: floatFunction("acos", math.Acos)},
+	"atan":        {Fn: floatFunction("atan", math.Atan)},
+	"atan2":       {Fn: builtinAtan2},
+	"log":         {Fn: floatFunction("log", math.Log)}, // natural logarithm
+	"log10":       {Fn: floatFunction("log10", math.Log10)},
+	"log2":        {Fn: floatFunction("log2", math.Log2)},
+	"exp":         {Fn: floatFunction("exp", math.Exp)},
+	"fpb":         {Fn: builtinFpb},    // gcd
+	"kpk":         {Fn: builtinKpk},    // lcm
+	"batasi":      {Fn: builtinBatasi}, // clamp
+
+	// Random functions, see acak.go
+	"acak":         {Fn: builtinAcak},
+	"benih":        {Fn: builtinBenih},       // seed
+	"acak_antara":  {Fn: builtinAcakAntara},  // random integer in a range
+	"acak_pecahan": {Fn: builtinAcakPecahan}, // random float
+	"pilih_acak":   {Fn: builtinPilihAcak},   // choice
+	"kocok":        {Fn: builtinKocok},       // shuffle
+}
+
+// Lookup returns the builtin function or constant called name
+func Lookup(name string) (object.Object, bool) {
+	if builtin, ok := Builtins[name]; ok {
+		return builtin, true
+	}
+	constant, ok := Constants[name]
+	return constant, ok
 }
 
 // builtinCetak prints arguments separated by space with newline
-func builtinCetak(args ...object.Object) object.Object {
+func builtinCetak(rt *object.Runtime, args ...object.Object) object.Object {
 	strs := make([]string, len(args))
 	for i, arg := range args {
 		strs[i] = arg.Inspect()
 	}
-	fmt.Println(strings.Join(strs, " "))
-	return &object.Null{}
+	fmt.Fprintln(rt.Stdout, strings.Join(strs, " "))
+	return object.NULL
 }
 
-// builtinIsik reads input from stdin
-func builtinIsik(args ...object.Object) object.Object {
+// builtinIsik reads a line of input
+func builtinIsik(rt *object.Runtime, args ...object.Object) object.Object {
 	if len(args) > 1 {
 		return &object.Error{Message: "isik() butuh maksimal 1 argumen"}
 	}
 
 	if len(args) == 1 {
-		fmt.Print(args[0].Inspect())
+		fmt.Fprint(rt.Stdout, args[0].Inspect())
 	}
 
-	reader := bufio.NewReader(os.Stdin)
-	input, err := reader.ReadString('\n')
+	input, err := rt.ReadLine()
 	if err != nil {
 		return &object.Error{Message: "gagal membaca input"}
 	}
+	if errObj := rt.Allocate(object.StringSize(len(input))); errObj != nil {
+		return errObj
+	}
 
-	return &object.String{Value: strings.TrimSuffix(input, "\n")}
+	return &object.String{Value: input}
 }
 
 // builtinBelong returns the length of string or array
-func builtinBelong(args ...object.Object) object.Object {
+func builtinBelong(rt *object.Runtime, args ...object.Object) object.Object {
 	if len(args) != 1 {
 		return &object.Error{Message: fmt.Sprintf("belong() butuh 1 argumen, dapat %d", len(args))}
 	}
@@ -104,15 +187,17 @@ func builtinBelong(args ...object.Object) object.Object {
 }
 
 // builtinJenis returns the type name of an object
-func builtinJenis(args ...object.Object) object.Object {
+func builtinJenis(rt *object.Runtime, args ...object.Object) object.Object {
 	if len(args) != 1 {
 		return &object.Error{Message: fmt.Sprintf("jenis() butuh 1 argumen, dapat %d", len(args))}
 	}
 
 	var typeName string
 	switch args[0].(type) {
-	case *object.Integer:
+	case *object.Integer, *object.BigInteger:
 		typeName = "angka"
+	case *object.Float:
+		typeName = "pecahan"
 	case *object.String:
 		typeName = "teks"
 	case *object.Boolean:
@@ -123,10 +208,14 @@ func builtinJenis(args ...object.Object) object.Object {
 		typeName = "daftar"
 	case *object.Map:
 		typeName = "peta"
-	case *object.Function:
+	case *object.Range:
+		typeName = "rentang"
+	case *object.Function, *object.Closure:
 		typeName = "fungsi"
 	case *object.Builtin:
 		typeName = "fungsi_bawaan"
+	case *object.Module:
+		typeName = "modul"
 	default:
 		typeName = "tidak_dikenal"
 	}
@@ -135,7 +224,7 @@ func builtinJenis(args ...object.Object) object.Object {
 }
 
 // builtinWaktu returns the current unix timestamp
-func builtinWaktu(args ...object.Object) object.Object {
+func builtinWaktu(rt *object.Runtime, args ...object.Object) object.Object {
 	if len(args) != 0 {
 		return &object.Error{Message: "waktu() tidak butuh argumen"}
 	}
@@ -143,7 +232,7 @@ func builtinWaktu(args ...object.Object) object.Object {
 }
 
 // builtinSorong appends an element to an array
-func builtinSorong(args ...object.Object) object.Object {
+func builtinSorong(rt *object.Runtime, args ...object.Object) object.Object {
 	if len(args) != 2 {
 		return &object.Error{Message: fmt.Sprintf("sorong() butuh 2 argumen, dapat %d", len(args))}
 	}
@@ -153,6 +242,10 @@ func builtinSorong(args ...object.Object) object.Object {
 		return &object.Error{Message: "argumen pertama sorong() harus daftar"}
 	}
 
+	// sorong copies the array, so the whole new array counts
+	if errObj := rt.Allocate(object.ArraySize(len(arr.Elements) + 1)); errObj != nil {
+		return errObj
+	}
 	newElements := make([]object.Object, len(arr.Elements)+1)
 	copy(newElements, arr.Elements)
 	newElements[len(arr.Elements)] = args[1]
@@ -161,7 +254,7 @@ func builtinSorong(args ...object.Object) object.Object {
 }
 
 // builtinBait returns an element from array or map (get)
-func builtinBait(args ...object.Object) object.Object {
+func builtinBait(rt *object.Runtime, args ...object.Object) object.Object {
 	if len(args) != 2 {
 		return &object.Error{Message: fmt.Sprintf("bait() butuh 2 argumen (koleksi, indeks/kunci), dapat %d", len(args))}
 	}
@@ -173,34 +266,28 @@ func builtinBait(args ...object.Object) object.Object {
 			return &object.Error{Message: "indeks harus angka"}
 		}
 		if idx.Value < 0 || idx.Value >= int64(len(container.Elements)) {
-			return &object.Null{}
+			return object.NULL
 		}
 		return container.Elements[idx.Value]
 
 	case *object.Map:
-		key, ok := args[1].(object.Hashable)
-		if !ok {
+		if _, ok := object.HashOf(args[1]); !ok {
 			return &object.Error{Message: "kunci peta tidak valid"}
 		}
-		pair, ok := container.Pairs[key.HashKey()]
+		val, ok := container.Get(args[1])
 		if !ok {
-			return &object.Null{}
+			return object.NULL
 		}
-		return pair.Value
+		return val
 
 	default:
 		return &object.Error{Message: "argumen pertama harus daftar atau peta"}
 	}
 }
 
-// builtinNgatur sets an element in array or map (set) - returns the modified collection
-// Note: SasakLang objects are immutable by default in implementation (except map/array internal pointers),
-// but builtins usually return new objects or modifying them?
-// The existing implementation of `evalIndexExpression` supports reading.
-// Writing via index `arr[0] = 1` involves `evalAssignmentExpression` but that likely needs AST support for index assignment which parser might not handle as "assignment" (only IDENT).
-// Parser `parseAssignmentExpression` takes an identifier.
-// So `ngatur` is essential for modifying arrays/maps if syntax doesn't support `arr[i] = val`.
-func builtinNgatur(args ...object.Object) object.Object {
+// builtinNgatur sets an element in array or map (set) - returns the modified collection.
+// It mutates the collection in place, like the `koleksi[kunci] = nilai` syntax.
+func builtinNgatur(rt *object.Runtime, args ...object.Object) object.Object {
 	if len(args) != 3 {
 		return &object.Error{Message: fmt.Sprintf("ngatur() butuh 3 argumen (koleksi, indeks/kunci, nilai), dapat %d", len(args))}
 	}
@@ -212,7 +299,6 @@ func builtinNgatur(args ...object.Object) object.Object {
 			return &object.Error{Message: "indeks harus angka"}
 		}
 
-		// For simplicity, we modify correctly. If index out of bounds, maybe grow or error?
 		if idx.Value < 0 || idx.Value >= int64(len(container.Elements)) {
 			return &object.Error{Message: "indeks di luar batas"}
 		}
@@ -221,14 +307,79 @@ func builtinNgatur(args ...object.Object) object.Object {
 		return container
 
 	case *object.Map:
-		key, ok := args[1].(object.Hashable)
-		if !ok {
+		if _, ok := object.HashOf(args[1]); !ok {
 			return &object.Error{Message: "kunci peta tidak valid"}
 		}
-		container.Pairs[key.HashKey()] = object.MapPair{Key: args[1], Value: args[2]} // Mutation
+		if _, ok := container.Get(args[1]); !ok {
+			if errObj := rt.Allocate(object.MapPairSize); errObj != nil {
+				return errObj
+			}
+		}
+		container.Set(args[1], args[2]) // Mutation
 		return container
 
 	default:
 		return &object.Error{Message: "argumen pertama harus daftar atau peta"}
 	}
 }
+
+// ordinals names argument positions in error messages
+var ordinals = []string{"pertama", "kedua", "ketiga", "keempat"}
+
+// ordinal names the argument at index i, as "ke-5" past the named ones
+func ordinal(i int) string {
+	if i < len(ordinals) {
+		return ordinals[i]
+	}
+	return fmt.Sprintf("ke-%d", i+1)
+}
+
+// checkArgCount returns an error unless name got between min and max args
+func checkArgCount(name string, args []object.Object, min, max int) *object.Error {
+	if len(args) >= min && len(args) <= max {
+		return nil
+	}
+	if min == max {
+		return &object.Error{Message: fmt.Sprintf("%s() butuh %d argumen, dapat %d", name, min, len(args))}
+	}
+	return &object.Error{Message: fmt.Sprintf("%s() butuh %d sampai %d argumen, dapat %d", name, min, max, len(args))}
+}
+
+// stringArg returns args[i] as a Go string, or an error naming the function
+func stringArg(name string, args []object.Object, i int) (string, *object.Error) {
+	str, ok := args[i].(*object.String)
+	if !ok {
+		return "", &object.Error{Message: fmt.Sprintf("argumen %s %s() harus teks, dapat %s", ordinal(i), name, args[i].Type())}
+	}
+	return str.Value, nil
+}
+
+// intArg returns args[i] as a Go int64, or an error naming the function
+func intArg(name string, args []object.Object, i int) (int64, *object.Error) {
+	n, ok := args[i].(*object.Integer)
+	if _, tooBig := args[i].(*object.BigInteger); tooBig {
+		return 0, &object.Error{Message: fmt.Sprintf("argumen %s %s() terlalu besar", ordinal(i), name)}
+	}
+	if !ok {
+		return 0, &object.Error{Message: fmt.Sprintf("argumen %s %s() harus angka, dapat %s", ordinal(i), name, args[i].Type())}
+	}
+	return n.Value, nil
+}
+
+// arrayArg returns args[i] as an array, or an error naming the function
+func arrayArg(name string, args []object.Object, i int) (*object.Array, *object.Error) {
+	arr, ok := args[i].(*object.Array)
+	if !ok {
+		return nil, &object.Error{Message: fmt.Sprintf("argumen %s %s() harus daftar, dapat %s", ordinal(i), name, args[i].Type())}
+	}
+	return arr, nil
+}
+
+// funcArg returns an error unless args[i] can be called
+func funcArg(name string, args []object.Object, i int) *object.Error {
+	switch args[i].(type) {
+	case *object.Function, *object.Closure, *object.Builtin:
+		return nil
+	}
+	return &object.Error{Message: fmt.Sprintf("argumen %s %s() harus fungsi, dapat %s", ordinal(i), name, args[i].Type())}
+}
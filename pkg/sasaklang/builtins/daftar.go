@@ -0,0 +1,336 @@
+package builtins
+
+import (
+	"fmt"
+	"math/big"
+	"sort"
+
+	"github.com/arjunaayasa/sasaklang/pkg/sasaklang/object"
+)
+
+// Array functions. They never change the arrays they are given; functions
+// that return an array return a new one. Functions passed to them are
+// called with one element at a time.
+
+// newArray allocates and returns an array holding a copy of elements
+func newArray(rt *object.Runtime, elements []object.Object) object.Object {
+	if errObj := rt.Allocate(object.ArraySize(len(elements))); errObj != nil {
+		return errObj
+	}
+	copied := make([]object.Object, len(elements))
+	copy(copied, elements)
+	return &object.Array{Elements: copied}
+}
+
+// arrayAndFunc checks the arguments of the builtins that take an array and
+// a function, and returns the array
+func arrayAndFunc(name string, args []object.Object) (*object.Array, *object.Error) {
+	if errObj := checkArgCount(name, args, 2, 2); errObj != nil {
+		return nil, errObj
+	}
+	arr, errObj := arrayArg(name, args, 0)
+	if errObj != nil {
+		return nil, errObj
+	}
+	if errObj := funcArg(name, args, 1); errObj != nil {
+		return nil, errObj
+	}
+	return arr, nil
+}
+
+// builtinPetakan returns the results of calling fn on each element (map)
+func builtinPetakan(rt *object.Runtime, args ...object.Object) object.Object {
+	arr, errObj := arrayAndFunc("petakan", args)
+	if errObj != nil {
+		return errObj
+	}
+
+	// Copy first: fn may change the array while we go through it
+	elements := append([]object.Object(nil), arr.Elements...)
+	for i, el := range elements {
+		result := rt.Call(args[1], el)
+		if isError(result) {
+			return result
+		}
+		elements[i] = result
+	}
+	return newArray(rt, elements)
+}
+
+// builtinSaring returns the elements for which fn returns a truthy value
+// (filter)
+func builtinSaring(rt *object.Runtime, args ...object.Object) object.Object {
+	arr, errObj := arrayAndFunc("saring", args)
+	if errObj != nil {
+		return errObj
+	}
+
+	var kept []object.Object
+	for _, el := range append([]object.Object(nil), arr.Elements...) {
+		result := rt.Call(args[1], el)
+		if isError(result) {
+			return result
+		}
+		if isTruthy(result) {
+			kept = append(kept, el)
+		}
+	}
+	return newArray(rt, kept)
+}
+
+// builtinLipat combines the elements into one value by calling
+// fn(hasil, elemen) for each of them (reduce). The first result is the
+// initial value if one is given, and the first element otherwise.
+func builtinLipat(rt *object.Runtime, args ...object.Object) object.Object {
+	if errObj := checkArgCount("lipat", args, 2, 3); errObj != nil {
+		return errObj
+	}
+	arr, errObj := arrayArg("lipat", args, 0)
+	if errObj != nil {
+		return errObj
+	}
+	if errObj := funcArg("lipat", args, 1); errObj != nil {
+		return errObj
+	}
+
+	elements := append([]object.Object(nil), arr.Elements...)
+	var acc object.Object
+	if len(args) == 3 {
+		acc = args[2]
+	} else {
+		if len(elements) == 0 {
+			return &object.Error{Message: "lipat() dari daftar kosong butuh nilai awal"}
+		}
+		acc, elements = elements[0], elements[1:]
+	}
+
+	for _, el := range elements {
+		acc = rt.Call(args[1], acc, el)
+		if isError(acc) {
+			return acc
+		}
+	}
+	return acc
+}
+
+// builtinTemukan returns the first element for which fn returns a truthy
+// value, or ndarak (find)
+func builtinTemukan(rt *object.Runtime, args ...object.Object) object.Object {
+	arr, errObj := arrayAndFunc("temukan", args)
+	if errObj != nil {
+		return errObj
+	}
+
+	for _, el := range append([]object.Object(nil), arr.Elements...) {
+		result := rt.Call(args[1], el)
+		if isError(result) {
+			return result
+		}
+		if isTruthy(result) {
+			return el
+		}
+	}
+	return object.NULL
+}
+
+// builtinAda reports whether fn returns a truthy value for any element
+// (any)
+func builtinAda(rt *object.Runtime, args ...object.Object) object.Object {
+	return matchElements(rt, "ada", args, true)
+}
+
+// builtinSemua reports whether fn returns a truthy value for all elements
+// (all)
+func builtinSemua(rt *object.Runtime, args ...object.Object) object.Object {
+	return matchElements(rt, "semua", args, false)
+}
+
+// matchElements calls fn on the elements until its result is as truthy as
+// stopOn, and returns whether it stopped early (for ada) or not (for semua)
+func matchElements(rt *object.Runtime, name string, args []object.Object, stopOn bool) object.Object {
+	arr, errObj := arrayAndFunc(name, args)
+	if errObj != nil {
+		return errObj
+	}
+
+	for _, el := range append([]object.Object(nil), arr.Elements...) {
+		result := rt.Call(args[1], el)
+		if isError(result) {
+			return result
+		}
+		if isTruthy(result) == stopOn {
+			return object.NativeBool(stopOn)
+		}
+	}
+	return object.NativeBool(!stopOn)
+}
+
+// builtinUrutkan returns the elements sorted, keeping equal elements in
+// their order. Without a comparator numbers and strings are sorted in
+// ascending order. A comparator fn(a, b) returns a negative number when a
+// comes before b, a positive one when it comes after b, and 0 otherwise.
+func builtinUrutkan(rt *object.Runtime, args ...object.Object) object.Object {
+	if errObj := checkArgCount("urutkan", args, 1, 2); errObj != nil {
+		return errObj
+	}
+	arr, errObj := arrayArg("urutkan", args, 0)
+	if errObj != nil {
+		return errObj
+	}
+	if len(args) == 2 {
+		if errObj := funcArg("urutkan", args, 1); errObj != nil {
+			return errObj
+		}
+	}
+
+	sorted := newArray(rt, arr.Elements)
+	if isError(sorted) {
+		return sorted
+	}
+	elements := sorted.(*object.Array).Elements
+
+	// sort cannot be stopped, so after an error the remaining comparisons
+	// do nothing and the error is returned at the end
+	var sortErr object.Object
+	sort.SliceStable(elements, func(i, j int) bool {
+		if sortErr != nil {
+			return false
+		}
+		var order int
+		if len(args) == 2 {
+			order, sortErr = compareWith(rt, args[1], elements[i], elements[j])
+		} else {
+			order, sortErr = compareValues(elements[i], elements[j])
+		}
+		return sortErr == nil && order < 0
+	})
+	if sortErr != nil {
+		return sortErr
+	}
+	return sorted
+}
+
+// compareWith orders a and b using the comparator fn
+func compareWith(rt *object.Runtime, fn, a, b object.Object) (int, object.Object) {
+	result := rt.Call(fn, a, b)
+	switch result := result.(type) {
+	case *object.Error:
+		return 0, result
+	case *object.Integer:
+		return sign(float64(result.Value)), nil
+	case *object.BigInteger:
+		return result.Value.Sign(), nil
+	case *object.Float:
+		return sign(result.Value), nil
+	default:
+		return 0, &object.Error{Message: fmt.Sprintf("pembanding urutkan() harus mengembalikan angka, dapat %s", result.Type())}
+	}
+}
+
+// compareValues orders two numbers or two strings
+func compareValues(a, b object.Object) (int, object.Object) {
+	if x, ok := a.(*object.String); ok {
+		if y, ok := b.(*object.String); ok {
+			switch {
+			case x.Value < y.Value:
+				return -1, nil
+			case x.Value > y.Value:
+				return 1, nil
+			}
+			return 0, nil
+		}
+	}
+	// Integers are compared exactly, big ones included
+	if x, ok := object.BigValue(a); ok {
+		if y, ok := object.BigValue(b); ok {
+			return x.Cmp(y), nil
+		}
+	}
+	x, okA := toFloat(a)
+	y, okB := toFloat(b)
+	if !okA || !okB {
+		return 0, &object.Error{Message: fmt.Sprintf("urutkan() tidak bisa membandingkan %s dengan %s", a.Type(), b.Type())}
+	}
+	return sign(x - y), nil
+}
+
+func sign(x float64) int {
+	switch {
+	case x < 0:
+		return -1
+	case x > 0:
+		return 1
+	}
+	return 0
+}
+
+// toFloat converts an integer or float to a float64
+func toFloat(obj object.Object) (float64, bool) {
+	switch obj := obj.(type) {
+	case *object.Integer:
+		return float64(obj.Value), true
+	case *object.BigInteger:
+		f, _ := new(big.Float).SetInt(obj.Value).Float64()
+		return f, true
+	case *object.Float:
+		return obj.Value, true
+	}
+	return 0, false
+}
+
+// builtinBalik returns the elements of an array, or the characters of a
+// string, in reverse order
+func builtinBalik(rt *object.Runtime, args ...object.Object) object.Object {
+	if errObj := checkArgCount("balik", args, 1, 1); errObj != nil {
+		return errObj
+	}
+
+	switch arg := args[0].(type) {
+	case *object.Array:
+		reversed := newArray(rt, arg.Elements)
+		if arr, ok := reversed.(*object.Array); ok {
+			for i, j := 0, len(arr.Elements)-1; i < j; i, j = i+1, j-1 {
+				arr.Elements[i], arr.Elements[j] = arr.Elements[j], arr.Elements[i]
+			}
+		}
+		return reversed
+	case *object.String:
+		runes := []rune(arg.Value)
+		for i, j := 0, len(runes)-1; i < j; i, j = i+1, j-1 {
+			runes[i], runes[j] = runes[j], runes[i]
+		}
+		return newString(rt, string(runes))
+	default:
+		return &object.Error{Message: fmt.Sprintf("argumen pertama balik() harus daftar atau teks, dapat %s", arg.Type())}
+	}
+}
+
+// builtinSambung returns one array holding the elements of all the given
+// arrays, in order (concat)
+func builtinSambung(rt *object.Runtime, args ...object.Object) object.Object {
+	var elements []object.Object
+	for i, arg := range args {
+		arr, ok := arg.(*object.Array)
+		if !ok {
+			return &object.Error{Message: fmt.Sprintf("argumen ke-%d sambung() harus daftar, dapat %s", i+1, arg.Type())}
+		}
+		elements = append(elements, arr.Elements...)
+	}
+	return newArray(rt, elements)
+}
+
+func isError(obj object.Object) bool {
+	_, ok := obj.(*object.Error)
+	return ok
+}
+
+// isTruthy reports whether a value counts as true, like a condition
+func isTruthy(obj object.Object) bool {
+	switch obj := obj.(type) {
+	case *object.Boolean:
+		return obj.Value
+	case *object.Null:
+		return false
+	}
+	return true
+}
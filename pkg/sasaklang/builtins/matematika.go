@@ -0,0 +1,248 @@
+package builtins
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+
+	"github.com/arjunaayasa/sasaklang/pkg/sasaklang/object"
+)
+
+// Matematika (math) functions. They take integers, big integers and floats
+// alike. Functions whose result is exact for integers, such as mutlak() and
+// pangkat(), return an integer for integer arguments; the others return a
+// float.
+
+// Constants contains the builtin constants
+var Constants = map[string]object.Object{
+	"PI": &object.Float{Value: math.Pi},
+	"E":  &object.Float{Value: math.E},
+}
+
+// numberArg returns args[i] as a float64, or an error if it is not a number
+func numberArg(name string, args []object.Object, i int) (float64, *object.Error) {
+	f, ok := toFloat(args[i])
+	if !ok {
+		return 0, &object.Error{Message: fmt.Sprintf("argumen %s %s() harus angka, dapat %s", ordinal(i), name, args[i].Type())}
+	}
+	return f, nil
+}
+
+// integerArg returns args[i] as a big.Int, or an error if it is not an
+// integer
+func integerArg(name string, args []object.Object, i int) (*big.Int, *object.Error) {
+	n, ok := object.BigValue(args[i])
+	if !ok {
+		return nil, &object.Error{Message: fmt.Sprintf("argumen %s %s() harus bilangan bulat, dapat %s", ordinal(i), name, args[i].Type())}
+	}
+	return n, nil
+}
+
+// floatFunction builds the builtins that apply a float function to one
+// number
+func floatFunction(name string, fn func(float64) float64) object.BuiltinFunction {
+	return func(rt *object.Runtime, args ...object.Object) object.Object {
+		if errObj := checkArgCount(name, args, 1, 1); errObj != nil {
+			return errObj
+		}
+		x, errObj := numberArg(name, args, 0)
+		if errObj != nil {
+			return errObj
+		}
+		return &object.Float{Value: fn(x)}
+	}
+}
+
+// builtinMutlak returns the absolute value of a number (abs)
+func builtinMutlak(rt *object.Runtime, args ...object.Object) object.Object {
+	if errObj := checkArgCount("mutlak", args, 1, 1); errObj != nil {
+		return errObj
+	}
+	if n, ok := object.BigValue(args[0]); ok {
+		return object.NewInteger(n.Abs(n))
+	}
+	x, errObj := numberArg("mutlak", args, 0)
+	if errObj != nil {
+		return errObj
+	}
+	return &object.Float{Value: math.Abs(x)}
+}
+
+// extremum builds terkecil() and terbesar(), which return the smallest or
+// largest of their arguments, or of the elements of an array given alone.
+// want is the result of compareValues that makes a number the new extreme.
+func extremum(name string, want int) object.BuiltinFunction {
+	return func(rt *object.Runtime, args ...object.Object) object.Object {
+		if len(args) == 0 {
+			return &object.Error{Message: fmt.Sprintf("%s() butuh paling sedikit 1 argumen", name)}
+		}
+		numbers := args
+		if arr, ok := args[0].(*object.Array); ok && len(args) == 1 {
+			numbers = arr.Elements
+			if len(numbers) == 0 {
+				return &object.Error{Message: fmt.Sprintf("%s() tidak bisa dipakai pada daftar kosong", name)}
+			}
+		}
+
+		var result object.Object
+		for _, n := range numbers {
+			if _, ok := toFloat(n); !ok {
+				return &object.Error{Message: fmt.Sprintf("%s() butuh angka, dapat %s", name, n.Type())}
+			}
+			if result == nil {
+				result = n
+				continue
+			}
+			if order, _ := compareValues(n, result); order == want {
+				result = n
+			}
+		}
+		return result
+	}
+}
+
+// maxPowerBits limits the size of the exact results of pangkat(), even
+// without a memory limit: big.Int.Exp cannot be stopped by the run's
+// context, and results of this size take milliseconds
+const maxPowerBits = 1 << 21
+
+// builtinPangkat returns x to the power y (pow). An integer to a
+// non-negative integer power is computed exactly.
+func builtinPangkat(rt *object.Runtime, args ...object.Object) object.Object {
+	if errObj := checkArgCount("pangkat", args, 2, 2); errObj != nil {
+		return errObj
+	}
+	base, baseInt := object.BigValue(args[0])
+	exp, expInt := args[1].(*object.Integer)
+	if baseInt && expInt && exp.Value >= 0 {
+		// The result has at most this many bits; 0, 1 and -1 stay small
+		bits := float64(base.BitLen()) * float64(exp.Value)
+		if base.BitLen() <= 1 {
+			bits = 1
+		}
+		if bits > maxPowerBits {
+			return &object.Error{Message: fmt.Sprintf("hasil pangkat() terlalu besar: lebih dari %d bit", maxPowerBits)}
+		}
+		if errObj := rt.Allocate(object.BigIntegerSize(int(bits))); errObj != nil {
+			return errObj
+		}
+		return object.NewInteger(base.Exp(base, big.NewInt(exp.Value), nil))
+	}
+
+	x, errObj := numberArg("pangkat", args, 0)
+	if errObj != nil {
+		return errObj
+	}
+	y, errObj := numberArg("pangkat", args, 1)
+	if errObj != nil {
+		return errObj
+	}
+	return &object.Float{Value: math.Pow(x, y)}
+}
+
+// rounding builds the builtins that round a number to an integer. Integers
+// are returned as they are.
+func rounding(name string, round func(float64) float64) object.BuiltinFunction {
+	return func(rt *object.Runtime, args ...object.Object) object.Object {
+		if errObj := checkArgCount(name, args, 1, 1); errObj != nil {
+			return errObj
+		}
+		if _, ok := object.BigValue(args[0]); ok {
+			return args[0]
+		}
+		x, errObj := numberArg(name, args, 0)
+		if errObj != nil {
+			return errObj
+		}
+
+		x = round(x)
+		if math.IsNaN(x) || math.IsInf(x, 0) {
+			return &object.Error{Message: fmt.Sprintf("%s() tidak bisa membulatkan %s", name, args[0].Inspect())}
+		}
+		n, _ := big.NewFloat(x).Int(nil)
+		return object.NewInteger(n)
+	}
+}
+
+// builtinAtan2 returns the angle of the point (x, y), in radians
+func builtinAtan2(rt *object.Runtime, args ...object.Object) object.Object {
+	if errObj := checkArgCount("atan2", args, 2, 2); errObj != nil {
+		return errObj
+	}
+	y, errObj := numberArg("atan2", args, 0)
+	if errObj != nil {
+		return errObj
+	}
+	x, errObj := numberArg("atan2", args, 1)
+	if errObj != nil {
+		return errObj
+	}
+	return &object.Float{Value: math.Atan2(y, x)}
+}
+
+// builtinFpb returns the greatest common divisor of two integers (gcd),
+// which is never negative
+func builtinFpb(rt *object.Runtime, args ...object.Object) object.Object {
+	a, b, errObj := integerPair("fpb", args)
+	if errObj != nil {
+		return errObj
+	}
+	return object.NewInteger(new(big.Int).GCD(nil, nil, a.Abs(a), b.Abs(b)))
+}
+
+// builtinKpk returns the least common multiple of two integers (lcm), which
+// is never negative
+func builtinKpk(rt *object.Runtime, args ...object.Object) object.Object {
+	a, b, errObj := integerPair("kpk", args)
+	if errObj != nil {
+		return errObj
+	}
+	if a.Sign() == 0 || b.Sign() == 0 {
+		return object.NewInteger(new(big.Int))
+	}
+	a.Abs(a)
+	b.Abs(b)
+	gcd := new(big.Int).GCD(nil, nil, a, b)
+	return object.NewInteger(a.Mul(a.Quo(a, gcd), b))
+}
+
+// integerPair checks the arguments of fpb() and kpk()
+func integerPair(name string, args []object.Object) (*big.Int, *big.Int, *object.Error) {
+	if errObj := checkArgCount(name, args, 2, 2); errObj != nil {
+		return nil, nil, errObj
+	}
+	a, errObj := integerArg(name, args, 0)
+	if errObj != nil {
+		return nil, nil, errObj
+	}
+	b, errObj := integerArg(name, args, 1)
+	if errObj != nil {
+		return nil, nil, errObj
+	}
+	return a, b, nil
+}
+
+// builtinBatasi returns x kept between lo and hi (clamp): lo if x is
+// smaller, hi if x is larger, and x otherwise
+func builtinBatasi(rt *object.Runtime, args ...object.Object) object.Object {
+	if errObj := checkArgCount("batasi", args, 3, 3); errObj != nil {
+		return errObj
+	}
+	for i := range args {
+		if _, errObj := numberArg("batasi", args, i); errObj != nil {
+			return errObj
+		}
+	}
+
+	x, lo, hi := args[0], args[1], args[2]
+	if order, _ := compareValues(lo, hi); order > 0 {
+		return &object.Error{Message: fmt.Sprintf("batas bawah batasi() lebih besar dari batas atas: %s > %s", lo.Inspect(), hi.Inspect())}
+	}
+	if order, _ := compareValues(x, lo); order < 0 {
+		return lo
+	}
+	if order, _ := compareValues(x, hi); order > 0 {
+		return hi
+	}
+	return x
+}
@@ -0,0 +1,102 @@
+package builtins
+
+import (
+	"fmt"
+
+	"github.com/arjunaayasa/sasaklang/pkg/sasaklang/object"
+)
+
+// Peta (map) functions. Maps keep their entries in the order the keys were
+// first added, and these functions return them in that order.
+
+// mapArg returns args[i] as a map, or an error if it is not one
+func mapArg(name string, args []object.Object, i int) (*object.Map, *object.Error) {
+	m, ok := args[i].(*object.Map)
+	if !ok {
+		return nil, &object.Error{Message: fmt.Sprintf("argumen %s %s() harus peta, dapat %s", ordinal(i), name, args[i].Type())}
+	}
+	return m, nil
+}
+
+// keyArg returns an error unless args[i] can be used as a map key
+func keyArg(name string, args []object.Object, i int) *object.Error {
+	if _, ok := object.HashOf(args[i]); !ok {
+		return &object.Error{Message: fmt.Sprintf("argumen %s %s() tidak bisa digunakan sebagai kunci peta, dapat %s", ordinal(i), name, args[i].Type())}
+	}
+	return nil
+}
+
+// mapEntries builds the builtins that return one value for each entry of
+// a map
+func mapEntries(name string, entry func(rt *object.Runtime, pair object.MapPair) object.Object) object.BuiltinFunction {
+	return func(rt *object.Runtime, args ...object.Object) object.Object {
+		if errObj := checkArgCount(name, args, 1, 1); errObj != nil {
+			return errObj
+		}
+		m, errObj := mapArg(name, args, 0)
+		if errObj != nil {
+			return errObj
+		}
+
+		pairs := m.Pairs()
+		elements := make([]object.Object, len(pairs))
+		for i, pair := range pairs {
+			el := entry(rt, pair)
+			if isError(el) {
+				return el
+			}
+			elements[i] = el
+		}
+		return newArray(rt, elements)
+	}
+}
+
+// builtinKunci returns the keys of a map (keys)
+var builtinKunci = mapEntries("kunci", func(rt *object.Runtime, pair object.MapPair) object.Object {
+	return pair.Key
+})
+
+// builtinNilai returns the values of a map (values)
+var builtinNilai = mapEntries("nilai", func(rt *object.Runtime, pair object.MapPair) object.Object {
+	return pair.Value
+})
+
+// builtinPasangan returns the entries of a map as [kunci, nilai] arrays
+// (entries)
+var builtinPasangan = mapEntries("pasangan", func(rt *object.Runtime, pair object.MapPair) object.Object {
+	return newArray(rt, []object.Object{pair.Key, pair.Value})
+})
+
+// builtinPunyaKunci reports whether a map has an entry for a key (has key)
+func builtinPunyaKunci(rt *object.Runtime, args ...object.Object) object.Object {
+	if errObj := checkArgCount("punya_kunci", args, 2, 2); errObj != nil {
+		return errObj
+	}
+	m, errObj := mapArg("punya_kunci", args, 0)
+	if errObj != nil {
+		return errObj
+	}
+	if errObj := keyArg("punya_kunci", args, 1); errObj != nil {
+		return errObj
+	}
+
+	_, ok := m.Get(args[1])
+	return object.NativeBool(ok)
+}
+
+// builtinHapus removes the entry for a key from a map, in place, and
+// reports whether there was one (delete)
+func builtinHapus(rt *object.Runtime, args ...object.Object) object.Object {
+	if errObj := checkArgCount("hapus", args, 2, 2); errObj != nil {
+		return errObj
+	}
+	m, errObj := mapArg("hapus", args, 0)
+	if errObj != nil {
+		return errObj
+	}
+	if errObj := keyArg("hapus", args, 1); errObj != nil {
+		return errObj
+	}
+
+	return object.NativeBool(m.Delete(args[1]))
+}
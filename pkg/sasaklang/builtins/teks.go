@@ -0,0 +1,301 @@
+package builtins
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/arjunaayasa/sasaklang/pkg/sasaklang/object"
+)
+
+// String functions. Positions and lengths count characters (runes), not
+// bytes, so "é" has length 1 just as "e" does.
+
+// newString allocates and returns a string value
+func newString(rt *object.Runtime, s string) object.Object {
+	if errObj := rt.Allocate(object.StringSize(len(s))); errObj != nil {
+		return errObj
+	}
+	return &object.String{Value: s}
+}
+
+// newStringArray allocates and returns an array of string values
+func newStringArray(rt *object.Runtime, strs []string) object.Object {
+	size := object.ArraySize(len(strs))
+	for _, s := range strs {
+		size += object.StringSize(len(s))
+	}
+	if errObj := rt.Allocate(size); errObj != nil {
+		return errObj
+	}
+
+	elements := make([]object.Object, len(strs))
+	for i, s := range strs {
+		elements[i] = &object.String{Value: s}
+	}
+	return &object.Array{Elements: elements}
+}
+
+// builtinPisah splits a string around a separator, around runs of
+// whitespace when there is none, or into characters when it is ""
+func builtinPisah(rt *object.Runtime, args ...object.Object) object.Object {
+	if errObj := checkArgCount("pisah", args, 1, 2); errObj != nil {
+		return errObj
+	}
+	s, errObj := stringArg("pisah", args, 0)
+	if errObj != nil {
+		return errObj
+	}
+
+	if len(args) == 1 {
+		return newStringArray(rt, strings.Fields(s))
+	}
+	sep, errObj := stringArg("pisah", args, 1)
+	if errObj != nil {
+		return errObj
+	}
+	return newStringArray(rt, strings.Split(s, sep))
+}
+
+// builtinGabung joins the elements of an array into one string, with an
+// optional separator between them. Elements that are not strings are
+// written as cetak prints them.
+func builtinGabung(rt *object.Runtime, args ...object.Object) object.Object {
+	if errObj := checkArgCount("gabung", args, 1, 2); errObj != nil {
+		return errObj
+	}
+	arr, errObj := arrayArg("gabung", args, 0)
+	if errObj != nil {
+		return errObj
+	}
+
+	sep := ""
+	if len(args) == 2 {
+		if sep, errObj = stringArg("gabung", args, 1); errObj != nil {
+			return errObj
+		}
+	}
+
+	text, errObj := rt.AllocateText(arr.Elements, sep)
+	if errObj != nil {
+		return errObj
+	}
+	return &object.String{Value: text}
+}
+
+// builtinRapikan removes whitespace, or the given characters, from both
+// ends of a string
+func builtinRapikan(rt *object.Runtime, args ...object.Object) object.Object {
+	if errObj := checkArgCount("rapikan", args, 1, 2); errObj != nil {
+		return errObj
+	}
+	s, errObj := stringArg("rapikan", args, 0)
+	if errObj != nil {
+		return errObj
+	}
+
+	if len(args) == 1 {
+		return newString(rt, strings.TrimSpace(s))
+	}
+	cutset, errObj := stringArg("rapikan", args, 1)
+	if errObj != nil {
+		return errObj
+	}
+	return newString(rt, strings.Trim(s, cutset))
+}
+
+// builtinHurufBesar converts a string to upper case
+func builtinHurufBesar(rt *object.Runtime, args ...object.Object) object.Object {
+	if errObj := checkArgCount("huruf_besar", args, 1, 1); errObj != nil {
+		return errObj
+	}
+	s, errObj := stringArg("huruf_besar", args, 0)
+	if errObj != nil {
+		return errObj
+	}
+	return newString(rt, strings.ToUpper(s))
+}
+
+// builtinHurufKecil converts a string to lower case
+func builtinHurufKecil(rt *object.Runtime, args ...object.Object) object.Object {
+	if errObj := checkArgCount("huruf_kecil", args, 1, 1); errObj != nil {
+		return errObj
+	}
+	s, errObj := stringArg("huruf_kecil", args, 0)
+	if errObj != nil {
+		return errObj
+	}
+	return newString(rt, strings.ToLower(s))
+}
+
+// stringPredicate builds a builtin that tests a string against another one
+func stringPredicate(name string, test func(s, part string) bool) object.BuiltinFunction {
+	return func(rt *object.Runtime, args ...object.Object) object.Object {
+		if errObj := checkArgCount(name, args, 2, 2); errObj != nil {
+			return errObj
+		}
+		s, errObj := stringArg(name, args, 0)
+		if errObj != nil {
+			return errObj
+		}
+		part, errObj := stringArg(name, args, 1)
+		if errObj != nil {
+			return errObj
+		}
+		return object.NativeBool(test(s, part))
+	}
+}
+
+// builtinPosisi returns the character position of the first occurrence of
+// a substring, or -1 when there is none
+func builtinPosisi(rt *object.Runtime, args ...object.Object) object.Object {
+	if errObj := checkArgCount("posisi", args, 2, 2); errObj != nil {
+		return errObj
+	}
+	s, errObj := stringArg("posisi", args, 0)
+	if errObj != nil {
+		return errObj
+	}
+	part, errObj := stringArg("posisi", args, 1)
+	if errObj != nil {
+		return errObj
+	}
+
+	i := strings.Index(s, part)
+	if i < 0 {
+		return &object.Integer{Value: -1}
+	}
+	return &object.Integer{Value: int64(utf8.RuneCountInString(s[:i]))}
+}
+
+// builtinGanti replaces occurrences of a substring: all of them, or only
+// the first n when n is given
+func builtinGanti(rt *object.Runtime, args ...object.Object) object.Object {
+	if errObj := checkArgCount("ganti", args, 3, 4); errObj != nil {
+		return errObj
+	}
+	var strs [3]string
+	for i := range strs {
+		s, errObj := stringArg("ganti", args, i)
+		if errObj != nil {
+			return errObj
+		}
+		strs[i] = s
+	}
+
+	n := int64(-1)
+	if len(args) == 4 {
+		var errObj *object.Error
+		if n, errObj = intArg("ganti", args, 3); errObj != nil {
+			return errObj
+		}
+	}
+	// The result's size is known from the number of replacements, so it is
+	// allocated before the string is built
+	count := int64(strings.Count(strs[0], strs[1]))
+	if n >= 0 && n < count {
+		count = n
+	}
+	size := int64(len(strs[0])) + count*(int64(len(strs[2]))-int64(len(strs[1])))
+	if errObj := rt.Allocate(object.StringSize(0) + size); errObj != nil {
+		return errObj
+	}
+	return &object.String{Value: strings.Replace(strs[0], strs[1], strs[2], int(n))}
+}
+
+// builtinIris returns the characters of a string, or the elements of an
+// array, from start up to, but not including, end. A negative position
+// counts from the end, and positions beyond either end are clamped.
+func builtinIris(rt *object.Runtime, args ...object.Object) object.Object {
+	if errObj := checkArgCount("iris", args, 2, 3); errObj != nil {
+		return errObj
+	}
+
+	var length int
+	var runes []rune
+	switch arg := args[0].(type) {
+	case *object.String:
+		runes = []rune(arg.Value)
+		length = len(runes)
+	case *object.Array:
+		length = len(arg.Elements)
+	default:
+		return &object.Error{Message: fmt.Sprintf("argumen pertama iris() harus teks atau daftar, dapat %s", arg.Type())}
+	}
+
+	start, errObj := intArg("iris", args, 1)
+	if errObj != nil {
+		return errObj
+	}
+	end := int64(length)
+	if len(args) == 3 {
+		if end, errObj = intArg("iris", args, 2); errObj != nil {
+			return errObj
+		}
+	}
+	start, end = clampIndex(start, length), clampIndex(end, length)
+	if start > end {
+		end = start
+	}
+
+	if arr, ok := args[0].(*object.Array); ok {
+		return newArray(rt, arr.Elements[start:end])
+	}
+	return newString(rt, string(runes[start:end]))
+}
+
+// clampIndex turns a possibly negative position into one within 0..length
+func clampIndex(i int64, length int) int64 {
+	if i < 0 {
+		i += int64(length)
+	}
+	if i < 0 {
+		return 0
+	}
+	if i > int64(length) {
+		return int64(length)
+	}
+	return i
+}
+
+// builtinUlang repeats a string n times
+func builtinUlang(rt *object.Runtime, args ...object.Object) object.Object {
+	if errObj := checkArgCount("ulang", args, 2, 2); errObj != nil {
+		return errObj
+	}
+	s, errObj := stringArg("ulang", args, 0)
+	if errObj != nil {
+		return errObj
+	}
+	n, errObj := intArg("ulang", args, 1)
+	if errObj != nil {
+		return errObj
+	}
+	if n < 0 {
+		return &object.Error{Message: "argumen kedua ulang() tidak boleh negatif"}
+	}
+
+	// Check the size before building the string, which could be huge
+	if len(s) > 0 && n > int64(maxStringLen/len(s)) {
+		return &object.Error{Message: "hasil ulang() terlalu panjang"}
+	}
+	if errObj := rt.Allocate(object.StringSize(len(s) * int(n))); errObj != nil {
+		return errObj
+	}
+	return &object.String{Value: strings.Repeat(s, int(n))}
+}
+
+// maxStringLen is the length of the longest string ulang() builds
+const maxStringLen = 1 << 30
+
+// builtinJumlahHuruf returns the number of characters of a string
+func builtinJumlahHuruf(rt *object.Runtime, args ...object.Object) object.Object {
+	if errObj := checkArgCount("jumlah_huruf", args, 1, 1); errObj != nil {
+		return errObj
+	}
+	s, errObj := stringArg("jumlah_huruf", args, 0)
+	if errObj != nil {
+		return errObj
+	}
+	return &object.Integer{Value: int64(utf8.RuneCountInString(s))}
+}
@@ -0,0 +1,286 @@
+package code
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"sort"
+)
+
+// Instructions is a sequence of encoded bytecode instructions
+type Instructions []byte
+
+// Opcode identifies a single VM instruction
+type Opcode byte
+
+// Opcodes. Comments describe the stack effect: values popped -> values pushed.
+const (
+	OpConstant Opcode = iota // push constants[i]
+	OpPop                    // a ->
+	OpDup                    // a -> a a
+	OpDup2                   // a b -> a b a b
+	OpNip                    // a b -> b
+	OpNull                   // -> ndarak
+	OpTrue                   // -> kenak
+	OpFalse                  // -> salak
+
+	OpAdd          // a b -> a+b
+	OpSub          // a b -> a-b
+	OpMul          // a b -> a*b
+	OpDiv          // a b -> a/b
+	OpMod          // a b -> a%b
+	OpEqual        // a b -> a==b
+	OpNotEqual     // a b -> a!=b
+	OpLess         // a b -> a<b
+	OpLessEqual    // a b -> a<=b
+	OpGreater      // a b -> a>b
+	OpGreaterEqual // a b -> a>=b
+	OpMinus        // a -> -a
+	OpBang         // a -> !a
+	OpIncrement    // a -> a+1
+	OpDecrement    // a -> a-1
+
+	OpJump          // jump to address
+	OpJumpNotTruthy // cond -> ; jump to address if cond is not truthy
+
+	OpIter     // value -> iterator of an ojok ... dalem loop
+	OpIterNext // -> the next element's n loop values, from the iterator below the top; jump to address at the end
+
+	OpGetGlobal    // -> globals[i], falling back to the builtin of that name
+	OpSetGlobal    // a -> ; define globals[i]
+	OpAssignGlobal // a -> ; assign globals[i], which must already be defined
+	OpGetLocal     // -> locals[i]
+	OpSetLocal     // a -> ; locals[i] = a
+	OpGetCell      // -> value of the cell in locals[i]
+	OpSetCell      // a -> ; store into the cell in locals[i]
+	OpDefineCell   // a -> ; like OpSetCell, creating the cell if needed
+	OpLoadCell     // -> the cell in locals[i] itself, for closures
+	OpMakeCell     // wrap the argument in locals[i] into a cell
+	OpClearLocal   // locals[i] = nil, starting a fresh binding
+	OpGetFree      // -> value of free cell i
+	OpSetFree      // a -> ; store into free cell i
+	OpLoadFree     // -> free cell i itself, for closures
+
+	OpArray          // n elements -> array
+	OpMap            // n key/value pairs -> map
+	OpTemplate       // n parts -> string of an interpolated string literal
+	OpIndex          // left index -> left[index]
+	OpIndexForUpdate // container index -> container[index], erroring out of range
+	OpSetIndex       // container index value -> value
+	OpPostfixIndex   // container index -> old value; operand 0 is ++, 1 is --
+
+	OpCall        // fn args... -> result
+	OpReturnValue // a -> ; return a from the current function
+	OpClosure     // free cells... -> closure
+
+	OpTry    // install an error handler jumping to address
+	OpEndTry // remove the innermost error handler
+	OpThrow  // a -> ; raise a (lempar)
+	OpCatch  // error -> error map bound by tangkep
+	OpRaise  // raise constants[i] as an error message
+
+	OpImport // -> module loaded from the path in constants[i]
+)
+
+// Position maps the instruction starting at Offset to a source location
+type Position struct {
+	Offset int
+	Line   int
+	Column int
+}
+
+// FindPosition returns the source location of the instruction at offset,
+// given positions sorted by Offset
+func FindPosition(positions []Position, offset int) (line, column int) {
+	i := sort.Search(len(positions), func(i int) bool {
+		return positions[i].Offset > offset
+	})
+	if i == 0 {
+		return 0, 0
+	}
+	return positions[i-1].Line, positions[i-1].Column
+}
+
+// Definition describes an opcode for encoding and disassembly
+type Definition struct {
+	Name          string
+	OperandWidths []int
+}
+
+var definitions = map[Opcode]*Definition{
+	OpConstant: {"OpConstant", []int{2}},
+	OpPop:      {"OpPop", []int{}},
+	OpDup:      {"OpDup", []int{}},
+	OpDup2:     {"OpDup2", []int{}},
+	OpNip:      {"OpNip", []int{}},
+	OpNull:     {"OpNull", []int{}},
+	OpTrue:     {"OpTrue", []int{}},
+	OpFalse:    {"OpFalse", []int{}},
+
+	OpAdd:          {"OpAdd", []int{}},
+	OpSub:          {"OpSub", []int{}},
+	OpMul:          {"OpMul", []int{}},
+	OpDiv:          {"OpDiv", []int{}},
+	OpMod:          {"OpMod", []int{}},
+	OpEqual:        {"OpEqual", []int{}},
+	OpNotEqual:     {"OpNotEqual", []int{}},
+	OpLess:         {"OpLess", []int{}},
+	OpLessEqual:    {"OpLessEqual", []int{}},
+	OpGreater:      {"OpGreater", []int{}},
+	OpGreaterEqual: {"OpGreaterEqual", []int{}},
+	OpMinus:        {"OpMinus", []int{}},
+	OpBang:         {"OpBang", []int{}},
+	OpIncrement:    {"OpIncrement", []int{}},
+	OpDecrement:    {"OpDecrement", []int{}},
+
+	OpJump:          {"OpJump", []int{2}},
+	OpJumpNotTruthy: {"OpJumpNotTruthy", []int{2}},
+
+	OpIter:     {"OpIter", []int{}},
+	OpIterNext: {"OpIterNext", []int{2, 1}},
+
+	OpGetGlobal:    {"OpGetGlobal", []int{2}},
+	OpSetGlobal:    {"OpSetGlobal", []int{2}},
+	OpAssignGlobal: {"OpAssignGlobal", []int{2}},
+	OpGetLocal:     {"OpGetLocal", []int{1}},
+	OpSetLocal:     {"OpSetLocal", []int{1}},
+	OpGetCell:      {"OpGetCell", []int{1}},
+	OpSetCell:      {"OpSetCell", []int{1}},
+	OpDefineCell:   {"OpDefineCell", []int{1}},
+	OpLoadCell:     {"OpLoadCell", []int{1}},
+	OpMakeCell:     {"OpMakeCell", []int{1}},
+	OpClearLocal:   {"OpClearLocal", []int{1}},
+	OpGetFree:      {"OpGetFree", []int{1}},
+	OpSetFree:      {"OpSetFree", []int{1}},
+	OpLoadFree:     {"OpLoadFree", []int{1}},
+
+	OpArray:          {"OpArray", []int{2}},
+	OpMap:            {"OpMap", []int{2}},
+	OpTemplate:       {"OpTemplate", []int{2}},
+	OpIndex:          {"OpIndex", []int{}},
+	OpIndexForUpdate: {"OpIndexForUpdate", []int{}},
+	OpSetIndex:       {"OpSetIndex", []int{}},
+	OpPostfixIndex:   {"OpPostfixIndex", []int{1}},
+
+	OpCall:        {"OpCall", []int{1}},
+	OpReturnValue: {"OpReturnValue", []int{}},
+	OpClosure:     {"OpClosure", []int{2, 1}},
+
+	OpTry:    {"OpTry", []int{2}},
+	OpEndTry: {"OpEndTry", []int{}},
+	OpThrow:  {"OpThrow", []int{}},
+	OpCatch:  {"OpCatch", []int{}},
+	OpRaise:  {"OpRaise", []int{2}},
+
+	OpImport: {"OpImport", []int{2}},
+}
+
+// Lookup returns the definition of an opcode
+func Lookup(op byte) (*Definition, error) {
+	def, ok := definitions[Opcode(op)]
+	if !ok {
+		return nil, fmt.Errorf("opcode %d tidak dikenal", op)
+	}
+	return def, nil
+}
+
+// Make encodes an instruction
+func Make(op Opcode, operands ...int) []byte {
+	def, ok := definitions[op]
+	if !ok {
+		return []byte{}
+	}
+
+	instructionLen := 1
+	for _, w := range def.OperandWidths {
+		instructionLen += w
+	}
+
+	instruction := make([]byte, instructionLen)
+	instruction[0] = byte(op)
+
+	offset := 1
+	for i, o := range operands {
+		width := def.OperandWidths[i]
+		switch width {
+		case 2:
+			binary.BigEndian.PutUint16(instruction[offset:], uint16(o))
+		case 1:
+			instruction[offset] = byte(o)
+		}
+		offset += width
+	}
+
+	return instruction
+}
+
+// ReadOperands decodes the operands of an instruction and returns them with
+// the number of bytes read
+func ReadOperands(def *Definition, ins Instructions) ([]int, int) {
+	operands := make([]int, len(def.OperandWidths))
+	offset := 0
+
+	for i, width := range def.OperandWidths {
+		switch width {
+		case 2:
+			operands[i] = int(ReadUint16(ins[offset:]))
+		case 1:
+			operands[i] = int(ReadUint8(ins[offset:]))
+		}
+		offset += width
+	}
+
+	return operands, offset
+}
+
+// ReadUint16 decodes a two-byte operand
+func ReadUint16(ins Instructions) uint16 {
+	return binary.BigEndian.Uint16(ins)
+}
+
+// ReadUint8 decodes a one-byte operand
+func ReadUint8(ins Instructions) uint8 {
+	return uint8(ins[0])
+}
+
+// String disassembles the instructions, one per line
+func (ins Instructions) String() string {
+	var out bytes.Buffer
+
+	i := 0
+	for i < len(ins) {
+		def, err := Lookup(ins[i])
+		if err != nil {
+			fmt.Fprintf(&out, "ERROR: %s\n", err)
+			i++
+			continue
+		}
+
+		operands, read := ReadOperands(def, ins[i+1:])
+		fmt.Fprintf(&out, "%04d %s\n", i, ins.fmtInstruction(def, operands))
+
+		i += 1 + read
+	}
+
+	return out.String()
+}
+
+func (ins Instructions) fmtInstruction(def *Definition, operands []int) string {
+	operandCount := len(def.OperandWidths)
+
+	if len(operands) != operandCount {
+		return fmt.Sprintf("ERROR: operand len %d does not match defined %d\n",
+			len(operands), operandCount)
+	}
+
+	switch operandCount {
+	case 0:
+		return def.Name
+	case 1:
+		return fmt.Sprintf("%s %d", def.Name, operands[0])
+	case 2:
+		return fmt.Sprintf("%s %d %d", def.Name, operands[0], operands[1])
+	}
+
+	return fmt.Sprintf("ERROR: unhandled operandCount for %s\n", def.Name)
+}
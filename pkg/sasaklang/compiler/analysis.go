@@ -0,0 +1,189 @@
+package compiler
+
+import (
+	"github.com/arjunaayasa/sasaklang/pkg/sasaklang/ast"
+	"github.com/arjunaayasa/sasaklang/pkg/sasaklang/evaluator"
+)
+
+// walk calls visit for node and, while visit returns true, for its children
+func walk(node ast.Node, visit func(ast.Node) bool) {
+	if node == nil || !visit(node) {
+		return
+	}
+
+	switch node := node.(type) {
+	case *ast.Program:
+		for _, s := range node.Statements {
+			walk(s, visit)
+		}
+	case *ast.BlockStatement:
+		for _, s := range node.Statements {
+			walk(s, visit)
+		}
+	case *ast.ExpressionStatement:
+		walkExpression(node.Expression, visit)
+	case *ast.LetStatement:
+		walk(node.Name, visit)
+		walkExpression(node.Value, visit)
+	case *ast.ConstStatement:
+		walk(node.Name, visit)
+		walkExpression(node.Value, visit)
+	case *ast.ReturnStatement:
+		walkExpression(node.ReturnValue, visit)
+	case *ast.WhileStatement:
+		walkExpression(node.Condition, visit)
+		walkBlock(node.Body, visit)
+	case *ast.ForStatement:
+		if node.Init != nil {
+			walk(node.Init, visit)
+		}
+		walkExpression(node.Condition, visit)
+		walkExpression(node.Update, visit)
+		walkBlock(node.Body, visit)
+	case *ast.ForInStatement:
+		for _, v := range node.Variables {
+			walk(v, visit)
+		}
+		walkExpression(node.Iterable, visit)
+		walkBlock(node.Body, visit)
+	case *ast.TryStatement:
+		walkBlock(node.Block, visit)
+		if node.CatchParam != nil {
+			walk(node.CatchParam, visit)
+		}
+		walkBlock(node.Catch, visit)
+		walkBlock(node.Finally, visit)
+	case *ast.ThrowStatement:
+		walkExpression(node.Value, visit)
+	case *ast.ImportStatement:
+		if node.Alias != nil {
+			walk(node.Alias, visit)
+		}
+	case *ast.PrefixExpression:
+		walkExpression(node.Right, visit)
+	case *ast.PostfixExpression:
+		walkExpression(node.Target, visit)
+	case *ast.InfixExpression:
+		walkExpression(node.Left, visit)
+		walkExpression(node.Right, visit)
+	case *ast.AssignmentExpression:
+		walkExpression(node.Target, visit)
+		walkExpression(node.Value, visit)
+	case *ast.IfExpression:
+		walkExpression(node.Condition, visit)
+		walkBlock(node.Consequence, visit)
+		walkBlock(node.Alternative, visit)
+	case *ast.FunctionLiteral:
+		for _, p := range node.Parameters {
+			walk(p, visit)
+		}
+		walkBlock(node.Body, visit)
+	case *ast.CallExpression:
+		walkExpression(node.Function, visit)
+		for _, a := range node.Arguments {
+			walkExpression(a, visit)
+		}
+	case *ast.ArrayLiteral:
+		for _, e := range node.Elements {
+			walkExpression(e, visit)
+		}
+	case *ast.TemplateLiteral:
+		for _, part := range node.Parts {
+			walkExpression(part, visit)
+		}
+	case *ast.IndexExpression:
+		walkExpression(node.Left, visit)
+		walkExpression(node.Index, visit)
+	case *ast.MemberExpression:
+		// The property is a key, not a variable
+		walkExpression(node.Left, visit)
+	case *ast.MapLiteral:
+		for _, pair := range node.Pairs {
+			walkExpression(pair.Key, visit)
+			walkExpression(pair.Value, visit)
+		}
+	}
+}
+
+func walkExpression(node ast.Expression, visit func(ast.Node) bool) {
+	if node != nil {
+		walk(node, visit)
+	}
+}
+
+func walkBlock(node *ast.BlockStatement, visit func(ast.Node) bool) {
+	if node != nil {
+		walk(node, visit)
+	}
+}
+
+// capturedNames returns the names referred to from functions nested in body.
+// Locals with these names are kept in cells so closures can share them.
+func capturedNames(body *ast.BlockStatement) map[string]bool {
+	captured := make(map[string]bool)
+
+	walkBlock(body, func(node ast.Node) bool {
+		fn, ok := node.(*ast.FunctionLiteral)
+		if !ok {
+			return true
+		}
+
+		walk(fn, func(node ast.Node) bool {
+			if ident, ok := node.(*ast.Identifier); ok {
+				captured[ident.Value] = true
+			}
+			return true
+		})
+		if fn.Name != "" {
+			captured[fn.Name] = true
+		}
+		return false
+	})
+
+	return captured
+}
+
+// declaredNames returns the names a list of statements binds in its own
+// scope: variables, named functions and imported modules. Nested scopes
+// (ojok loops, tangkep blocks and function bodies) are skipped.
+func declaredNames(statements []ast.Statement) []string {
+	var names []string
+	seen := make(map[string]bool)
+	add := func(name string) {
+		if !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+
+	var visit func(ast.Node) bool
+	visit = func(node ast.Node) bool {
+		switch node := node.(type) {
+		case *ast.LetStatement:
+			add(node.Name.Value)
+		case *ast.ConstStatement:
+			add(node.Name.Value)
+		case *ast.ImportStatement:
+			if name, errObj := evaluator.ModuleBindingName(node); errObj == nil {
+				add(name)
+			}
+		case *ast.FunctionLiteral:
+			if node.Name != "" {
+				add(node.Name)
+			}
+			return false
+		case *ast.ForStatement, *ast.ForInStatement:
+			return false
+		case *ast.TryStatement:
+			walkBlock(node.Block, visit)
+			walkBlock(node.Finally, visit)
+			return false
+		}
+		return true
+	}
+
+	for _, s := range statements {
+		walk(s, visit)
+	}
+	return names
+}
@@ -0,0 +1,1182 @@
+package compiler
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+
+	"github.com/arjunaayasa/sasaklang/pkg/sasaklang/ast"
+	"github.com/arjunaayasa/sasaklang/pkg/sasaklang/code"
+	"github.com/arjunaayasa/sasaklang/pkg/sasaklang/evaluator"
+	"github.com/arjunaayasa/sasaklang/pkg/sasaklang/object"
+)
+
+// Limits imposed by the operand widths of the instruction set
+const (
+	maxLocals    = 256
+	maxArguments = 255
+	maxGlobals   = 65536
+)
+
+var infixOpcodes = map[string]code.Opcode{
+	"+":  code.OpAdd,
+	"-":  code.OpSub,
+	"*":  code.OpMul,
+	"/":  code.OpDiv,
+	"%":  code.OpMod,
+	"==": code.OpEqual,
+	"!=": code.OpNotEqual,
+	"<":  code.OpLess,
+	"<=": code.OpLessEqual,
+	">":  code.OpGreater,
+	">=": code.OpGreaterEqual,
+}
+
+// Bytecode is the output of the compiler
+type Bytecode struct {
+	Main        *object.CompiledFunction
+	Constants   []object.Object
+	GlobalNames []string       // names of the global slots, by index
+	TopLevel    map[string]int // slots of the top-level variables, by name
+}
+
+// Compiler lowers an AST to bytecode for the VM
+type Compiler struct {
+	constants   []object.Object
+	symbolTable *SymbolTable
+	scopes      []*CompilationScope
+	main        *object.CompiledFunction
+
+	// indexes of the literal constants, so that equal literals share one
+	// slot of the constant pool
+	literals map[literalKey]int
+
+	// position of the node being compiled, recorded for the instructions
+	// emitted for it
+	line, column int
+
+	// tooLarge is the error for the first operand that did not fit in its
+	// instruction, returned by Compile
+	tooLarge error
+}
+
+// literalKey identifies a literal constant by its type and value
+type literalKey struct {
+	typ   object.ObjectType
+	value string
+}
+
+// CompilationScope holds the state of the function being compiled
+type CompilationScope struct {
+	instructions code.Instructions
+	positions    []code.Position
+
+	// depth is the number of values the code emitted so far leaves on the
+	// stack. Every statement leaves exactly one value, its result.
+	depth int
+
+	loops []*loopContext
+	tries []*tryContext
+}
+
+type loopContext struct {
+	depth     int // stack depth below the loop's result slot
+	tryDepth  int // number of enclosing coba blocks when the loop started
+	breaks    []int
+	continues []int
+}
+
+// tryContext is a coba block (or a tangkep block followed by akhirne) that
+// has an error handler installed while its body runs
+type tryContext struct {
+	finally *ast.BlockStatement
+}
+
+// New creates a compiler
+func New() *Compiler {
+	return &Compiler{
+		symbolTable: NewSymbolTable(),
+		scopes:      []*CompilationScope{{}},
+	}
+}
+
+// Compile compiles a program
+func (c *Compiler) Compile(program *ast.Program) error {
+	if err := c.compileStatements(program.Statements); err != nil {
+		return err
+	}
+	c.emit(code.OpReturnValue)
+	if c.tooLarge != nil {
+		return c.tooLarge
+	}
+
+	scope := c.scope()
+	c.main = &object.CompiledFunction{
+		Instructions: scope.instructions,
+		Positions:    scope.positions,
+	}
+	if c.symbolTable.NumDefinitions() > maxGlobals {
+		return fmt.Errorf("terlalu banyak variabel global")
+	}
+	return nil
+}
+
+// Bytecode returns the compiled program
+func (c *Compiler) Bytecode() *Bytecode {
+	// Functions keep a reference to their constant pool, so that closures
+	// exported by a module still find their constants
+	c.main.Constants = c.constants
+	for _, constant := range c.constants {
+		if fn, ok := constant.(*object.CompiledFunction); ok {
+			fn.Constants = c.constants
+		}
+	}
+
+	return &Bytecode{
+		Main:        c.main,
+		Constants:   c.constants,
+		GlobalNames: c.symbolTable.Names(),
+		TopLevel:    c.symbolTable.Slots(),
+	}
+}
+
+func (c *Compiler) compile(node ast.Node) error {
+	prevLine, prevColumn := c.line, c.column
+	if line, column := ast.Position(node); line > 0 {
+		c.line, c.column = line, column
+	}
+	defer func() { c.line, c.column = prevLine, prevColumn }()
+
+	switch node := node.(type) {
+	// Statements
+	case *ast.ExpressionStatement:
+		if node.Expression == nil {
+			c.emit(code.OpNull)
+			return nil
+		}
+		return c.compile(node.Expression)
+
+	case *ast.BlockStatement:
+		return c.compileStatements(node.Statements)
+
+	case *ast.LetStatement:
+		return c.compileDefinition(node.Name.Value, node.Value, false)
+
+	case *ast.ConstStatement:
+		return c.compileDefinition(node.Name.Value, node.Value, true)
+
+	case *ast.ReturnStatement:
+		base := c.scope().depth
+		if node.ReturnValue == nil {
+			c.emit(code.OpNull)
+		} else if err := c.compile(node.ReturnValue); err != nil {
+			return err
+		}
+		if err := c.unwindTries(0); err != nil {
+			return err
+		}
+		c.emit(code.OpReturnValue)
+		c.setDepth(base + 1)
+
+	case *ast.BreakStatement:
+		return c.compileBreak()
+
+	case *ast.ContinueStatement:
+		return c.compileContinue()
+
+	case *ast.WhileStatement:
+		return c.compileWhileStatement(node)
+
+	case *ast.ForStatement:
+		return c.compileForStatement(node)
+
+	case *ast.ForInStatement:
+		return c.compileForInStatement(node)
+
+	case *ast.TryStatement:
+		return c.compileTryStatement(node)
+
+	case *ast.ThrowStatement:
+		base := c.scope().depth
+		if err := c.compile(node.Value); err != nil {
+			return err
+		}
+		c.emit(code.OpThrow)
+		c.setDepth(base + 1)
+
+	case *ast.ImportStatement:
+		name, errObj := evaluator.ModuleBindingName(node)
+		if errObj != nil {
+			c.raise(errObj.Message)
+			return nil
+		}
+		c.emit(code.OpImport, c.addConstant(&object.String{Value: node.Path}))
+		c.emit(code.OpDup)
+		return c.storeSymbol(c.symbolTable.Define(name, false), true)
+
+	// Expressions
+	case *ast.IntegerLiteral:
+		if node.Big != nil {
+			c.emit(code.OpConstant, c.addConstant(&object.BigInteger{Value: node.Big}))
+			return nil
+		}
+		c.emit(code.OpConstant, c.addConstant(&object.Integer{Value: node.Value}))
+
+	case *ast.FloatLiteral:
+		c.emit(code.OpConstant, c.addConstant(&object.Float{Value: node.Value}))
+
+	case *ast.StringLiteral:
+		c.emit(code.OpConstant, c.addConstant(&object.String{Value: node.Value}))
+
+	case *ast.TemplateLiteral:
+		for _, part := range node.Parts {
+			if err := c.compile(part); err != nil {
+				return err
+			}
+		}
+		c.emit(code.OpTemplate, len(node.Parts))
+
+	case *ast.Boolean:
+		if node.Value {
+			c.emit(code.OpTrue)
+		} else {
+			c.emit(code.OpFalse)
+		}
+
+	case *ast.NullLiteral:
+		c.emit(code.OpNull)
+
+	case *ast.PrefixExpression:
+		if err := c.compile(node.Right); err != nil {
+			return err
+		}
+		switch node.Operator {
+		case "-":
+			c.emit(code.OpMinus)
+		case "!", "ndek":
+			c.emit(code.OpBang)
+		default:
+			return fmt.Errorf("operator tidak dikenal: %s", node.Operator)
+		}
+
+	case *ast.InfixExpression:
+		switch node.Operator {
+		case "ance", "&&":
+			return c.compileLogicalExpression(node, true)
+		case "atau", "||":
+			return c.compileLogicalExpression(node, false)
+		}
+		op, ok := infixOpcodes[node.Operator]
+		if !ok {
+			return fmt.Errorf("operator tidak dikenal: %s", node.Operator)
+		}
+		if err := c.compile(node.Left); err != nil {
+			return err
+		}
+		if err := c.compile(node.Right); err != nil {
+			return err
+		}
+		c.emit(op)
+
+	case *ast.AssignmentExpression:
+		return c.compileAssignment(node)
+
+	case *ast.PostfixExpression:
+		return c.compilePostfix(node)
+
+	case *ast.IfExpression:
+		return c.compileIfExpression(node)
+
+	case *ast.Identifier:
+		c.loadSymbol(c.resolve(node.Value))
+
+	case *ast.FunctionLiteral:
+		return c.compileFunctionLiteral(node)
+
+	case *ast.CallExpression:
+		if len(node.Arguments) > maxArguments {
+			return fmt.Errorf("terlalu banyak argumen: %d", len(node.Arguments))
+		}
+		if err := c.compile(node.Function); err != nil {
+			return err
+		}
+		for _, arg := range node.Arguments {
+			if err := c.compile(arg); err != nil {
+				return err
+			}
+		}
+		c.emit(code.OpCall, len(node.Arguments))
+
+	case *ast.ArrayLiteral:
+		for _, el := range node.Elements {
+			if err := c.compile(el); err != nil {
+				return err
+			}
+		}
+		c.emit(code.OpArray, len(node.Elements))
+
+	case *ast.IndexExpression:
+		if err := c.compile(node.Left); err != nil {
+			return err
+		}
+		if err := c.compile(node.Index); err != nil {
+			return err
+		}
+		c.emit(code.OpIndex)
+
+	case *ast.MemberExpression:
+		if err := c.compile(node.Left); err != nil {
+			return err
+		}
+		c.emit(code.OpConstant, c.addConstant(&object.String{Value: node.Property.Value}))
+		c.emit(code.OpIndex)
+
+	case *ast.MapLiteral:
+		return c.compileMapLiteral(node)
+
+	default:
+		return fmt.Errorf("node %T belum didukung oleh compiler", node)
+	}
+
+	return nil
+}
+
+// compileStatements compiles a list of statements that leaves the value of
+// the last one on the stack, or ndarak when the list is empty
+func (c *Compiler) compileStatements(statements []ast.Statement) error {
+	if len(statements) == 0 {
+		c.emit(code.OpNull)
+		return nil
+	}
+
+	for i, s := range statements {
+		if err := c.compile(s); err != nil {
+			return err
+		}
+		if i < len(statements)-1 {
+			c.emit(code.OpPop)
+		}
+	}
+	return nil
+}
+
+func (c *Compiler) compileDefinition(name string, value ast.Expression, isConst bool) error {
+	if err := c.compile(value); err != nil {
+		return err
+	}
+	c.emit(code.OpDup)
+	return c.storeSymbol(c.symbolTable.Define(name, isConst), true)
+}
+
+func (c *Compiler) compileWhileStatement(node *ast.WhileStatement) error {
+	loop := c.enterLoop()
+
+	condition := len(c.scope().instructions)
+	if err := c.compile(node.Condition); err != nil {
+		return err
+	}
+	exit := c.emit(code.OpJumpNotTruthy, 9999)
+
+	if err := c.compile(node.Body); err != nil {
+		return err
+	}
+	c.emit(code.OpNip)
+	c.emit(code.OpJump, condition)
+
+	return c.leaveLoop(loop, exit, condition)
+}
+
+func (c *Compiler) compileForStatement(node *ast.ForStatement) error {
+	c.enterBlockScope(forScopeStatements(node))
+	defer c.leaveBlockScope()
+
+	if node.Init != nil {
+		if err := c.compile(node.Init); err != nil {
+			return err
+		}
+		c.emit(code.OpPop)
+	}
+
+	loop := c.enterLoop()
+
+	condition := len(c.scope().instructions)
+	exit := -1
+	if node.Condition != nil {
+		if err := c.compile(node.Condition); err != nil {
+			return err
+		}
+		exit = c.emit(code.OpJumpNotTruthy, 9999)
+	}
+
+	if err := c.compile(node.Body); err != nil {
+		return err
+	}
+	c.emit(code.OpNip)
+
+	update := len(c.scope().instructions)
+	if node.Update != nil {
+		if err := c.compile(node.Update); err != nil {
+			return err
+		}
+		c.emit(code.OpPop)
+	}
+	c.emit(code.OpJump, condition)
+
+	return c.leaveLoop(loop, exit, update)
+}
+
+// forScopeStatements returns the statements whose declarations belong to
+// the scope of an ojok loop
+func forScopeStatements(node *ast.ForStatement) []ast.Statement {
+	var statements []ast.Statement
+	if node.Init != nil {
+		statements = append(statements, node.Init)
+	}
+	if node.Body != nil {
+		statements = append(statements, node.Body.Statements...)
+	}
+	return statements
+}
+
+// compileForInStatement compiles an ojok ... dalem loop. The iterator
+// stays on the stack below the loop's result slot until the loop ends.
+func (c *Compiler) compileForInStatement(node *ast.ForInStatement) error {
+	// The value looped over is evaluated outside the loop's scope
+	if err := c.compile(node.Iterable); err != nil {
+		return err
+	}
+	c.emit(code.OpIter)
+
+	// Like the variables of ojok (;;) loops, the loop variables are shared
+	// by all times round
+	c.enterBlockScope(node.Body.Statements)
+	defer c.leaveBlockScope()
+
+	symbols := make([]*Symbol, len(node.Variables))
+	for i, v := range node.Variables {
+		symbols[i] = c.symbolTable.Define(v.Value, false)
+		if symbols[i].Scope == CellScope {
+			c.emit(code.OpClearLocal, symbols[i].Index)
+		}
+	}
+
+	loop := c.enterLoop()
+
+	next := c.emit(code.OpIterNext, 9999, len(symbols))
+	for i := len(symbols) - 1; i >= 0; i-- {
+		if err := c.storeSymbol(symbols[i], true); err != nil {
+			return err
+		}
+	}
+
+	if err := c.compile(node.Body); err != nil {
+		return err
+	}
+	c.emit(code.OpNip)
+	c.emit(code.OpJump, next)
+
+	if err := c.leaveLoop(loop, next, next); err != nil {
+		return err
+	}
+	// Drop the iterator, keeping the loop's result
+	c.emit(code.OpNip)
+	return nil
+}
+
+// enterLoop pushes the loop's result slot, ndarak until the body has run
+func (c *Compiler) enterLoop() *loopContext {
+	scope := c.scope()
+	loop := &loopContext{depth: scope.depth, tryDepth: len(scope.tries)}
+	c.emit(code.OpNull)
+	scope.loops = append(scope.loops, loop)
+	return loop
+}
+
+// leaveLoop patches the jumps out of the loop; exit is the conditional jump
+// taken when the condition fails, or -1 for a loop without condition
+func (c *Compiler) leaveLoop(loop *loopContext, exit, continueTarget int) error {
+	scope := c.scope()
+	scope.loops = scope.loops[:len(scope.loops)-1]
+
+	end := len(scope.instructions)
+	if exit >= 0 {
+		c.changeOperand(exit, end)
+	}
+	for _, pos := range loop.breaks {
+		c.changeOperand(pos, end)
+	}
+	for _, pos := range loop.continues {
+		c.changeOperand(pos, continueTarget)
+	}
+
+	c.setDepth(loop.depth + 1)
+	return nil
+}
+
+func (c *Compiler) compileBreak() error {
+	scope := c.scope()
+	if len(scope.loops) == 0 {
+		return fmt.Errorf("baris %d: mentelah di luar perulangan", c.line)
+	}
+	loop := scope.loops[len(scope.loops)-1]
+	base := scope.depth
+
+	if err := c.unwindTries(loop.tryDepth); err != nil {
+		return err
+	}
+	// The loop evaluates to ndarak when it is left with mentelah
+	c.popTo(loop.depth)
+	c.emit(code.OpNull)
+	loop.breaks = append(loop.breaks, c.emit(code.OpJump, 9999))
+
+	c.setDepth(base + 1)
+	return nil
+}
+
+func (c *Compiler) compileContinue() error {
+	scope := c.scope()
+	if len(scope.loops) == 0 {
+		return fmt.Errorf("baris %d: lanjutan di luar perulangan", c.line)
+	}
+	loop := scope.loops[len(scope.loops)-1]
+	base := scope.depth
+
+	if err := c.unwindTries(loop.tryDepth); err != nil {
+		return err
+	}
+	c.popTo(loop.depth + 1)
+	loop.continues = append(loop.continues, c.emit(code.OpJump, 9999))
+
+	c.setDepth(base + 1)
+	return nil
+}
+
+// popTo pops values until the stack is back at depth
+func (c *Compiler) popTo(depth int) {
+	for c.scope().depth > depth {
+		c.emit(code.OpPop)
+	}
+}
+
+// unwindTries removes the error handlers of the coba blocks being left by a
+// jump, innermost first, running their akhirne blocks
+func (c *Compiler) unwindTries(depth int) error {
+	scope := c.scope()
+	tries := scope.tries
+	defer func() { scope.tries = tries }()
+
+	for i := len(tries) - 1; i >= depth; i-- {
+		// The akhirne block is compiled outside of its own coba block
+		scope.tries = tries[:i]
+		c.emit(code.OpEndTry)
+		if tries[i].finally != nil {
+			if err := c.compile(tries[i].finally); err != nil {
+				return err
+			}
+			c.emit(code.OpPop)
+		}
+	}
+	return nil
+}
+
+func (c *Compiler) compileTryStatement(node *ast.TryStatement) error {
+	scope := c.scope()
+	base := scope.depth
+
+	tryHandler := c.emit(code.OpTry, 9999)
+	scope.tries = append(scope.tries, &tryContext{finally: node.Finally})
+	if err := c.compile(node.Block); err != nil {
+		return err
+	}
+	scope.tries = scope.tries[:len(scope.tries)-1]
+	c.emit(code.OpEndTry)
+	afterBlock := c.emit(code.OpJump, 9999)
+
+	// The handler starts with the error on the stack
+	c.changeOperand(tryHandler, len(scope.instructions))
+	c.setDepth(base + 1)
+
+	catchHandler := -1
+	if node.Catch != nil {
+		if node.Finally != nil {
+			// An error in tangkep still runs akhirne
+			catchHandler = c.emit(code.OpTry, 9999)
+			scope.tries = append(scope.tries, &tryContext{finally: node.Finally})
+		}
+		if err := c.compileCatch(node); err != nil {
+			return err
+		}
+		if node.Finally != nil {
+			scope.tries = scope.tries[:len(scope.tries)-1]
+			c.emit(code.OpEndTry)
+		}
+	} else {
+		if err := c.compileRethrowingFinally(node.Finally, base); err != nil {
+			return err
+		}
+	}
+
+	c.changeOperand(afterBlock, len(scope.instructions))
+	if node.Finally == nil {
+		return nil
+	}
+
+	if err := c.compile(node.Finally); err != nil {
+		return err
+	}
+	c.emit(code.OpPop)
+
+	if catchHandler >= 0 {
+		end := c.emit(code.OpJump, 9999)
+		c.changeOperand(catchHandler, len(scope.instructions))
+		c.setDepth(base + 1)
+		if err := c.compileRethrowingFinally(node.Finally, base); err != nil {
+			return err
+		}
+		c.changeOperand(end, len(scope.instructions))
+	}
+	return nil
+}
+
+// compileCatch compiles a tangkep block, which runs in its own scope with the
+// error bound to the catch parameter
+func (c *Compiler) compileCatch(node *ast.TryStatement) error {
+	c.enterBlockScope(node.Catch.Statements)
+	defer c.leaveBlockScope()
+
+	if node.CatchParam != nil {
+		c.emit(code.OpCatch)
+		symbol := c.symbolTable.Define(node.CatchParam.Value, false)
+		if symbol.Scope == CellScope {
+			c.emit(code.OpClearLocal, symbol.Index)
+		}
+		if err := c.storeSymbol(symbol, true); err != nil {
+			return err
+		}
+	} else {
+		c.emit(code.OpPop)
+	}
+	return c.compile(node.Catch)
+}
+
+// compileRethrowingFinally runs an akhirne block with the pending error on
+// the stack, then raises the error again
+func (c *Compiler) compileRethrowingFinally(finally *ast.BlockStatement, base int) error {
+	if err := c.compile(finally); err != nil {
+		return err
+	}
+	c.emit(code.OpPop)
+	c.emit(code.OpThrow)
+	c.setDepth(base + 1)
+	return nil
+}
+
+// compileLogicalExpression compiles ance (and) or atau (!and) with jumps, so
+// the right operand only runs when the left one does not decide the result.
+// Like the evaluator it always leaves kenak or salak.
+func (c *Compiler) compileLogicalExpression(node *ast.InfixExpression, and bool) error {
+	if err := c.compile(node.Left); err != nil {
+		return err
+	}
+	base := c.scope().depth - 1
+
+	var toTrue, toFalse []int
+	leftFalse := c.emit(code.OpJumpNotTruthy, 9999)
+	if and {
+		toFalse = append(toFalse, leftFalse)
+	} else {
+		toTrue = append(toTrue, c.emit(code.OpJump, 9999))
+		c.changeOperand(leftFalse, len(c.scope().instructions))
+	}
+
+	if err := c.compile(node.Right); err != nil {
+		return err
+	}
+	toFalse = append(toFalse, c.emit(code.OpJumpNotTruthy, 9999))
+
+	for _, pos := range toTrue {
+		c.changeOperand(pos, len(c.scope().instructions))
+	}
+	c.emit(code.OpTrue)
+	end := c.emit(code.OpJump, 9999)
+
+	for _, pos := range toFalse {
+		c.changeOperand(pos, len(c.scope().instructions))
+	}
+	c.setDepth(base)
+	c.emit(code.OpFalse)
+	c.changeOperand(end, len(c.scope().instructions))
+	return nil
+}
+
+func (c *Compiler) compileIfExpression(node *ast.IfExpression) error {
+	if err := c.compile(node.Condition); err != nil {
+		return err
+	}
+	base := c.scope().depth - 1
+
+	jumpNotTruthy := c.emit(code.OpJumpNotTruthy, 9999)
+	if err := c.compile(node.Consequence); err != nil {
+		return err
+	}
+	jump := c.emit(code.OpJump, 9999)
+
+	c.changeOperand(jumpNotTruthy, len(c.scope().instructions))
+	c.setDepth(base)
+	if node.Alternative == nil {
+		c.emit(code.OpNull)
+	} else if err := c.compile(node.Alternative); err != nil {
+		return err
+	}
+
+	c.changeOperand(jump, len(c.scope().instructions))
+	return nil
+}
+
+func (c *Compiler) compileMapLiteral(node *ast.MapLiteral) error {
+	for _, pair := range node.Pairs {
+		if err := c.compile(pair.Key); err != nil {
+			return err
+		}
+		if err := c.compile(pair.Value); err != nil {
+			return err
+		}
+	}
+	c.emit(code.OpMap, len(node.Pairs))
+	return nil
+}
+
+func (c *Compiler) compileAssignment(node *ast.AssignmentExpression) error {
+	compound := node.Operator != ""
+	var op code.Opcode
+	if compound {
+		var ok bool
+		if op, ok = infixOpcodes[node.Operator]; !ok {
+			return fmt.Errorf("operator tidak dikenal: %s=", node.Operator)
+		}
+	}
+
+	switch target := node.Target.(type) {
+	case *ast.Identifier:
+		symbol := c.resolve(target.Value)
+		if symbol.Const {
+			c.raiseConstant(target.Value)
+			return nil
+		}
+		if compound {
+			c.loadSymbol(symbol)
+		}
+		if err := c.compile(node.Value); err != nil {
+			return err
+		}
+		if compound {
+			c.emit(op)
+		}
+		c.emit(code.OpDup)
+		return c.storeSymbol(symbol, false)
+
+	case *ast.IndexExpression, *ast.MemberExpression:
+		if c.raiseIfConstantRoot(target) {
+			return nil
+		}
+		if err := c.compileElement(target); err != nil {
+			return err
+		}
+		if compound {
+			c.emit(code.OpDup2)
+			c.emit(code.OpIndexForUpdate)
+		}
+		if err := c.compile(node.Value); err != nil {
+			return err
+		}
+		if compound {
+			c.emit(op)
+		}
+		c.emit(code.OpSetIndex)
+		return nil
+
+	default:
+		return fmt.Errorf("tidak bisa assign ke %s", node.Target.String())
+	}
+}
+
+func (c *Compiler) compilePostfix(node *ast.PostfixExpression) error {
+	update := code.OpIncrement
+	if node.Operator == "--" {
+		update = code.OpDecrement
+	}
+
+	switch target := node.Target.(type) {
+	case *ast.Identifier:
+		symbol := c.resolve(target.Value)
+		if symbol.Const {
+			c.raiseConstant(target.Value)
+			return nil
+		}
+		// Leave the old value below the updated one, which is stored
+		c.loadSymbol(symbol)
+		c.emit(code.OpDup)
+		c.emit(update)
+		return c.storeSymbol(symbol, false)
+
+	case *ast.IndexExpression, *ast.MemberExpression:
+		if c.raiseIfConstantRoot(target) {
+			return nil
+		}
+		if err := c.compileElement(target); err != nil {
+			return err
+		}
+		operand := 0
+		if update == code.OpDecrement {
+			operand = 1
+		}
+		c.emit(code.OpPostfixIndex, operand)
+		return nil
+
+	default:
+		return fmt.Errorf("tidak bisa assign ke %s", node.Target.String())
+	}
+}
+
+// compileElement pushes the container and the index of an element target
+func (c *Compiler) compileElement(target ast.Expression) error {
+	switch target := target.(type) {
+	case *ast.IndexExpression:
+		if err := c.compile(target.Left); err != nil {
+			return err
+		}
+		return c.compile(target.Index)
+	case *ast.MemberExpression:
+		if err := c.compile(target.Left); err != nil {
+			return err
+		}
+		c.emit(code.OpConstant, c.addConstant(&object.String{Value: target.Property.Value}))
+		return nil
+	}
+	return fmt.Errorf("tidak bisa assign ke %s", target.String())
+}
+
+// raiseIfConstantRoot emits an error for modifying an element of a
+// collection held by a constant
+func (c *Compiler) raiseIfConstantRoot(target ast.Expression) bool {
+	root := rootIdentifier(target)
+	if root == nil {
+		return false
+	}
+	if symbol, ok := c.symbolTable.Resolve(root.Value); ok && symbol.Const {
+		c.raiseConstant(root.Value)
+		return true
+	}
+	return false
+}
+
+func (c *Compiler) raiseConstant(name string) {
+	c.raise(fmt.Sprintf("tidak bisa mengubah konstanta '%s'", name))
+}
+
+// raise emits an instruction that fails with message. It stands in for an
+// expression, so the compiler counts it as pushing a value.
+func (c *Compiler) raise(message string) {
+	c.emit(code.OpRaise, c.addConstant(&object.String{Value: message}))
+	c.scope().depth++
+}
+
+// rootIdentifier returns the variable an index chain such as a[0]["k"] starts from
+func rootIdentifier(node ast.Expression) *ast.Identifier {
+	for {
+		switch n := node.(type) {
+		case *ast.Identifier:
+			return n
+		case *ast.IndexExpression:
+			node = n.Left
+		case *ast.MemberExpression:
+			node = n.Left
+		default:
+			return nil
+		}
+	}
+}
+
+func (c *Compiler) compileFunctionLiteral(node *ast.FunctionLiteral) error {
+	// A named function is also bound in the scope that defines it
+	var nameSymbol *Symbol
+	if node.Name != "" {
+		nameSymbol = c.symbolTable.Define(node.Name, false)
+	}
+
+	c.enterScope(capturedNames(node.Body))
+
+	params := make([]string, len(node.Parameters))
+	for i, p := range node.Parameters {
+		params[i] = p.Value
+		symbol := c.symbolTable.Define(p.Value, false)
+		if symbol.Scope == CellScope {
+			c.emit(code.OpMakeCell, symbol.Index)
+		}
+	}
+	c.predeclareCells(node.Body.Statements, false)
+
+	if err := c.compile(node.Body); err != nil {
+		return err
+	}
+	c.emit(code.OpReturnValue)
+
+	freeSymbols := c.symbolTable.FreeSymbols
+	numLocals := c.symbolTable.NumDefinitions()
+	localNames := c.symbolTable.Names()
+	scope := c.leaveScope()
+
+	if numLocals > maxLocals {
+		return fmt.Errorf("fungsi %s punya terlalu banyak variabel lokal", node.Name)
+	}
+
+	freeNames := make([]string, len(freeSymbols))
+	for i, s := range freeSymbols {
+		freeNames[i] = s.Name
+		if err := c.loadCell(s); err != nil {
+			return err
+		}
+	}
+
+	fn := &object.CompiledFunction{
+		Instructions:  scope.instructions,
+		Positions:     scope.positions,
+		NumLocals:     numLocals,
+		NumParameters: len(node.Parameters),
+		Name:          node.Name,
+		Parameters:    params,
+		LocalNames:    localNames,
+		FreeNames:     freeNames,
+	}
+	c.emit(code.OpClosure, c.addConstant(fn), len(freeSymbols))
+
+	if nameSymbol != nil {
+		c.emit(code.OpDup)
+		return c.storeSymbol(nameSymbol, true)
+	}
+	return nil
+}
+
+// predeclareCells defines, up front, the captured variables that statements
+// declare. A closure can then refer to a variable declared after it, such as
+// a function calling itself through the variable it is assigned to. In a
+// block scope the slots are cleared so every run of the block starts with
+// fresh cells.
+func (c *Compiler) predeclareCells(statements []ast.Statement, clear bool) {
+	for _, name := range declaredNames(statements) {
+		if !c.symbolTable.isCaptured(name) {
+			continue
+		}
+		symbol := c.symbolTable.Define(name, false)
+		if clear && symbol.Scope == CellScope {
+			c.emit(code.OpClearLocal, symbol.Index)
+		}
+	}
+}
+
+func (c *Compiler) resolve(name string) *Symbol {
+	symbol, ok := c.symbolTable.Resolve(name)
+	if !ok {
+		// Unknown names are looked up as globals at run time, falling back
+		// to builtins, just like the evaluator's environment lookup
+		global := c.symbolTable
+		for global.Outer != nil {
+			global = global.Outer
+		}
+		symbol = global.Define(name, false)
+	}
+	return symbol
+}
+
+func (c *Compiler) loadSymbol(s *Symbol) {
+	switch s.Scope {
+	case GlobalScope:
+		c.emit(code.OpGetGlobal, s.Index)
+	case LocalScope:
+		c.emit(code.OpGetLocal, s.Index)
+	case CellScope:
+		c.emit(code.OpGetCell, s.Index)
+	case FreeScope:
+		c.emit(code.OpGetFree, s.Index)
+	}
+}
+
+// loadCell pushes the cell of a captured variable, for OpClosure
+func (c *Compiler) loadCell(s *Symbol) error {
+	switch s.Scope {
+	case CellScope:
+		c.emit(code.OpLoadCell, s.Index)
+	case FreeScope:
+		c.emit(code.OpLoadFree, s.Index)
+	default:
+		return fmt.Errorf("variabel '%s' tidak bisa ditangkap closure", s.Name)
+	}
+	return nil
+}
+
+// storeSymbol pops a value into a variable. define is set for gawe and
+// tetep; plain assignment requires a global to exist already.
+func (c *Compiler) storeSymbol(s *Symbol, define bool) error {
+	switch s.Scope {
+	case GlobalScope:
+		if define {
+			c.emit(code.OpSetGlobal, s.Index)
+		} else {
+			c.emit(code.OpAssignGlobal, s.Index)
+		}
+	case LocalScope:
+		c.emit(code.OpSetLocal, s.Index)
+	case CellScope:
+		if define {
+			c.emit(code.OpDefineCell, s.Index)
+		} else {
+			c.emit(code.OpSetCell, s.Index)
+		}
+	case FreeScope:
+		c.emit(code.OpSetFree, s.Index)
+	}
+	return nil
+}
+
+// addConstant adds obj to the constant pool and returns its index. Numbers
+// and strings are immutable, so an equal one already in the pool is reused.
+func (c *Compiler) addConstant(obj object.Object) int {
+	var key literalKey
+	switch obj := obj.(type) {
+	case *object.Integer, *object.BigInteger, *object.String:
+		key = literalKey{obj.Type(), obj.Inspect()}
+	case *object.Float:
+		// By bits, so that 0.0 and -0.0 stay apart
+		key = literalKey{obj.Type(), strconv.FormatUint(math.Float64bits(obj.Value), 16)}
+	default:
+		c.constants = append(c.constants, obj)
+		return len(c.constants) - 1
+	}
+
+	if index, ok := c.literals[key]; ok {
+		return index
+	}
+	if c.literals == nil {
+		c.literals = make(map[literalKey]int)
+	}
+	c.constants = append(c.constants, obj)
+	c.literals[key] = len(c.constants) - 1
+	return len(c.constants) - 1
+}
+
+func (c *Compiler) scope() *CompilationScope {
+	return c.scopes[len(c.scopes)-1]
+}
+
+func (c *Compiler) emit(op code.Opcode, operands ...int) int {
+	c.checkOperands(op, operands)
+	scope := c.scope()
+	pos := len(scope.instructions)
+	scope.instructions = append(scope.instructions, code.Make(op, operands...)...)
+	scope.depth += stackEffect(op, operands)
+
+	if c.line > 0 {
+		n := len(scope.positions)
+		if n == 0 || scope.positions[n-1].Line != c.line || scope.positions[n-1].Column != c.column {
+			scope.positions = append(scope.positions, code.Position{Offset: pos, Line: c.line, Column: c.column})
+		}
+	}
+	return pos
+}
+
+// setDepth records the stack depth at a jump target or after a jump
+func (c *Compiler) setDepth(depth int) {
+	c.scope().depth = depth
+}
+
+// changeOperand replaces the first operand of the instruction at pos
+func (c *Compiler) changeOperand(pos int, operand int) {
+	ins := c.scope().instructions
+	op := code.Opcode(ins[pos])
+	def, _ := code.Lookup(ins[pos])
+	operands, _ := code.ReadOperands(def, ins[pos+1:])
+	operands[0] = operand
+	c.checkOperands(op, operands)
+	copy(ins[pos:], code.Make(op, operands...))
+}
+
+// checkOperands records an error when an operand is too large for its
+// instruction, which would otherwise be cut short without a word
+func (c *Compiler) checkOperands(op code.Opcode, operands []int) {
+	def, err := code.Lookup(byte(op))
+	if err != nil || c.tooLarge != nil {
+		return
+	}
+	for i, width := range def.OperandWidths {
+		limit := 1<<(8*width) - 1
+		if operands[i] <= limit {
+			continue
+		}
+		switch op {
+		case code.OpConstant, code.OpClosure, code.OpRaise, code.OpImport:
+			c.tooLarge = fmt.Errorf("program punya terlalu banyak konstanta (lebih dari %d)", limit+1)
+		case code.OpArray, code.OpMap, code.OpTemplate:
+			c.tooLarge = fmt.Errorf("literal terlalu besar: %d elemen, paling banyak %d", operands[i], limit)
+		case code.OpJump, code.OpJumpNotTruthy, code.OpIterNext, code.OpTry:
+			c.tooLarge = fmt.Errorf("kode terlalu panjang: lompatan ke %d melewati batas %d", operands[i], limit)
+		default:
+			c.tooLarge = fmt.Errorf("operand %s terlalu besar: %d, paling banyak %d", def.Name, operands[i], limit)
+		}
+		return
+	}
+}
+
+func (c *Compiler) enterScope(captured map[string]bool) {
+	c.scopes = append(c.scopes, &CompilationScope{})
+	c.symbolTable = NewEnclosedSymbolTable(c.symbolTable, captured)
+}
+
+func (c *Compiler) leaveScope() *CompilationScope {
+	scope := c.scope()
+	c.scopes = c.scopes[:len(c.scopes)-1]
+	c.symbolTable = c.symbolTable.Outer
+	return scope
+}
+
+// enterBlockScope starts the scope of an ojok loop or a tangkep block
+func (c *Compiler) enterBlockScope(statements []ast.Statement) {
+	c.symbolTable = NewBlockSymbolTable(c.symbolTable)
+	c.predeclareCells(statements, true)
+}
+
+func (c *Compiler) leaveBlockScope() {
+	c.symbolTable = c.symbolTable.Outer
+}
+
+// stackEffect returns how many values an instruction adds to the stack
+func stackEffect(op code.Opcode, operands []int) int {
+	switch op {
+	case code.OpConstant, code.OpDup, code.OpNull, code.OpTrue, code.OpFalse,
+		code.OpGetGlobal, code.OpGetLocal, code.OpGetCell, code.OpLoadCell,
+		code.OpGetFree, code.OpLoadFree, code.OpImport:
+		return 1
+	case code.OpDup2:
+		return 2
+	case code.OpPop, code.OpNip, code.OpJumpNotTruthy,
+		code.OpAdd, code.OpSub, code.OpMul, code.OpDiv, code.OpMod,
+		code.OpEqual, code.OpNotEqual, code.OpLess, code.OpLessEqual,
+		code.OpGreater, code.OpGreaterEqual,
+		code.OpSetGlobal, code.OpAssignGlobal, code.OpSetLocal, code.OpSetCell,
+		code.OpDefineCell, code.OpSetFree, code.OpIndex, code.OpIndexForUpdate,
+		code.OpPostfixIndex, code.OpReturnValue, code.OpThrow:
+		return -1
+	case code.OpSetIndex:
+		return -2
+	case code.OpArray, code.OpTemplate:
+		return 1 - operands[0]
+	case code.OpMap:
+		return 1 - 2*operands[0]
+	case code.OpCall:
+		return -operands[0]
+	case code.OpIterNext:
+		return operands[1]
+	case code.OpClosure:
+		return 1 - operands[1]
+	}
+	return 0
+}
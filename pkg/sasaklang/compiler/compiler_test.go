@@ -0,0 +1,292 @@
+package compiler
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/arjunaayasa/sasaklang/pkg/sasaklang/code"
+	"github.com/arjunaayasa/sasaklang/pkg/sasaklang/lexer"
+	"github.com/arjunaayasa/sasaklang/pkg/sasaklang/object"
+	"github.com/arjunaayasa/sasaklang/pkg/sasaklang/parser"
+)
+
+func TestCompileExpressions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []code.Instructions
+	}{
+		{
+			"1 + 2",
+			[]code.Instructions{
+				code.Make(code.OpConstant, 0),
+				code.Make(code.OpConstant, 1),
+				code.Make(code.OpAdd),
+				code.Make(code.OpReturnValue),
+			},
+		},
+		{
+			"gawe x = 1; x",
+			[]code.Instructions{
+				code.Make(code.OpConstant, 0),
+				code.Make(code.OpDup),
+				code.Make(code.OpSetGlobal, 0),
+				code.Make(code.OpPop),
+				code.Make(code.OpGetGlobal, 0),
+				code.Make(code.OpReturnValue),
+			},
+		},
+		{
+			"lamun (kenak) { 1 }",
+			[]code.Instructions{
+				code.Make(code.OpTrue),
+				code.Make(code.OpJumpNotTruthy, 10),
+				code.Make(code.OpConstant, 0),
+				code.Make(code.OpJump, 11),
+				code.Make(code.OpNull),
+				code.Make(code.OpReturnValue),
+			},
+		},
+		{
+			"kenak ance salak",
+			[]code.Instructions{
+				code.Make(code.OpTrue),
+				code.Make(code.OpJumpNotTruthy, 12),
+				code.Make(code.OpFalse),
+				code.Make(code.OpJumpNotTruthy, 12),
+				code.Make(code.OpTrue),
+				code.Make(code.OpJump, 13),
+				code.Make(code.OpFalse),
+				code.Make(code.OpReturnValue),
+			},
+		},
+		{
+			"kenak atau salak",
+			[]code.Instructions{
+				code.Make(code.OpTrue),
+				// The right side is skipped once the left side is kenak
+				code.Make(code.OpJumpNotTruthy, 7),
+				code.Make(code.OpJump, 11),
+				code.Make(code.OpFalse),
+				code.Make(code.OpJumpNotTruthy, 15),
+				code.Make(code.OpTrue),
+				code.Make(code.OpJump, 16),
+				code.Make(code.OpFalse),
+				code.Make(code.OpReturnValue),
+			},
+		},
+		{
+			"selame (kenak) { mentelah }",
+			[]code.Instructions{
+				code.Make(code.OpNull),
+				code.Make(code.OpTrue),
+				code.Make(code.OpJumpNotTruthy, 14),
+				// mentelah drops the result slot and leaves ndarak
+				code.Make(code.OpPop),
+				code.Make(code.OpNull),
+				code.Make(code.OpJump, 14),
+				code.Make(code.OpNip),
+				code.Make(code.OpJump, 1),
+				code.Make(code.OpReturnValue),
+			},
+		},
+		{
+			"ojok (x dalem xs) { x }",
+			[]code.Instructions{
+				code.Make(code.OpGetGlobal, 0),
+				code.Make(code.OpIter),
+				code.Make(code.OpNull),
+				code.Make(code.OpIterNext, 19, 1),
+				code.Make(code.OpSetGlobal, 1),
+				code.Make(code.OpGetGlobal, 1),
+				code.Make(code.OpNip),
+				code.Make(code.OpJump, 5),
+				// The iterator below the result is dropped at the end
+				code.Make(code.OpNip),
+				code.Make(code.OpReturnValue),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		bytecode := compile(t, tt.input)
+		testInstructions(t, tt.input, tt.expected, bytecode.Main.Instructions)
+	}
+}
+
+func TestCompileClosures(t *testing.T) {
+	input := `fungsi() { gawe n = 0; fungsi() { n } }`
+	bytecode := compile(t, input)
+
+	var outer, inner *object.CompiledFunction
+	for _, c := range bytecode.Constants {
+		if fn, ok := c.(*object.CompiledFunction); ok {
+			if len(fn.FreeNames) == 0 {
+				outer = fn
+			} else {
+				inner = fn
+			}
+		}
+	}
+	if outer == nil || inner == nil {
+		t.Fatalf("expected two compiled functions, got constants %v", bytecode.Constants)
+	}
+
+	// n is captured, so the outer function keeps it in a cell
+	testInstructions(t, "outer", []code.Instructions{
+		code.Make(code.OpConstant, 0),
+		code.Make(code.OpDup),
+		code.Make(code.OpDefineCell, 0),
+		code.Make(code.OpPop),
+		code.Make(code.OpLoadCell, 0),
+		code.Make(code.OpClosure, 1, 1),
+		code.Make(code.OpReturnValue),
+	}, outer.Instructions)
+
+	testInstructions(t, "inner", []code.Instructions{
+		code.Make(code.OpGetFree, 0),
+		code.Make(code.OpReturnValue),
+	}, inner.Instructions)
+
+	if inner.FreeNames[0] != "n" {
+		t.Errorf("wrong free variable. got=%q", inner.FreeNames[0])
+	}
+}
+
+func TestCompileErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"mentelah", "baris 1: mentelah di luar perulangan"},
+		{"fungsi() { lanjutan }", "baris 1: lanjutan di luar perulangan"},
+	}
+
+	for _, tt := range tests {
+		p := parser.New(lexer.New(tt.input))
+		program := p.ParseProgram()
+		if len(p.Errors()) != 0 {
+			t.Fatalf("parser errors: %v", p.Errors())
+		}
+
+		err := New().Compile(program)
+		if err == nil {
+			t.Errorf("expected compiler error for %q", tt.input)
+			continue
+		}
+		if err.Error() != tt.expected {
+			t.Errorf("wrong error. expected=%q, got=%q", tt.expected, err.Error())
+		}
+	}
+}
+
+func TestCompileOperandLimits(t *testing.T) {
+	var constants []string
+	for i := 0; i < 70000; i++ {
+		constants = append(constants, strconv.Itoa(i))
+	}
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"[" + strings.TrimSuffix(strings.Repeat("0, ", 70000), ", ") + "]", "literal terlalu besar: 70000 elemen, paling banyak 65535"},
+		{strings.Join(constants, "\n"), "program punya terlalu banyak konstanta (lebih dari 65536)"},
+		{"lamun (kenak) {\n" + strings.Repeat("ndarak\n", 70000) + "}", "kode terlalu panjang: lompatan ke 140006 melewati batas 65535"},
+	}
+
+	for _, tt := range tests {
+		p := parser.New(lexer.New(tt.input))
+		program := p.ParseProgram()
+		if len(p.Errors()) != 0 {
+			t.Fatalf("parser errors: %v", p.Errors())
+		}
+
+		err := New().Compile(program)
+		if err == nil || err.Error() != tt.expected {
+			t.Errorf("wrong error. expected=%q, got=%v", tt.expected, err)
+		}
+	}
+
+	// Equal literals share one constant
+	bytecode := compile(t, strings.Repeat("1 + 1.5 + \"a\"\n", 70000))
+	if len(bytecode.Constants) != 3 {
+		t.Errorf("expected 3 constants, got=%d", len(bytecode.Constants))
+	}
+}
+
+func TestSymbolTable(t *testing.T) {
+	global := NewSymbolTable()
+	a := global.Define("a", false)
+	if a.Scope != GlobalScope || a.Index != 0 {
+		t.Errorf("wrong symbol for a: %+v", a)
+	}
+
+	fn := NewEnclosedSymbolTable(global, map[string]bool{"c": true})
+	b := fn.Define("b", false)
+	c := fn.Define("c", true)
+	if b.Scope != LocalScope || b.Index != 0 {
+		t.Errorf("wrong symbol for b: %+v", b)
+	}
+	if c.Scope != CellScope || c.Index != 1 || !c.Const {
+		t.Errorf("wrong symbol for c: %+v", c)
+	}
+
+	// Block scopes take their slots from the function
+	block := NewBlockSymbolTable(fn)
+	d := block.Define("d", false)
+	if d.Scope != LocalScope || d.Index != 2 {
+		t.Errorf("wrong symbol for d: %+v", d)
+	}
+	if _, ok := fn.Resolve("d"); ok {
+		t.Errorf("d should not be visible outside its block")
+	}
+
+	// Redefining a name reuses its slot and keeps it constant
+	if again := fn.Define("c", false); again != c || !again.Const {
+		t.Errorf("redefinition should reuse the symbol, got %+v", again)
+	}
+
+	nested := NewEnclosedSymbolTable(block, nil)
+	for _, name := range []string{"a", "c", "c"} {
+		symbol, ok := nested.Resolve(name)
+		if !ok {
+			t.Fatalf("%s not resolvable", name)
+		}
+		if name == "a" && symbol.Scope != GlobalScope {
+			t.Errorf("a should stay global, got %+v", symbol)
+		}
+		if name == "c" && (symbol.Scope != FreeScope || symbol.Index != 0) {
+			t.Errorf("c should be free variable 0, got %+v", symbol)
+		}
+	}
+	if len(nested.FreeSymbols) != 1 || nested.FreeSymbols[0] != c {
+		t.Errorf("wrong free symbols: %+v", nested.FreeSymbols)
+	}
+}
+
+func compile(t *testing.T, input string) *Bytecode {
+	t.Helper()
+	p := parser.New(lexer.New(input))
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		t.Fatalf("parser errors: %v", p.Errors())
+	}
+
+	c := New()
+	if err := c.Compile(program); err != nil {
+		t.Fatalf("compiler error: %s", err)
+	}
+	return c.Bytecode()
+}
+
+func testInstructions(t *testing.T, name string, expected []code.Instructions, actual code.Instructions) {
+	t.Helper()
+	concatted := code.Instructions{}
+	for _, ins := range expected {
+		concatted = append(concatted, ins...)
+	}
+
+	if concatted.String() != actual.String() {
+		t.Errorf("wrong instructions for %q.\nwant=\n%s\ngot=\n%s", name, concatted, actual)
+	}
+}
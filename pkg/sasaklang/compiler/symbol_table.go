@@ -0,0 +1,136 @@
+package compiler
+
+// SymbolScope tells the compiler where a variable lives at run time
+type SymbolScope string
+
+const (
+	GlobalScope SymbolScope = "GLOBAL"
+	LocalScope  SymbolScope = "LOCAL"
+	CellScope   SymbolScope = "CELL" // a local that closures capture, boxed in an object.Cell
+	FreeScope   SymbolScope = "FREE" // a variable captured from an enclosing function
+)
+
+// Symbol is a resolved variable
+type Symbol struct {
+	Name  string
+	Scope SymbolScope
+	Index int
+	Const bool
+}
+
+// SymbolTable maps names to symbols for one scope. Function tables (and the
+// global table) own the variable slots; block tables, used for the scopes
+// that ojok and tangkep introduce, allocate their slots from the enclosing
+// function table.
+type SymbolTable struct {
+	Outer       *SymbolTable
+	FreeSymbols []*Symbol // symbols of the enclosing function captured by this one
+
+	store    map[string]*Symbol
+	function *SymbolTable
+
+	numDefinitions int
+	names          []string
+	captured       map[string]bool // names used by nested functions; these become cells
+}
+
+// NewSymbolTable creates the global symbol table
+func NewSymbolTable() *SymbolTable {
+	s := &SymbolTable{store: make(map[string]*Symbol)}
+	s.function = s
+	return s
+}
+
+// NewEnclosedSymbolTable creates the table of a function nested in outer.
+// captured lists the names that functions nested in this one refer to.
+func NewEnclosedSymbolTable(outer *SymbolTable, captured map[string]bool) *SymbolTable {
+	s := &SymbolTable{Outer: outer, store: make(map[string]*Symbol), captured: captured}
+	s.function = s
+	return s
+}
+
+// NewBlockSymbolTable creates the table of a block scope inside outer
+func NewBlockSymbolTable(outer *SymbolTable) *SymbolTable {
+	return &SymbolTable{Outer: outer, store: make(map[string]*Symbol), function: outer.function}
+}
+
+// Define binds name in this scope. Defining a name again in the same scope
+// reuses its slot, and a constant stays constant.
+func (s *SymbolTable) Define(name string, isConst bool) *Symbol {
+	if symbol, ok := s.store[name]; ok && symbol.Scope != FreeScope {
+		symbol.Const = symbol.Const || isConst
+		return symbol
+	}
+
+	fn := s.function
+	symbol := &Symbol{Name: name, Index: fn.numDefinitions, Const: isConst}
+	switch {
+	case fn.Outer == nil:
+		symbol.Scope = GlobalScope
+	case fn.captured[name]:
+		symbol.Scope = CellScope
+	default:
+		symbol.Scope = LocalScope
+	}
+
+	fn.numDefinitions++
+	fn.names = append(fn.names, name)
+	s.store[name] = symbol
+	return symbol
+}
+
+// Resolve looks name up through the enclosing scopes. A variable of an
+// enclosing function becomes a free variable of every function in between.
+func (s *SymbolTable) Resolve(name string) (*Symbol, bool) {
+	if symbol, ok := s.store[name]; ok {
+		return symbol, true
+	}
+	if s.Outer == nil {
+		return nil, false
+	}
+
+	symbol, ok := s.Outer.Resolve(name)
+	if !ok || symbol.Scope == GlobalScope || s.function != s {
+		return symbol, ok
+	}
+	return s.defineFree(symbol), true
+}
+
+func (s *SymbolTable) defineFree(original *Symbol) *Symbol {
+	s.FreeSymbols = append(s.FreeSymbols, original)
+
+	symbol := &Symbol{
+		Name:  original.Name,
+		Scope: FreeScope,
+		Index: len(s.FreeSymbols) - 1,
+		Const: original.Const,
+	}
+	s.store[original.Name] = symbol
+	return symbol
+}
+
+// NumDefinitions returns the number of slots allocated in the function
+func (s *SymbolTable) NumDefinitions() int {
+	return s.function.numDefinitions
+}
+
+// Names returns the names of the function's slots, by index
+func (s *SymbolTable) Names() []string {
+	return s.function.names
+}
+
+// Slots returns the slot index of every variable defined in this scope
+func (s *SymbolTable) Slots() map[string]int {
+	slots := make(map[string]int, len(s.store))
+	for name, symbol := range s.store {
+		if symbol.Scope != FreeScope {
+			slots[name] = symbol.Index
+		}
+	}
+	return slots
+}
+
+// isCaptured reports whether a nested function refers to name
+func (s *SymbolTable) isCaptured(name string) bool {
+	return s.function.captured[name]
+}
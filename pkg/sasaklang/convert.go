@@ -0,0 +1,148 @@
+package sasaklang
+
+import (
+	"fmt"
+	"math/big"
+	"reflect"
+	"sort"
+
+	"github.com/arjunaayasa/sasaklang/pkg/sasaklang/evaluator"
+	"github.com/arjunaayasa/sasaklang/pkg/sasaklang/object"
+)
+
+// ToObject converts a Go value to a SasakLang value. It accepts nil, bools,
+// integers (*big.Int included), floats, strings, slices and arrays, maps with string keys,
+// builtin functions and values that already are objects. Map entries are
+// added in the order of their keys.
+func ToObject(value interface{}) (object.Object, error) {
+	switch v := value.(type) {
+	case nil:
+		return evaluator.NULL, nil
+	case object.Object:
+		return v, nil
+	case *big.Int:
+		return object.NewInteger(new(big.Int).Set(v)), nil
+	case object.BuiltinFunction:
+		return &object.Builtin{Fn: v}, nil
+	case func(*object.Runtime, ...object.Object) object.Object:
+		return &object.Builtin{Fn: v}, nil
+	}
+
+	rv := reflect.ValueOf(value)
+	switch rv.Kind() {
+	case reflect.Bool:
+		if rv.Bool() {
+			return evaluator.TRUE, nil
+		}
+		return evaluator.FALSE, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return &object.Integer{Value: rv.Int()}, nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return object.NewInteger(new(big.Int).SetUint64(rv.Uint())), nil
+	case reflect.Float32, reflect.Float64:
+		return &object.Float{Value: rv.Float()}, nil
+	case reflect.String:
+		return &object.String{Value: rv.String()}, nil
+	case reflect.Slice, reflect.Array:
+		elements := make([]object.Object, rv.Len())
+		for i := range elements {
+			el, err := ToObject(rv.Index(i).Interface())
+			if err != nil {
+				return nil, err
+			}
+			elements[i] = el
+		}
+		return &object.Array{Elements: elements}, nil
+	case reflect.Map:
+		if rv.Type().Key().Kind() != reflect.String {
+			return nil, fmt.Errorf("kunci map harus string, dapat %s", rv.Type().Key())
+		}
+		// Go maps have no order, so the entries are added sorted by key
+		keys := rv.MapKeys()
+		sort.Slice(keys, func(i, j int) bool { return keys[i].String() < keys[j].String() })
+		m := &object.Map{}
+		for _, k := range keys {
+			val, err := ToObject(rv.MapIndex(k).Interface())
+			if err != nil {
+				return nil, err
+			}
+			m.Set(&object.String{Value: k.String()}, val)
+		}
+		return m, nil
+	}
+	return nil, fmt.Errorf("tipe Go %T tidak bisa diubah ke nilai SasakLang", value)
+}
+
+// ToGo converts a SasakLang value to a Go value: int64 (*big.Int for big
+// integers), float64, string, bool, nil, []interface{} or
+// map[string]interface{}, with map keys in their printed form. Other
+// values, such as functions, are returned as is. An array or map inside
+// itself becomes a slice or map inside itself.
+func ToGo(obj object.Object) interface{} {
+	return toGo(obj, make(map[object.Object]interface{}))
+}
+
+// toGo converts obj, where converted holds the arrays and maps converted
+// so far
+func toGo(obj object.Object, converted map[object.Object]interface{}) interface{} {
+	if v, ok := converted[obj]; ok {
+		return v
+	}
+	switch obj := obj.(type) {
+	case *object.Integer:
+		return obj.Value
+	case *object.BigInteger:
+		return new(big.Int).Set(obj.Value)
+	case *object.Float:
+		return obj.Value
+	case *object.String:
+		return obj.Value
+	case *object.Boolean:
+		return obj.Value
+	case *object.Null:
+		return nil
+	case *object.Array:
+		elements := make([]interface{}, len(obj.Elements))
+		converted[obj] = elements
+		for i, el := range obj.Elements {
+			elements[i] = toGo(el, converted)
+		}
+		return elements
+	case *object.Map:
+		m := make(map[string]interface{}, obj.Len())
+		converted[obj] = m
+		for _, pair := range obj.Pairs() {
+			m[pair.Key.Inspect()] = toGo(pair.Value, converted)
+		}
+		return m
+	}
+	return obj
+}
+
+// copyValue returns a copy of obj in which the arrays and maps are new, so
+// that a run changing them does not change obj. copies maps the arrays and
+// maps already copied to their copies, which keeps values that are shared
+// or contain themselves that way in the copy.
+func copyValue(obj object.Object, copies map[object.Object]object.Object) object.Object {
+	if c, ok := copies[obj]; ok {
+		return c
+	}
+
+	switch obj := obj.(type) {
+	case *object.Array:
+		arr := &object.Array{Elements: make([]object.Object, len(obj.Elements))}
+		copies[obj] = arr
+		for i, el := range obj.Elements {
+			arr.Elements[i] = copyValue(el, copies)
+		}
+		return arr
+	case *object.Map:
+		m := &object.Map{}
+		copies[obj] = m
+		for _, pair := range obj.Pairs() {
+			m.Set(pair.Key, copyValue(pair.Value, copies))
+		}
+		return m
+	}
+	return obj
+}
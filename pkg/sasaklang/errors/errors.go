@@ -1,16 +1,27 @@
 package errors
 
-import "fmt"
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/arjunaayasa/sasaklang/pkg/sasaklang/object"
+	"github.com/arjunaayasa/sasaklang/pkg/sasaklang/token"
+)
 
 // ParseError represents a parsing error
 type ParseError struct {
-	Message string
-	Line    int
-	Column  int
-	Token   string
+	Message  string
+	Line     int
+	Column   int
+	Token    string            // text of the offending token, "" at the end of input
+	Expected []token.TokenType // tokens that would have been accepted, if known
 }
 
 func (e *ParseError) Error() string {
+	if e.Token == "" {
+		return fmt.Sprintf("Parse error [baris %d, kolom %d]: %s", e.Line, e.Column, e.Message)
+	}
 	return fmt.Sprintf("Parse error [baris %d, kolom %d] dekat '%s': %s",
 		e.Line, e.Column, e.Token, e.Message)
 }
@@ -44,3 +55,72 @@ const (
 func FormatError(format string, args ...interface{}) string {
 	return fmt.Sprintf(format, args...)
 }
+
+// Excerpt returns line of source with a caret under column, both prefixed
+// with a gutter holding the line number:
+//
+//	3 | gawe y = x / 0
+//	  |            ^
+//
+// It returns "" when the line does not exist.
+func Excerpt(source string, line, column int) string {
+	lines := strings.Split(source, "\n")
+	if line < 1 || line > len(lines) {
+		return ""
+	}
+	text := strings.TrimRight(lines[line-1], "\r")
+
+	// Keep tabs in the caret line so the caret lines up with the source
+	var pad strings.Builder
+	for i, ch := range []rune(text) {
+		if i >= column-1 {
+			break
+		}
+		if ch == '\t' {
+			pad.WriteByte('\t')
+		} else {
+			pad.WriteByte(' ')
+		}
+	}
+
+	gutter := fmt.Sprintf("%4d | ", line)
+	blank := strings.Repeat(" ", len(gutter)-2) + "| "
+	return gutter + text + "\n" + blank + pad.String() + "^"
+}
+
+// maxStackEnds is the number of calls FormatRuntimeError shows at each end
+// of a long call stack
+const maxStackEnds = 10
+
+// FormatRuntimeError renders a runtime error for the terminal: the message,
+// an excerpt of source (the text of the file the error occurred in, or ""
+// if it is not available) and the call stack
+func FormatRuntimeError(err *object.Error, source string) string {
+	var out strings.Builder
+	out.WriteString(err.Inspect())
+
+	if err.Line > 0 && source != "" {
+		if excerpt := Excerpt(source, err.Line, err.Column); excerpt != "" {
+			out.WriteString("\n" + excerpt)
+		}
+	}
+
+	if len(err.Stack) > 0 {
+		out.WriteString("\nJejak panggilan (terakhir di atas):")
+		for i, frame := range err.Stack {
+			// Deep recursion is shown by its innermost and outermost calls
+			if len(err.Stack) > 2*maxStackEnds && i >= maxStackEnds && i < len(err.Stack)-maxStackEnds {
+				if i == maxStackEnds {
+					fmt.Fprintf(&out, "\n  ... %d panggilan lain ...", len(err.Stack)-2*maxStackEnds)
+				}
+				continue
+			}
+			fmt.Fprintf(&out, "\n  di %s, dipanggil di baris %d, kolom %d", frame.Function, frame.Line, frame.Column)
+			if frame.File != "" && frame.File != err.File {
+				fmt.Fprintf(&out, " (%s)", filepath.Base(frame.File))
+			}
+		}
+	}
+
+	return out.String()
+}
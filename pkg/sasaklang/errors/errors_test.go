@@ -0,0 +1,59 @@
+package errors
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/arjunaayasa/sasaklang/pkg/sasaklang/object"
+)
+
+func TestExcerpt(t *testing.T) {
+	source := "gawe x = 1;\n\tgawe y = x / 0;"
+
+	expected := "   2 | \tgawe y = x / 0;\n     | \t           ^"
+	if got := Excerpt(source, 2, 13); got != expected {
+		t.Errorf("wrong excerpt.\nexpected=%q\ngot=%q", expected, got)
+	}
+
+	if got := Excerpt(source, 5, 1); got != "" {
+		t.Errorf("expected no excerpt for a missing line, got=%q", got)
+	}
+}
+
+func TestFormatRuntimeError(t *testing.T) {
+	err := &object.Error{
+		Message: "pembagian dengan nol",
+		Line:    1,
+		Column:  5,
+		File:    "/tmp/modul.ssk",
+		Stack: []object.StackFrame{
+			{Function: "bagi", File: "/tmp/utama.ssk", Line: 3, Column: 9},
+		},
+	}
+
+	expected := `Error [baris 1, kolom 5]: pembagian dengan nol
+   1 | a / b
+     |     ^
+Jejak panggilan (terakhir di atas):
+  di bagi, dipanggil di baris 3, kolom 9 (utama.ssk)`
+	if got := FormatRuntimeError(err, "a / b"); got != expected {
+		t.Errorf("wrong format.\nexpected=%q\ngot=%q", expected, got)
+	}
+}
+
+func TestFormatRuntimeErrorLongStack(t *testing.T) {
+	err := &object.Error{Message: "stack overflow: rekursi terlalu dalam"}
+	for i := 1; i <= 25; i++ {
+		err.Stack = append(err.Stack, object.StackFrame{Function: "f", Line: i, Column: 1})
+	}
+
+	got := FormatRuntimeError(err, "")
+	if n := strings.Count(got, "\n  di f"); n != 2*maxStackEnds {
+		t.Errorf("expected %d calls shown, got=%d", 2*maxStackEnds, n)
+	}
+	for _, expected := range []string{"baris 10, kolom 1\n  ... 5 panggilan lain ...\n  di f, dipanggil di baris 16,", "baris 25, kolom 1"} {
+		if !strings.Contains(got, expected) {
+			t.Errorf("output does not contain %q:\n%s", expected, got)
+		}
+	}
+}
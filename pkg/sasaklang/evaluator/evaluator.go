@@ -2,21 +2,40 @@ package evaluator
 
 import (
 	"fmt"
+	"math"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
 
 	"github.com/arjunaayasa/sasaklang/pkg/sasaklang/ast"
 	"github.com/arjunaayasa/sasaklang/pkg/sasaklang/builtins"
+	"github.com/arjunaayasa/sasaklang/pkg/sasaklang/lexer"
 	"github.com/arjunaayasa/sasaklang/pkg/sasaklang/object"
+	"github.com/arjunaayasa/sasaklang/pkg/sasaklang/parser"
+	"github.com/arjunaayasa/sasaklang/pkg/sasaklang/token"
 )
 
 // Singletons
 var (
-	NULL  = &object.Null{}
-	TRUE  = &object.Boolean{Value: true}
-	FALSE = &object.Boolean{Value: false}
+	NULL  = object.NULL
+	TRUE  = object.TRUE
+	FALSE = object.FALSE
 )
 
-// Eval evaluates an AST node
+// Eval evaluates an AST node. Runtime errors are tagged with the position of
+// the innermost node that produced them.
 func Eval(node ast.Node, env *object.Environment) object.Object {
+	result := eval(node, env)
+	if errObj, ok := result.(*object.Error); ok && errObj.Line == 0 {
+		errObj.Line, errObj.Column = ast.Position(node)
+		errObj.File = env.File()
+	}
+	return result
+}
+
+func eval(node ast.Node, env *object.Environment) object.Object {
 	switch node := node.(type) {
 	// Statements
 	case *ast.Program:
@@ -39,16 +58,35 @@ func Eval(node ast.Node, env *object.Environment) object.Object {
 		return evalWhileStatement(node, env)
 	case *ast.ForStatement:
 		return evalForStatement(node, env)
+	case *ast.ForInStatement:
+		return evalForInStatement(node, env)
 	case *ast.BreakStatement:
 		return &object.BreakReturnValue{}
 	case *ast.ContinueStatement:
 		return &object.ContinueReturnValue{}
+	case *ast.TryStatement:
+		return evalTryStatement(node, env)
+	case *ast.ThrowStatement:
+		return evalThrowStatement(node, env)
+	case *ast.ImportStatement:
+		return evalImportStatement(node, env)
 
 	// Expressions
 	case *ast.IntegerLiteral:
+		if node.Big != nil {
+			return &object.BigInteger{Value: node.Big}
+		}
 		return &object.Integer{Value: node.Value}
+	case *ast.FloatLiteral:
+		return &object.Float{Value: node.Value}
 	case *ast.StringLiteral:
 		return &object.String{Value: node.Value}
+	case *ast.TemplateLiteral:
+		parts := evalExpressions(node.Parts, env)
+		if len(parts) == 1 && isError(parts[0]) {
+			return parts[0]
+		}
+		return joinTemplate(env.Runtime(), parts)
 	case *ast.Boolean:
 		return nativeBoolToBooleanObject(node.Value)
 	case *ast.NullLiteral:
@@ -60,6 +98,9 @@ func Eval(node ast.Node, env *object.Environment) object.Object {
 		}
 		return evalPrefixExpression(node.Operator, right)
 	case *ast.InfixExpression:
+		if isLogicalOperator(node.Operator) {
+			return evalLogicalExpression(node, env)
+		}
 		left := Eval(node.Left, env)
 		if isError(left) {
 			return left
@@ -68,9 +109,11 @@ func Eval(node ast.Node, env *object.Environment) object.Object {
 		if isError(right) {
 			return right
 		}
-		return evalInfixExpression(node.Operator, left, right)
+		return evalInfixExpression(env.Runtime(), node.Operator, left, right)
 	case *ast.AssignmentExpression:
 		return evalAssignmentExpression(node, env)
+	case *ast.PostfixExpression:
+		return evalPostfixExpression(node, env)
 	case *ast.IfExpression:
 		return evalIfExpression(node, env)
 	case *ast.Identifier:
@@ -93,12 +136,15 @@ func Eval(node ast.Node, env *object.Environment) object.Object {
 		if len(args) == 1 && isError(args[0]) {
 			return args[0]
 		}
-		return applyFunction(function, args)
+		return evalCall(node, function, args, env)
 	case *ast.ArrayLiteral:
 		elements := evalExpressions(node.Elements, env)
 		if len(elements) == 1 && isError(elements[0]) {
 			return elements[0]
 		}
+		if errObj := env.Runtime().Allocate(object.ArraySize(len(elements))); errObj != nil {
+			return errObj
+		}
 		return &object.Array{Elements: elements}
 	case *ast.IndexExpression:
 		left := Eval(node.Left, env)
@@ -110,6 +156,12 @@ func Eval(node ast.Node, env *object.Environment) object.Object {
 			return index
 		}
 		return evalIndexExpression(left, index)
+	case *ast.MemberExpression:
+		left := Eval(node.Left, env)
+		if isError(left) {
+			return left
+		}
+		return evalIndexExpression(left, &object.String{Value: node.Property.Value})
 	case *ast.MapLiteral:
 		return evalMapLiteral(node, env)
 	}
@@ -191,10 +243,11 @@ func evalWhileStatement(node *ast.WhileStatement, env *object.Environment) objec
 				result = NULL
 				break
 			}
-			if result.Type() == object.CONTINUE_OBJ {
-				// Just continue the loop
-				continue
-			}
+		}
+
+		// Every iteration that goes round, lanjutan included, is a step
+		if errObj := env.Runtime().Step(); errObj != nil {
+			return errObj
 		}
 	}
 
@@ -248,31 +301,548 @@ func evalForStatement(node *ast.ForStatement, env *object.Environment) object.Ob
 				return updateResult
 			}
 		}
+
+		if errObj := env.Runtime().Step(); errObj != nil {
+			return errObj
+		}
 	}
 
 	return result
 }
 
-func evalAssignmentExpression(node *ast.AssignmentExpression, env *object.Environment) object.Object {
-	// Check if it's a constant
-	if env.IsConst(node.Name.Value) {
-		return newError("tidak bisa mengubah konstanta '%s'", node.Name.Value)
+func evalForInStatement(node *ast.ForInStatement, env *object.Environment) object.Object {
+	iterable := Eval(node.Iterable, env)
+	if isError(iterable) {
+		return iterable
+	}
+	it, errObj := newIterator(env.Runtime(), iterable)
+	if errObj != nil {
+		return errObj
+	}
+
+	// The loop variables live in the loop's scope and are set again each
+	// time round
+	forEnv := object.NewEnclosedEnvironment(env)
+	var result object.Object = NULL
+
+	for {
+		key, value, ok := it.Next()
+		if !ok {
+			break
+		}
+		if isError(value) {
+			return value
+		}
+		for i, v := range loopValues(it, len(node.Variables), key, value) {
+			forEnv.Set(node.Variables[i].Value, v)
+		}
+
+		val := Eval(node.Body, forEnv)
+		if val == nil {
+			val = NULL
+		}
+		switch val.Type() {
+		case object.RETURN_VALUE_OBJ, object.ERROR_OBJ:
+			return val
+		case object.BREAK_OBJ:
+			return NULL
+		case object.CONTINUE_OBJ:
+			// lanjutan keeps the value of the previous time round
+			val = result
+		}
+		result = val
+
+		if errObj := env.Runtime().Step(); errObj != nil {
+			return errObj
+		}
+	}
+
+	return result
+}
+
+// loopValues returns the values of the n variables of an ojok ... dalem
+// loop for one element
+func loopValues(it *object.Iterator, n int, key, value object.Object) []object.Object {
+	switch {
+	case n == 2:
+		return []object.Object{key, value}
+	case it.KeysOnly:
+		return []object.Object{key}
+	}
+	return []object.Object{value}
+}
+
+// newIterator returns an iterator over the elements of an array, the
+// entries of a map, the characters of a string or the numbers of a range
+func newIterator(rt *object.Runtime, obj object.Object) (*object.Iterator, *object.Error) {
+	switch obj := obj.(type) {
+	case *object.Array:
+		// Elements added during the loop are not visited
+		elements := obj.Elements
+		i := 0
+		return &object.Iterator{Next: func() (object.Object, object.Object, bool) {
+			if i >= len(elements) {
+				return nil, nil, false
+			}
+			i++
+			return &object.Integer{Value: int64(i - 1)}, elements[i-1], true
+		}}, nil
+
+	case *object.Map:
+		// The loop goes through the entries the map had when it started
+		pairs := obj.Pairs()
+		i := 0
+		return &object.Iterator{KeysOnly: true, Next: func() (object.Object, object.Object, bool) {
+			if i >= len(pairs) {
+				return nil, nil, false
+			}
+			i++
+			return pairs[i-1].Key, pairs[i-1].Value, true
+		}}, nil
+
+	case *object.String:
+		s := obj.Value
+		pos, i := 0, 0
+		return &object.Iterator{Next: func() (object.Object, object.Object, bool) {
+			if pos >= len(s) {
+				return nil, nil, false
+			}
+			_, size := utf8.DecodeRuneInString(s[pos:])
+			char := s[pos : pos+size]
+			pos += size
+			i++
+			key := &object.Integer{Value: int64(i - 1)}
+			if errObj := rt.Allocate(object.StringSize(len(char))); errObj != nil {
+				return key, errObj, true
+			}
+			return key, &object.String{Value: char}, true
+		}}, nil
+
+	case *object.Range:
+		return rangeIterator(obj), nil
+	}
+	return nil, newError("ojok ... dalem butuh daftar, peta, teks, atau rentang, dapat %s", obj.Type())
+}
+
+// rangeIterator produces the numbers of a range one at a time
+func rangeIterator(r *object.Range) *object.Iterator {
+	next, i := r.Start, int64(0)
+	done := r.Step == 0
+	return &object.Iterator{Next: func() (object.Object, object.Object, bool) {
+		if done || (r.Step > 0 && next >= r.End) || (r.Step < 0 && next <= r.End) {
+			return nil, nil, false
+		}
+		n := next
+		// Stop rather than go past the largest or smallest integer
+		if (r.Step > 0 && n > math.MaxInt64-r.Step) || (r.Step < 0 && n < math.MinInt64-r.Step) {
+			done = true
+		} else {
+			next += r.Step
+		}
+		i++
+		return &object.Integer{Value: i - 1}, &object.Integer{Value: n}, true
+	}}
+}
+
+func evalTryStatement(node *ast.TryStatement, env *object.Environment) object.Object {
+	result := Eval(node.Block, env)
+
+	if errObj, ok := result.(*object.Error); ok && node.Catch != nil {
+		catchEnv := object.NewEnclosedEnvironment(env)
+		if node.CatchParam != nil {
+			catchEnv.Set(node.CatchParam.Value, errorToMap(errObj))
+		}
+		result = Eval(node.Catch, catchEnv)
+	}
+
+	if node.Finally != nil {
+		// akhirne always runs; leaving it via an error, tulakan, mentelah or
+		// lanjutan overrides whatever the try or catch block produced
+		finallyResult := Eval(node.Finally, env)
+		if finallyResult != nil {
+			rt := finallyResult.Type()
+			if rt == object.RETURN_VALUE_OBJ || rt == object.ERROR_OBJ || rt == object.BREAK_OBJ || rt == object.CONTINUE_OBJ {
+				return finallyResult
+			}
+		}
 	}
 
-	// Check if variable exists
-	if _, ok := env.Get(node.Name.Value); !ok {
-		return newError("variabel '%s' belum didefinisikan", node.Name.Value)
+	if result == nil {
+		return NULL
 	}
+	return result
+}
 
+func evalThrowStatement(node *ast.ThrowStatement, env *object.Environment) object.Object {
 	val := Eval(node.Value, env)
 	if isError(val) {
 		return val
 	}
 
-	env.Update(node.Name.Value, val)
+	return throwValue(val, node.Token.Line, node.Token.Column)
+}
+
+// throwValue builds the error raised by lempar at the given position
+func throwValue(val object.Object, line, column int) *object.Error {
+	errObj := &object.Error{
+		Message: val.Inspect(),
+		Line:    line,
+		Column:  column,
+		Value:   val,
+	}
+
+	// Re-throwing a caught error keeps its original message and position
+	if m, ok := val.(*object.Map); ok {
+		if msg, ok := mapGet(m, "pesan").(*object.String); ok {
+			errObj.Message = msg.Value
+			errObj.Value = mapGet(m, "nilai")
+			if line, ok := mapGet(m, "baris").(*object.Integer); ok {
+				errObj.Line = int(line.Value)
+			}
+			if col, ok := mapGet(m, "kolom").(*object.Integer); ok {
+				errObj.Column = int(col.Value)
+			}
+			if kind, ok := mapGet(m, "jenis").(*object.String); ok {
+				errObj.Kind = kind.Value
+			}
+		}
+	}
+
+	return errObj
+}
+
+// errorToMap converts a caught error into the map bound by tangkep, with the
+// keys "pesan" (message), "baris" (line), "kolom" (column), "nilai" (the
+// thrown value, or the message for runtime errors) and, for errors raised by
+// a limit of the run, "jenis" (the error's kind)
+func errorToMap(err *object.Error) *object.Map {
+	value := err.Value
+	if value == nil {
+		value = &object.String{Value: err.Message}
+	}
+
+	m := &object.Map{}
+	mapSet(m, "pesan", &object.String{Value: err.Message})
+	mapSet(m, "baris", &object.Integer{Value: int64(err.Line)})
+	mapSet(m, "kolom", &object.Integer{Value: int64(err.Column)})
+	mapSet(m, "nilai", value)
+	if err.Kind != "" {
+		mapSet(m, "jenis", &object.String{Value: err.Kind})
+	}
+	return m
+}
+
+func mapGet(m *object.Map, key string) object.Object {
+	val, _ := m.Get(&object.String{Value: key})
+	return val
+}
+
+func mapSet(m *object.Map, key string, val object.Object) {
+	m.Set(&object.String{Value: key}, val)
+}
+
+func evalImportStatement(node *ast.ImportStatement, env *object.Environment) object.Object {
+	name, errObj := moduleBindingName(node)
+	if errObj != nil {
+		return errObj
+	}
+
+	module := importModule(node.Path, env)
+	if isError(module) {
+		return module
+	}
+
+	env.Set(name, module)
+	return module
+}
+
+// moduleBindingName returns the name an impor statement binds the module to
+func moduleBindingName(node *ast.ImportStatement) (string, *object.Error) {
+	if node.Alias != nil {
+		return node.Alias.Value, nil
+	}
+
+	name := strings.TrimSuffix(filepath.Base(node.Path), filepath.Ext(node.Path))
+	if !isValidIdentifier(name) {
+		return "", newError("nama modul '%s' tidak valid, gunakan 'sebagai' untuk memberi nama", name)
+	}
+	return name, nil
+}
+
+// importModule loads the file at path, resolved relative to the importing
+// file, evaluating it only the first time it is imported in a run
+func importModule(path string, env *object.Environment) object.Object {
+	if errObj := checkImportAllowed(env.Runtime(), path); errObj != nil {
+		return errObj
+	}
+
+	absPath, errObj := resolveModulePath(path, env.File())
+	if errObj != nil {
+		return errObj
+	}
+
+	cache := env.Modules()
+	if module, ok := cache.Modules[absPath]; ok {
+		return module
+	}
+
+	if errObj := checkImportCycle(cache, absPath); errObj != nil {
+		return errObj
+	}
+
+	program, errObj := parseModule(path, absPath)
+	if errObj != nil {
+		return errObj
+	}
+
+	cache.Loading = append(cache.Loading, absPath)
+	moduleEnv := object.NewModuleEnvironment(env, absPath)
+	result := Eval(program, moduleEnv)
+	cache.Loading = cache.Loading[:len(cache.Loading)-1]
+	if isError(result) {
+		return result
+	}
+
+	module := &object.Module{
+		Name: strings.TrimSuffix(filepath.Base(absPath), filepath.Ext(absPath)),
+		Path: absPath,
+		Env:  moduleEnv,
+	}
+	cache.Modules[absPath] = module
+	return module
+}
+
+// resolveModulePath turns an impor path into an absolute file path. Relative
+// paths are resolved against the directory of the importing file.
+func resolveModulePath(path, importer string) (string, *object.Error) {
+	if filepath.Ext(path) == "" {
+		path += ".ssk"
+	}
+	if !filepath.IsAbs(path) && importer != "" {
+		path = filepath.Join(filepath.Dir(importer), path)
+	}
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return "", newError("gagal membaca modul '%s': %s", path, err)
+	}
+	return absPath, nil
+}
+
+// checkImportAllowed reports an error if the host has disabled imports
+func checkImportAllowed(rt *object.Runtime, path string) *object.Error {
+	if rt.DisableImports {
+		return newError("impor tidak diizinkan: '%s'", path)
+	}
+	return nil
+}
+
+// checkImportCycle reports an error if absPath is still being loaded
+func checkImportCycle(cache *object.ModuleCache, absPath string) *object.Error {
+	for i, loading := range cache.Loading {
+		if loading == absPath {
+			chain := append(append([]string{}, cache.Loading[i:]...), absPath)
+			for j := range chain {
+				chain[j] = filepath.Base(chain[j])
+			}
+			return newError("impor melingkar: %s", strings.Join(chain, " -> "))
+		}
+	}
+	return nil
+}
+
+// parseModule reads and parses a module file
+func parseModule(path, absPath string) (*ast.Program, *object.Error) {
+	content, err := os.ReadFile(absPath)
+	if err != nil {
+		return nil, newError("gagal membaca modul '%s': %s", path, err)
+	}
+
+	p := parser.New(lexer.New(string(content)))
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		return nil, newError("modul '%s' gagal di-parse: %s", filepath.Base(absPath), p.Errors()[0])
+	}
+	return program, nil
+}
+
+func isValidIdentifier(name string) bool {
+	l := lexer.New(name)
+	tok := l.NextToken()
+	return tok.Type == token.IDENT && tok.Literal == name && l.NextToken().Type == token.EOF
+}
+
+func evalAssignmentExpression(node *ast.AssignmentExpression, env *object.Environment) object.Object {
+	compound := node.Operator != ""
+
+	_, val := evalUpdateTarget(node.Target, env, compound, func(current object.Object) object.Object {
+		val := Eval(node.Value, env)
+		if isError(val) || !compound {
+			return val
+		}
+		return evalInfixExpression(env.Runtime(), node.Operator, current, val)
+	})
+
 	return val
 }
 
+func evalPostfixExpression(node *ast.PostfixExpression, env *object.Environment) object.Object {
+	old, val := evalUpdateTarget(node.Target, env, true, func(current object.Object) object.Object {
+		return evalPostfixUpdate(env.Runtime(), node.Operator, current)
+	})
+	if isError(val) {
+		return val
+	}
+
+	// Like i++ in C, the expression yields the value before the update
+	return old
+}
+
+// evalPostfixUpdate computes the new value of an operand of ++ or --
+func evalPostfixUpdate(rt *object.Runtime, operator string, current object.Object) object.Object {
+	if !isNumber(current) {
+		return newError("operator %s butuh angka, dapat %s", operator, current.Type())
+	}
+
+	if operator == "--" {
+		return evalInfixExpression(rt, "-", current, &object.Integer{Value: 1})
+	}
+	return evalInfixExpression(rt, "+", current, &object.Integer{Value: 1})
+}
+
+// evalUpdateTarget stores update's result in an assignment target. When
+// readCurrent is set, the target's current value is looked up first and passed
+// to update. It returns the previous value and the stored value (or an error).
+func evalUpdateTarget(target ast.Expression, env *object.Environment, readCurrent bool, update func(current object.Object) object.Object) (object.Object, object.Object) {
+	switch target := target.(type) {
+	case *ast.Identifier:
+		// Check if it's a constant
+		if env.IsConst(target.Value) {
+			return nil, newError("tidak bisa mengubah konstanta '%s'", target.Value)
+		}
+
+		// Check if variable exists
+		current, ok := env.Get(target.Value)
+		if !ok {
+			return nil, newError("variabel '%s' belum didefinisikan", target.Value)
+		}
+
+		val := update(current)
+		if isError(val) {
+			return nil, val
+		}
+
+		env.Update(target.Value, val)
+		return current, val
+
+	case *ast.IndexExpression:
+		return evalUpdateElement(target, target.Left, func() object.Object {
+			return Eval(target.Index, env)
+		}, env, readCurrent, update)
+
+	case *ast.MemberExpression:
+		return evalUpdateElement(target, target.Left, func() object.Object {
+			return &object.String{Value: target.Property.Value}
+		}, env, readCurrent, update)
+
+	default:
+		return nil, newError("tidak bisa assign ke %s", target.String())
+	}
+}
+
+// evalUpdateElement is evalUpdateTarget for an element of a collection,
+// written as left[index] or left.name
+func evalUpdateElement(target, left ast.Expression, evalIndex func() object.Object, env *object.Environment, readCurrent bool, update func(current object.Object) object.Object) (object.Object, object.Object) {
+	// Collections held by a constant cannot be modified either
+	if root := rootIdentifier(target); root != nil && env.IsConst(root.Value) {
+		return nil, newError("tidak bisa mengubah konstanta '%s'", root.Value)
+	}
+
+	container := Eval(left, env)
+	if isError(container) {
+		return nil, container
+	}
+
+	index := evalIndex()
+	if isError(index) {
+		return nil, index
+	}
+
+	var current object.Object
+	if readCurrent {
+		current = evalIndexForUpdate(container, index)
+		if isError(current) {
+			return nil, current
+		}
+	}
+
+	val := update(current)
+	if isError(val) {
+		return nil, val
+	}
+
+	if result := evalSetIndex(env.Runtime(), container, index, val); isError(result) {
+		return nil, result
+	}
+	return current, val
+}
+
+// evalIndexForUpdate reads the element a compound assignment will overwrite.
+// Unlike a plain index read, an array index out of range is an error.
+func evalIndexForUpdate(container, index object.Object) object.Object {
+	if arr, ok := container.(*object.Array); ok {
+		if idx, ok := index.(*object.Integer); ok && (idx.Value < 0 || idx.Value >= int64(len(arr.Elements))) {
+			return newError("indeks di luar batas: %d", idx.Value)
+		}
+	}
+	return evalIndexExpression(container, index)
+}
+
+// rootIdentifier returns the variable an index chain such as a[0]["k"] starts from
+func rootIdentifier(node ast.Expression) *ast.Identifier {
+	for {
+		switch n := node.(type) {
+		case *ast.Identifier:
+			return n
+		case *ast.IndexExpression:
+			node = n.Left
+		case *ast.MemberExpression:
+			node = n.Left
+		default:
+			return nil
+		}
+	}
+}
+
+func evalSetIndex(rt *object.Runtime, container, index, val object.Object) object.Object {
+	switch container := container.(type) {
+	case *object.Array:
+		idx, ok := index.(*object.Integer)
+		if !ok {
+			return newError("indeks daftar harus angka, dapat %s", index.Type())
+		}
+		if idx.Value < 0 || idx.Value >= int64(len(container.Elements)) {
+			return newError("indeks di luar batas: %d", idx.Value)
+		}
+		container.Elements[idx.Value] = val
+		return val
+	case *object.Map:
+		if _, ok := object.HashOf(index); !ok {
+			return newError("tipe %s tidak bisa digunakan sebagai kunci map", index.Type())
+		}
+		if _, ok := container.Get(index); !ok {
+			if errObj := rt.Allocate(object.MapPairSize); errObj != nil {
+				return errObj
+			}
+		}
+		container.Set(index, val)
+		return val
+	case *object.Module:
+		return newError("anggota modul '%s' tidak bisa diubah", index.Inspect())
+	default:
+		return newError("tipe %s tidak bisa diakses dengan indeks", container.Type())
+	}
+}
+
 func nativeBoolToBooleanObject(input bool) *object.Boolean {
 	if input {
 		return TRUE
@@ -305,27 +875,69 @@ func evalBangOperatorExpression(right object.Object) object.Object {
 }
 
 func evalMinusPrefixOperatorExpression(right object.Object) object.Object {
-	if right.Type() != object.INTEGER_OBJ {
+	switch right := right.(type) {
+	case *object.Integer:
+		if right.Value == math.MinInt64 {
+			return object.NewInteger(new(big.Int).Neg(big.NewInt(right.Value)))
+		}
+		return &object.Integer{Value: -right.Value}
+	case *object.BigInteger:
+		return object.NewInteger(new(big.Int).Neg(right.Value))
+	case *object.Float:
+		return &object.Float{Value: -right.Value}
+	default:
 		return newError("operator tidak dikenal: -%s", right.Type())
 	}
-	value := right.(*object.Integer).Value
-	return &object.Integer{Value: -value}
 }
 
-func evalInfixExpression(operator string, left, right object.Object) object.Object {
+func isLogicalOperator(operator string) bool {
+	switch operator {
+	case "ance", "&&", "atau", "||":
+		return true
+	}
+	return false
+}
+
+// evalLogicalExpression evaluates ance and atau. The right operand is only
+// evaluated when the left one does not decide the result, and the result is
+// always kenak or salak.
+func evalLogicalExpression(node *ast.InfixExpression, env *object.Environment) object.Object {
+	left := Eval(node.Left, env)
+	if isError(left) {
+		return left
+	}
+	and := node.Operator == "ance" || node.Operator == "&&"
+	if isTruthy(left) != and {
+		// salak ance ... and kenak atau ... never look at the right side
+		return nativeBoolToBooleanObject(!and)
+	}
+	right := Eval(node.Right, env)
+	if isError(right) {
+		return right
+	}
+	return nativeBoolToBooleanObject(isTruthy(right))
+}
+
+func evalInfixExpression(rt *object.Runtime, operator string, left, right object.Object) object.Object {
 	switch {
 	case left.Type() == object.INTEGER_OBJ && right.Type() == object.INTEGER_OBJ:
-		return evalIntegerInfixExpression(operator, left, right)
+		return evalIntegerInfixExpression(rt, operator, left, right)
+	case isInteger(left) && isInteger(right):
+		return evalBigIntegerInfixExpression(rt, operator, left, right)
+	case isNumber(left) && isNumber(right):
+		return evalFloatInfixExpression(operator, left, right)
 	case left.Type() == object.STRING_OBJ && right.Type() == object.STRING_OBJ:
-		return evalStringInfixExpression(operator, left, right)
-	case operator == "==":
-		return nativeBoolToBooleanObject(left == right)
-	case operator == "!=":
-		return nativeBoolToBooleanObject(left != right)
-	case operator == "ance" || operator == "&&":
-		return nativeBoolToBooleanObject(isTruthy(left) && isTruthy(right))
-	case operator == "atau" || operator == "||":
-		return nativeBoolToBooleanObject(isTruthy(left) || isTruthy(right))
+		return evalStringInfixExpression(rt, operator, left, right)
+	case operator == "==" || operator == "!=":
+		// Values of different types are never equal, so comparing them is
+		// most likely a mistake; only ndarak can be compared with anything
+		if left.Type() != right.Type() && left != NULL && right != NULL {
+			return newError("tipe tidak cocok: %s %s %s", left.Type(), operator, right.Type())
+		}
+		return nativeBoolToBooleanObject(valuesEqual(left, right, nil) == (operator == "=="))
+	case operator == "<" || operator == ">" || operator == "<=" || operator == ">=":
+		// Only two numbers or two strings have an order
+		return newError("%s dan %s tidak bisa dibandingkan dengan %s", left.Type(), right.Type(), operator)
 	case left.Type() != right.Type():
 		return newError("tipe tidak cocok: %s %s %s", left.Type(), operator, right.Type())
 	default:
@@ -333,21 +945,104 @@ func evalInfixExpression(operator string, left, right object.Object) object.Obje
 	}
 }
 
-func evalIntegerInfixExpression(operator string, left, right object.Object) object.Object {
+// valuePair is a pair of arrays or maps being compared by valuesEqual
+type valuePair struct {
+	left, right object.Object
+}
+
+// valuesEqual reports whether two values are equal. Numbers, strings,
+// booleans and ndarak are compared by value, so 1 == 1.0, and arrays and maps
+// by their contents; a map's order does not matter. Other values, such as
+// functions, are only equal to themselves. seen holds the arrays and maps
+// being compared further up, so that values containing themselves are
+// compared without going round forever.
+func valuesEqual(left, right object.Object, seen map[valuePair]bool) bool {
+	if isNumber(left) && isNumber(right) {
+		return object.NumbersEqual(left, right)
+	}
+
+	switch l := left.(type) {
+	case *object.String:
+		r, ok := right.(*object.String)
+		return ok && l.Value == r.Value
+	case *object.Boolean:
+		r, ok := right.(*object.Boolean)
+		return ok && l.Value == r.Value
+	case *object.Null:
+		_, ok := right.(*object.Null)
+		return ok
+	case *object.Array:
+		r, ok := right.(*object.Array)
+		if !ok || len(l.Elements) != len(r.Elements) {
+			return false
+		}
+		if l == r || seen[valuePair{l, r}] {
+			return true
+		}
+		if seen == nil {
+			seen = make(map[valuePair]bool)
+		}
+		seen[valuePair{l, r}] = true
+		for i := range l.Elements {
+			if !valuesEqual(l.Elements[i], r.Elements[i], seen) {
+				return false
+			}
+		}
+		return true
+	case *object.Map:
+		r, ok := right.(*object.Map)
+		if !ok || l.Len() != r.Len() {
+			return false
+		}
+		if l == r || seen[valuePair{l, r}] {
+			return true
+		}
+		if seen == nil {
+			seen = make(map[valuePair]bool)
+		}
+		seen[valuePair{l, r}] = true
+		for _, pair := range l.Pairs() {
+			val, ok := r.Get(pair.Key)
+			if !ok || !valuesEqual(pair.Value, val, seen) {
+				return false
+			}
+		}
+		return true
+	}
+	return left == right
+}
+
+// evalIntegerInfixExpression handles two Integer operands. Arithmetic that
+// overflows int64 is redone with big integers.
+func evalIntegerInfixExpression(rt *object.Runtime, operator string, left, right object.Object) object.Object {
 	leftVal := left.(*object.Integer).Value
 	rightVal := right.(*object.Integer).Value
 
 	switch operator {
 	case "+":
-		return &object.Integer{Value: leftVal + rightVal}
+		sum := leftVal + rightVal
+		if (sum > leftVal) != (rightVal > 0) {
+			return evalBigIntegerInfixExpression(rt, operator, left, right)
+		}
+		return &object.Integer{Value: sum}
 	case "-":
-		return &object.Integer{Value: leftVal - rightVal}
+		diff := leftVal - rightVal
+		if (diff < leftVal) != (rightVal > 0) {
+			return evalBigIntegerInfixExpression(rt, operator, left, right)
+		}
+		return &object.Integer{Value: diff}
 	case "*":
+		if !multiplyFits(leftVal, rightVal) {
+			return evalBigIntegerInfixExpression(rt, operator, left, right)
+		}
 		return &object.Integer{Value: leftVal * rightVal}
 	case "/":
 		if rightVal == 0 {
 			return newError("pembagian dengan nol")
 		}
+		if leftVal == math.MinInt64 && rightVal == -1 {
+			return evalBigIntegerInfixExpression(rt, operator, left, right)
+		}
 		return &object.Integer{Value: leftVal / rightVal}
 	case "%":
 		if rightVal == 0 {
@@ -363,30 +1058,198 @@ func evalIntegerInfixExpression(operator string, left, right object.Object) obje
 	case ">=":
 		return nativeBoolToBooleanObject(leftVal >= rightVal)
 	case "==":
-		return nativeBoolToBooleanObject(leftVal == rightVal)
+		return nativeBoolToBooleanObject(object.NumbersEqual(left, right))
 	case "!=":
-		return nativeBoolToBooleanObject(leftVal != rightVal)
+		return nativeBoolToBooleanObject(!object.NumbersEqual(left, right))
+	default:
+		return newError("operator tidak dikenal: %s %s %s", left.Type(), operator, right.Type())
+	}
+}
+
+// multiplyFits reports whether a*b fits in int64
+func multiplyFits(a, b int64) bool {
+	if a == 0 || b == 0 {
+		return true
+	}
+	if (a == -1 && b == math.MinInt64) || (b == -1 && a == math.MinInt64) {
+		return false
+	}
+	return (a*b)/b == a
+}
+
+// evalBigIntegerInfixExpression handles integer operands of which at least
+// one is, or whose result is, a BigInteger. Division and remainder truncate
+// toward zero, like they do for Integer.
+func evalBigIntegerInfixExpression(rt *object.Runtime, operator string, left, right object.Object) object.Object {
+	leftVal, _ := object.BigValue(left)
+	rightVal, _ := object.BigValue(right)
+
+	var result *big.Int
+	switch operator {
+	case "+", "-":
+		result = new(big.Int)
+		if operator == "+" {
+			result.Add(leftVal, rightVal)
+		} else {
+			result.Sub(leftVal, rightVal)
+		}
+	case "*":
+		// The product is checked against the memory limit before it is
+		// computed, since it can take long to compute
+		if errObj := rt.Allocate(object.BigIntegerSize(leftVal.BitLen() + rightVal.BitLen())); errObj != nil {
+			return errObj
+		}
+		return object.NewInteger(new(big.Int).Mul(leftVal, rightVal))
+	case "/", "%":
+		if rightVal.Sign() == 0 {
+			return newError("pembagian dengan nol")
+		}
+		result = new(big.Int)
+		if operator == "/" {
+			result.Quo(leftVal, rightVal)
+		} else {
+			result.Rem(leftVal, rightVal)
+		}
+	case "<":
+		return nativeBoolToBooleanObject(leftVal.Cmp(rightVal) < 0)
+	case ">":
+		return nativeBoolToBooleanObject(leftVal.Cmp(rightVal) > 0)
+	case "<=":
+		return nativeBoolToBooleanObject(leftVal.Cmp(rightVal) <= 0)
+	case ">=":
+		return nativeBoolToBooleanObject(leftVal.Cmp(rightVal) >= 0)
+	case "==":
+		return nativeBoolToBooleanObject(leftVal.Cmp(rightVal) == 0)
+	case "!=":
+		return nativeBoolToBooleanObject(leftVal.Cmp(rightVal) != 0)
+	default:
+		return newError("operator tidak dikenal: %s %s %s", left.Type(), operator, right.Type())
+	}
+
+	if !result.IsInt64() {
+		if errObj := rt.Allocate(object.BigIntegerSize(result.BitLen())); errObj != nil {
+			return errObj
+		}
+	}
+	return object.NewInteger(result)
+}
+
+// compareIntegers compares two Integer or BigInteger values like Cmp
+func compareIntegers(left, right object.Object) int {
+	l, lok := left.(*object.Integer)
+	r, rok := right.(*object.Integer)
+	if lok && rok {
+		switch {
+		case l.Value < r.Value:
+			return -1
+		case l.Value > r.Value:
+			return 1
+		}
+		return 0
+	}
+	leftVal, _ := object.BigValue(left)
+	rightVal, _ := object.BigValue(right)
+	return leftVal.Cmp(rightVal)
+}
+
+// evalFloatInfixExpression handles float operands and mixed integer/float
+// operands. Integers are widened to float, and division follows IEEE 754
+// rules, so dividing by zero yields an infinity or NaN instead of an error.
+func evalFloatInfixExpression(operator string, left, right object.Object) object.Object {
+	leftVal := toFloat(left)
+	rightVal := toFloat(right)
+
+	switch operator {
+	case "+":
+		return &object.Float{Value: leftVal + rightVal}
+	case "-":
+		return &object.Float{Value: leftVal - rightVal}
+	case "*":
+		return &object.Float{Value: leftVal * rightVal}
+	case "/":
+		return &object.Float{Value: leftVal / rightVal}
+	case "%":
+		return &object.Float{Value: math.Mod(leftVal, rightVal)}
+	case "<":
+		return nativeBoolToBooleanObject(leftVal < rightVal)
+	case ">":
+		return nativeBoolToBooleanObject(leftVal > rightVal)
+	case "<=":
+		return nativeBoolToBooleanObject(leftVal <= rightVal)
+	case ">=":
+		return nativeBoolToBooleanObject(leftVal >= rightVal)
+	case "==":
+		return nativeBoolToBooleanObject(object.NumbersEqual(left, right))
+	case "!=":
+		return nativeBoolToBooleanObject(!object.NumbersEqual(left, right))
 	default:
 		return newError("operator tidak dikenal: %s %s %s", left.Type(), operator, right.Type())
 	}
 }
 
-func evalStringInfixExpression(operator string, left, right object.Object) object.Object {
+func isNumber(obj object.Object) bool {
+	t := obj.Type()
+	return t == object.INTEGER_OBJ || t == object.BIG_INTEGER_OBJ || t == object.FLOAT_OBJ
+}
+
+func isInteger(obj object.Object) bool {
+	t := obj.Type()
+	return t == object.INTEGER_OBJ || t == object.BIG_INTEGER_OBJ
+}
+
+func toFloat(obj object.Object) float64 {
+	switch obj := obj.(type) {
+	case *object.Integer:
+		return float64(obj.Value)
+	case *object.BigInteger:
+		f, _ := new(big.Float).SetInt(obj.Value).Float64()
+		return f
+	case *object.Float:
+		return obj.Value
+	default:
+		return 0
+	}
+}
+
+func evalStringInfixExpression(rt *object.Runtime, operator string, left, right object.Object) object.Object {
 	leftVal := left.(*object.String).Value
 	rightVal := right.(*object.String).Value
 
 	switch operator {
 	case "+":
+		if errObj := rt.Allocate(object.StringSize(len(leftVal) + len(rightVal))); errObj != nil {
+			return errObj
+		}
 		return &object.String{Value: leftVal + rightVal}
 	case "==":
 		return nativeBoolToBooleanObject(leftVal == rightVal)
 	case "!=":
 		return nativeBoolToBooleanObject(leftVal != rightVal)
+	// Strings are ordered by their characters' code points, as in a dictionary
+	// where upper case comes before lower case
+	case "<":
+		return nativeBoolToBooleanObject(leftVal < rightVal)
+	case ">":
+		return nativeBoolToBooleanObject(leftVal > rightVal)
+	case "<=":
+		return nativeBoolToBooleanObject(leftVal <= rightVal)
+	case ">=":
+		return nativeBoolToBooleanObject(leftVal >= rightVal)
 	default:
 		return newError("operator tidak dikenal: %s %s %s", left.Type(), operator, right.Type())
 	}
 }
 
+// joinTemplate builds the string of an interpolated string literal from the
+// values of its parts, each converted to text as cetak would print it
+func joinTemplate(rt *object.Runtime, parts []object.Object) object.Object {
+	text, errObj := rt.AllocateText(parts, "")
+	if errObj != nil {
+		return errObj
+	}
+	return &object.String{Value: text}
+}
+
 func evalIfExpression(ie *ast.IfExpression, env *object.Environment) object.Object {
 	condition := Eval(ie.Condition, env)
 	if isError(condition) {
@@ -420,7 +1283,7 @@ func evalIdentifier(node *ast.Identifier, env *object.Environment) object.Object
 		return val
 	}
 
-	if builtin, ok := builtins.Builtins[node.Value]; ok {
+	if builtin, ok := builtins.Lookup(node.Value); ok {
 		return builtin
 	}
 
@@ -441,19 +1304,69 @@ func evalExpressions(exps []ast.Expression, env *object.Environment) []object.Ob
 	return result
 }
 
-func applyFunction(fn object.Object, args []object.Object) object.Object {
+// evalCall calls function at node, counting calls of user functions
+// against the runtime's limits
+func evalCall(node *ast.CallExpression, function object.Object, args []object.Object, env *object.Environment) object.Object {
+	rt := env.Runtime()
+	switch function.(type) {
+	case *object.Function:
+		if errObj := rt.EnterCall(); errObj != nil {
+			return errObj
+		}
+		defer rt.LeaveCall()
+	case *object.Builtin:
+		// Functions the builtin calls back are called from here
+		prev := rt.SetCaller(&callSite{node: node, env: env})
+		defer rt.SetCaller(prev)
+	}
+
+	result := applyFunction(rt, function, args)
+	if errObj, ok := result.(*object.Error); ok {
+		addStackFrame(errObj, function, node, env)
+	}
+	return result
+}
+
+// callSite calls functions for a builtin called at node, as if node had
+// called them
+type callSite struct {
+	node *ast.CallExpression
+	env  *object.Environment
+}
+
+func (c *callSite) CallFunction(fn object.Object, args []object.Object) object.Object {
+	return evalCall(c.node, fn, args, c.env)
+}
+
+func applyFunction(rt *object.Runtime, fn object.Object, args []object.Object) object.Object {
 	switch fn := fn.(type) {
 	case *object.Function:
 		extendedEnv := extendFunctionEnv(fn, args)
 		evaluated := Eval(fn.Body, extendedEnv)
 		return unwrapReturnValue(evaluated)
 	case *object.Builtin:
-		return fn.Fn(args...)
+		return fn.Fn(rt, args...)
 	default:
 		return newError("bukan fungsi: %s", fn.Type())
 	}
 }
 
+// addStackFrame records that err passed out of a call to fn made at node.
+// Builtins do not get frames of their own.
+func addStackFrame(err *object.Error, fn object.Object, node *ast.CallExpression, env *object.Environment) {
+	function, ok := fn.(*object.Function)
+	if !ok {
+		return
+	}
+
+	name := function.Name
+	if name == "" {
+		name = "<anonim>"
+	}
+	line, column := ast.Position(node)
+	err.Stack = append(err.Stack, object.StackFrame{Function: name, File: env.File(), Line: line, Column: column})
+}
+
 func extendFunctionEnv(fn *object.Function, args []object.Object) *object.Environment {
 	env := object.NewEnclosedEnvironment(fn.Env)
 
@@ -477,13 +1390,30 @@ func evalIndexExpression(left, index object.Object) object.Object {
 	switch {
 	case left.Type() == object.ARRAY_OBJ && index.Type() == object.INTEGER_OBJ:
 		return evalArrayIndexExpression(left, index)
+	case left.Type() == object.ARRAY_OBJ && index.Type() == object.BIG_INTEGER_OBJ:
+		// A big index is always out of range
+		return NULL
 	case left.Type() == object.MAP_OBJ:
 		return evalMapIndexExpression(left, index)
+	case left.Type() == object.MODULE_OBJ && index.Type() == object.STRING_OBJ:
+		return evalModuleMemberExpression(left, index)
 	default:
 		return newError("tipe %s tidak bisa diakses dengan indeks", left.Type())
 	}
 }
 
+func evalModuleMemberExpression(module, name object.Object) object.Object {
+	moduleObject := module.(*object.Module)
+	memberName := name.(*object.String).Value
+
+	member, ok := moduleObject.Member(memberName)
+	if !ok {
+		return newError("modul '%s' tidak punya anggota '%s'", moduleObject.Name, memberName)
+	}
+
+	return member
+}
+
 func evalArrayIndexExpression(array, index object.Object) object.Object {
 	arrayObject := array.(*object.Array)
 	idx := index.(*object.Integer).Value
@@ -499,43 +1429,43 @@ func evalArrayIndexExpression(array, index object.Object) object.Object {
 func evalMapIndexExpression(mapObj, key object.Object) object.Object {
 	mapObject := mapObj.(*object.Map)
 
-	hashable, ok := key.(object.Hashable)
-	if !ok {
+	if _, ok := object.HashOf(key); !ok {
 		return newError("tipe %s tidak bisa digunakan sebagai kunci map", key.Type())
 	}
 
-	pair, ok := mapObject.Pairs[hashable.HashKey()]
+	val, ok := mapObject.Get(key)
 	if !ok {
 		return NULL
 	}
 
-	return pair.Value
+	return val
 }
 
 func evalMapLiteral(node *ast.MapLiteral, env *object.Environment) object.Object {
-	pairs := make(map[object.HashKey]object.MapPair)
+	m := &object.Map{}
 
-	for keyNode, valueNode := range node.Pairs {
-		key := Eval(keyNode, env)
+	for _, pair := range node.Pairs {
+		key := Eval(pair.Key, env)
 		if isError(key) {
 			return key
 		}
 
-		hashKey, ok := key.(object.Hashable)
-		if !ok {
+		if _, ok := object.HashOf(key); !ok {
 			return newError("tipe %s tidak bisa digunakan sebagai kunci map", key.Type())
 		}
 
-		value := Eval(valueNode, env)
+		value := Eval(pair.Value, env)
 		if isError(value) {
 			return value
 		}
 
-		hashed := hashKey.HashKey()
-		pairs[hashed] = object.MapPair{Key: key, Value: value}
+		m.Set(key, value)
 	}
 
-	return &object.Map{Pairs: pairs}
+	if errObj := env.Runtime().Allocate(object.MapSize(m.Len())); errObj != nil {
+		return errObj
+	}
+	return m
 }
 
 func newError(format string, a ...interface{}) *object.Error {
@@ -1,11 +1,18 @@
-package evaluator
+package evaluator_test
 
 import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
 	"testing"
 
+	"github.com/arjunaayasa/sasaklang/pkg/sasaklang/compiler"
+	"github.com/arjunaayasa/sasaklang/pkg/sasaklang/evaluator"
 	"github.com/arjunaayasa/sasaklang/pkg/sasaklang/lexer"
 	"github.com/arjunaayasa/sasaklang/pkg/sasaklang/object"
 	"github.com/arjunaayasa/sasaklang/pkg/sasaklang/parser"
+	"github.com/arjunaayasa/sasaklang/pkg/sasaklang/vm"
 )
 
 func TestEvalIntegerExpression(t *testing.T) {
@@ -37,6 +44,142 @@ func TestEvalIntegerExpression(t *testing.T) {
 	}
 }
 
+func TestEvalFloatExpression(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected float64
+	}{
+		{"2.5", 2.5},
+		{"-2.5", -2.5},
+		{"1.5 + 1.5", 3.0},
+		{"1 + 0.5", 1.5},
+		{"0.5 * 4", 2.0},
+		{"10 / 4.0", 2.5},
+		{"7.5 % 2", 1.5},
+		{"gawe total = 7 + 8 + 9; total / 3.0", 8.0},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		testFloatObject(t, evaluated, tt.expected)
+	}
+}
+
+func TestIntegerDivisionTruncates(t *testing.T) {
+	testIntegerObject(t, testEval("7 / 2"), 3)
+	testIntegerObject(t, testEval("-7 / 2"), -3)
+}
+
+func TestFloatDivisionByZero(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"1.0 / 0", "+Inf"},
+		{"-1 / 0.0", "-Inf"},
+		{"0.0 / 0", "NaN"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if _, ok := evaluated.(*object.Float); !ok {
+			t.Errorf("object is not Float. got=%T (%+v)", evaluated, evaluated)
+			continue
+		}
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("wrong result for %q. expected=%q, got=%q",
+				tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+}
+
+func TestBigIntegers(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		// Overflowing int64 switches to big integers instead of wrapping
+		{"9223372036854775807 + 1", "9223372036854775808"},
+		{"-9223372036854775807 - 2", "-9223372036854775809"},
+		{"4294967296 * 4294967296", "18446744073709551616"},
+		{"-9223372036854775808 / -1", "9223372036854775808"},
+		{"-(-9223372036854775807 - 1)", "9223372036854775808"},
+		{"gawe x = 9223372036854775807; x++; x", "9223372036854775808"},
+		{"gawe x = -9223372036854775808; x--; x", "-9223372036854775809"},
+		{"gawe x = 9223372036854775807; x += 10; x", "9223372036854775817"},
+		{"fungsi f(n) { lamun (n < 2) { 1 } endah { n * f(n - 1) } }; f(25)", "15511210043330985984000000"},
+		// Literals beyond int64
+		{"123456789012345678901234567890", "123456789012345678901234567890"},
+		{"123456789012345678901234567890 % 97", "52"},
+		{"-123456789012345678901234567890 / 1000000000000000000000", "-123456789"},
+		{"-123456789012345678901234567890 % 1000", "-890"},
+		// Results that fit again are plain integers
+		{"99999999999999999999 - 99999999999999999998", "1"},
+		{"jenis(99999999999999999999)", "angka"},
+		{"[1, 2, 3][99999999999999999999]", "ndarak"},
+		// Comparisons
+		{"99999999999999999999 > 9223372036854775807", "kenak"},
+		{"-99999999999999999999 < -9223372036854775808", "kenak"},
+		{"99999999999999999999 == 99999999999999999998 + 1", "kenak"},
+		{"99999999999999999999 != 99999999999999999999", "salak"},
+		{"1180591620717411303424 == 1180591620717411303424.0", "kenak"},
+		// The float nearest to 99999999999999999999 is 1e20
+		{"99999999999999999999 == 99999999999999999999.0", "salak"},
+		{"9223372036854775808 + 0.5", "9.223372036854776e+18"},
+		{"urutkan([99999999999999999999, 1, 99999999999999999998])", "[1, 99999999999999999998, 99999999999999999999]"},
+		// Big integers are map keys like any other integer
+		{"gawe m = {99999999999999999999: 1}; m[99999999999999999998 + 1]", "1"},
+		{"gawe m = {[99999999999999999999]: 1}; m[[99999999999999999999]]", "1"},
+		{"gawe m = {9223372036854775807: 1}; m[9223372036854775808 - 1]", "1"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated.Type() == object.ERROR_OBJ || evaluated.Inspect() != tt.expected {
+			t.Errorf("%s: expected %s, got=%s", tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+}
+
+func TestBigIntegerErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"99999999999999999999 / 0", "pembagian dengan nol"},
+		{"99999999999999999999 % 0", "pembagian dengan nol"},
+		{"rentang(99999999999999999999)", "argumen pertama rentang() terlalu besar"},
+		{`99999999999999999999 + "a"`, "tipe tidak cocok: BIG_INTEGER + STRING"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		errObj, ok := evaluated.(*object.Error)
+		if !ok || errObj.Message != tt.expected {
+			t.Errorf("%s: expected error %q, got=%T (%+v)", tt.input, tt.expected, evaluated, evaluated)
+		}
+	}
+}
+
+func TestFloatInspect(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"3.0", "3.0"},
+		{"0.1 + 0.2", "0.30000000000000004"},
+		{"1.5e3", "1500.0"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated.Inspect() != tt.expected {
+			t.Errorf("wrong Inspect() for %q. expected=%q, got=%q",
+				tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+}
+
 func TestEvalBooleanExpression(t *testing.T) {
 	tests := []struct {
 		input    string
@@ -64,10 +207,21 @@ func TestEvalBooleanExpression(t *testing.T) {
 		{"(1 < 2) == salak", false},
 		{"(1 > 2) == kenak", false},
 		{"(1 > 2) == salak", true},
+		{"1.5 < 2", true},
+		{"2 > 1.5", true},
+		{"1 == 1.0", true},
+		{"1.5 != 1.5", false},
+		{"2.5 >= 2.5", true},
 		{"kenak ance kenak", true},
 		{"kenak ance salak", false},
 		{"salak atau kenak", true},
 		{"salak atau salak", false},
+		{`"a" < "b"`, true},
+		{`"b" < "a"`, false},
+		{`"abc" > "ab"`, true},
+		{`"Z" < "a"`, true},
+		{`"a" <= "a"`, true},
+		{`"a" >= "b"`, false},
 	}
 
 	for _, tt := range tests {
@@ -76,6 +230,105 @@ func TestEvalBooleanExpression(t *testing.T) {
 	}
 }
 
+func TestStructuralEquality(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected bool
+	}{
+		{"[1, 2] == [1, 2]", true},
+		{"[1, 2] != [1, 2]", false},
+		{"[1, 2] == [2, 1]", false},
+		{"[1, 2] == [1, 2, 3]", false},
+		{"[1, [2, [3]]] == [1, [2, [3]]]", true},
+		{"[1, [2, [3]]] == [1, [2, [4]]]", false},
+		{"[1] == [1.0]", true},
+		{"[] == []", true},
+		{`{"a": 1, "b": [2]} == {"a": 1, "b": [2]}`, true},
+		// The order of a map's entries does not matter
+		{`{"a": 1, "b": 2} == {"b": 2, "a": 1}`, true},
+		{`{"a": 1} == {"a": 2}`, false},
+		{`{"a": 1} == {"b": 1}`, false},
+		{`{"a": 1} == {"a": 1, "b": 2}`, false},
+		// 1 and 1.0 are the same key, as 1 == 1.0
+		{`{1: "x"} == {1.0: "x"}`, true},
+		// Elements of different types are not equal
+		{`[1, "a"] == [1, 2]`, false},
+		{`{"a": [1]} == {"a": "1"}`, false},
+		// ndarak can be compared with anything
+		{`ndarak == ndarak`, true},
+		{`ndarak == salak`, false},
+		{`ndarak != 0`, true},
+		// Functions are only equal to themselves
+		{`fungsi f() { 1 }; f == f`, true},
+		{`fungsi() { 1 } == fungsi() { 1 }`, false},
+		{`cetak == cetak`, true},
+		// Values containing themselves
+		{`gawe a = [1, 0]; a[1] = a; gawe b = [1, 0]; b[1] = b; a == b`, true},
+		{`gawe a = [1, 0]; a[1] = a; gawe b = [2, 0]; b[1] = b; a == b`, false},
+		{`gawe a = {}; a["diri"] = a; gawe b = {}; b["diri"] = b; a == b`, true},
+		{`gawe a = [0]; a[0] = a; gawe b = [0]; b[0] = [b]; a == b`, true},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if !testBooleanObject(t, evaluated, tt.expected) {
+			t.Errorf("input: %s", tt.input)
+		}
+	}
+}
+
+func TestLogicalShortCircuit(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		// The right side is not evaluated once the left side decides
+		{`gawe x = ndarak; x != ndarak ance x[0] > 1`, "salak"},
+		{`gawe xs = []; xs == [] atau xs[0] > 1`, "kenak"},
+		{`salak ance tutu("bau")`, "salak"},
+		{`kenak || tutu("bau")`, "kenak"},
+		// Side effects of the right side only happen when it is evaluated
+		{`gawe n = 0; fungsi f() { n += 1; kenak }; salak ance f(); kenak ance f(); salak atau f(); kenak atau f(); n`, "2"},
+		// The result is always kenak or salak, never an operand
+		{`1 ance "a"`, "kenak"},
+		{`0 atau ""`, "kenak"},
+		{`ndarak atau salak`, "salak"},
+		{`ndarak atau [1]`, "kenak"},
+		{`[1] ance ndarak`, "salak"},
+		{`(1 ance 2) == kenak`, "kenak"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated.Type() == object.ERROR_OBJ || evaluated.Inspect() != tt.expected {
+			t.Errorf("%s: expected %s, got=%s", tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+}
+
+func TestComparisonErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`[1] < [2]`, "ARRAY dan ARRAY tidak bisa dibandingkan dengan <"},
+		{`"a" >= 1`, "STRING dan INTEGER tidak bisa dibandingkan dengan >="},
+		{`kenak > salak`, "BOOLEAN dan BOOLEAN tidak bisa dibandingkan dengan >"},
+		{`ndarak <= 0`, "NULL dan INTEGER tidak bisa dibandingkan dengan <="},
+		{`1 == "1"`, "tipe tidak cocok: INTEGER == STRING"},
+		{`[1] != {0: 1}`, "tipe tidak cocok: ARRAY != MAP"},
+		{`kenak == 1`, "tipe tidak cocok: BOOLEAN == INTEGER"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		errObj, ok := evaluated.(*object.Error)
+		if !ok || errObj.Message != tt.expected {
+			t.Errorf("%s: expected error %q, got=%T (%+v)", tt.input, tt.expected, evaluated, evaluated)
+		}
+	}
+}
+
 func TestBangOperator(t *testing.T) {
 	tests := []struct {
 		input    string
@@ -229,6 +482,57 @@ func TestStringConcatenation(t *testing.T) {
 	}
 }
 
+func TestStringEscapesAndUnicode(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`"baris1\nbaris2"`, "baris1\nbaris2"},
+		{`"\"kutip\" \\ \u{263A}"`, `"kutip" \ ☺`},
+		{"gawe niláé = \"Lombok\"\nniláé", "Lombok"},
+		// A combining accent continues an identifier
+		{"gawe cafe\u0301 = \"kopi\"\ncafe\u0301", "kopi"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		str, ok := evaluated.(*object.String)
+		if !ok || str.Value != tt.expected {
+			t.Errorf("%s: expected %q, got=%T (%+v)", tt.input, tt.expected, evaluated, evaluated)
+		}
+	}
+}
+
+func TestTemplateStrings(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`gawe nama = "Dunia"; "Halo, ${nama}!"`, "Halo, Dunia!"},
+		{`"${1 + 2} ${1.5} ${kenak} ${ndarak}"`, "3 1.5 kenak ndarak"},
+		{`"${[1, "a"]} ${{"k": 2}}"`, "[1, a] {k: 2}"},
+		{`gawe x = 2; "luar ${"dalam ${x * 10}"}"`, "luar dalam 20"},
+		{`fungsi sapa(n) { "hai ${n}" }; sapa("Ina")`, "hai Ina"},
+		{`gawe f = fungsi(a) { fungsi() { "${a}!" } }; f(7)()`, "7!"},
+		{`"\${x} $ {x}"`, "${x} $ {x}"},
+		{"`C:\\sasak\\n\nbaris ${1 + 1}`", "C:\\sasak\\n\nbaris 2"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		str, ok := evaluated.(*object.String)
+		if !ok || str.Value != tt.expected {
+			t.Errorf("%s: expected %q, got=%T (%+v)", tt.input, tt.expected, evaluated, evaluated)
+		}
+	}
+
+	evaluated := testEval(`"a ${tidakAda} b"`)
+	errObj, ok := evaluated.(*object.Error)
+	if !ok || errObj.Line != 1 || errObj.Column != 6 {
+		t.Errorf("expected an error at 1:6, got=%T (%+v)", evaluated, evaluated)
+	}
+}
+
 func TestArrayLiterals(t *testing.T) {
 	input := "[1, 2 * 2, 3 + 3]"
 
@@ -276,6 +580,139 @@ func TestArrayIndexExpressions(t *testing.T) {
 	}
 }
 
+func TestIndexAssignment(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"gawe a = [1, 2, 3]; a[0] = 10; a[0]", 10},
+		{"gawe a = [1, 2, 3]; a[1 + 1] = a[0] + a[1]; a[2]", 3},
+		{"gawe a = [[1, 2], [3, 4]]; a[1][0] = 9; a[1][0]", 9},
+		{`gawe m = {"x": 1}; m["x"] = 5; m["x"]`, 5},
+		{`gawe m = {}; m["baru"] = 7; m["baru"]`, 7},
+		{`gawe m = {"d": [1]}; m["d"][0] = 4; m["d"][0]`, 4},
+		{"gawe a = [0]; a[0] = 6", 6},
+	}
+
+	for _, tt := range tests {
+		testIntegerObject(t, testEval(tt.input), int64(tt.expected.(int)))
+	}
+}
+
+func TestIndexAssignmentSharesArray(t *testing.T) {
+	input := `
+gawe a = [1, 2]
+gawe b = a
+b[0] = 3
+a[0]
+`
+	testIntegerObject(t, testEval(input), 3)
+}
+
+func TestIndexAssignmentErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"gawe a = [1, 2]; a[2] = 1", "indeks di luar batas: 2"},
+		{"gawe a = [1, 2]; a[-1] = 1", "indeks di luar batas: -1"},
+		{`gawe a = [1]; a["x"] = 1`, "indeks daftar harus angka, dapat STRING"},
+		{"tetep A = [1, 2]; A[0] = 5", "tidak bisa mengubah konstanta 'A'"},
+		{`tetep M = {"k": [1]}; M["k"][0] = 5`, "tidak bisa mengubah konstanta 'M'"},
+		{`gawe m = {}; m[[1, {}]] = 1`, "tipe ARRAY tidak bisa digunakan sebagai kunci map"},
+		{`gawe a = [1]; a[0] = a; gawe m = {}; m[a] = 1`, "tipe ARRAY tidak bisa digunakan sebagai kunci map"},
+		{`gawe s = "abc"; s[0] = "x"`, "tipe STRING tidak bisa diakses dengan indeks"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		errObj, ok := evaluated.(*object.Error)
+		if !ok {
+			t.Errorf("expected error object for %q, got=%T (%+v)", tt.input, evaluated, evaluated)
+			continue
+		}
+		if errObj.Message != tt.expected {
+			t.Errorf("wrong error message. expected=%q, got=%q", tt.expected, errObj.Message)
+		}
+	}
+}
+
+func TestCompoundAssignment(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int64
+	}{
+		{"gawe x = 5; x += 3; x", 8},
+		{"gawe x = 5; x -= 3; x", 2},
+		{"gawe x = 5; x *= 3; x", 15},
+		{"gawe x = 7; x /= 2; x", 3},
+		{"gawe x = 7; x %= 4; x", 3},
+		{"gawe x = 1; x += 2", 3},
+		{"gawe x = 1; x += x += 1; x", 3},
+		{"gawe a = [1, 2]; a[1] += 10; a[1]", 12},
+		{`gawe m = {"n": 4}; m["n"] *= 2; m["n"]`, 8},
+		{"gawe x = 0; fungsi tambah() { x += 1 }; tambah(); tambah(); x", 2},
+	}
+
+	for _, tt := range tests {
+		testIntegerObject(t, testEval(tt.input), tt.expected)
+	}
+
+	testFloatObject(t, testEval("gawe x = 1; x /= 2.0; x"), 0.5)
+
+	str := testEval(`gawe s = "ab"; s += "c"; s`)
+	if str.Inspect() != "abc" {
+		t.Errorf("string += wrong. got=%q", str.Inspect())
+	}
+}
+
+func TestIncrementDecrement(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int64
+	}{
+		{"gawe i = 5; i++; i", 6},
+		{"gawe i = 5; i--; i", 4},
+		{"gawe i = 5; i++", 5},
+		{"gawe i = 5; i++ + i", 11},
+		{"gawe a = [1]; a[0]++; a[0]", 2},
+		{"gawe sum = 0; ojok (gawe i = 1; i <= 5; i++) { sum += i }; sum", 15},
+	}
+
+	for _, tt := range tests {
+		testIntegerObject(t, testEval(tt.input), tt.expected)
+	}
+
+	testFloatObject(t, testEval("gawe f = 1.5; f++; f"), 2.5)
+}
+
+func TestCompoundAssignmentErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"tetep X = 1; X += 1", "tidak bisa mengubah konstanta 'X'"},
+		{"tetep X = 1; X++", "tidak bisa mengubah konstanta 'X'"},
+		{"tetep A = [1]; A[0] += 1", "tidak bisa mengubah konstanta 'A'"},
+		{"y += 1", "variabel 'y' belum didefinisikan"},
+		{"gawe a = [1]; a[3] += 1", "indeks di luar batas: 3"},
+		{`gawe s = "a"; s++`, "operator ++ butuh angka, dapat STRING"},
+		{"gawe x = 1; x /= 0", "pembagian dengan nol"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		errObj, ok := evaluated.(*object.Error)
+		if !ok {
+			t.Errorf("expected error object for %q, got=%T (%+v)", tt.input, evaluated, evaluated)
+			continue
+		}
+		if errObj.Message != tt.expected {
+			t.Errorf("wrong error message. expected=%q, got=%q", tt.expected, errObj.Message)
+		}
+	}
+}
+
 func TestConstReassignError(t *testing.T) {
 	input := `
 tetep PI = 3
@@ -294,6 +731,32 @@ PI = 4
 	}
 }
 
+func TestLocalShadowsConst(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		// A local variable with the name of an outer constant can change
+		{"tetep a = 1; fungsi f() { gawe a = 2; a = 3; a }; [f(), a]", "[3, 1]"},
+		{"tetep a = 1; fungsi f() { gawe a = 2; a += 3; a }; f()", "5"},
+		{"tetep a = [1]; fungsi f() { gawe a = [2]; a[0] = 3; a }; [f(), a]", "[[3], [1]]"},
+		{"tetep a = 1; fungsi f(a) { a++; a }; f(5)", "6"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated.Type() == object.ERROR_OBJ || evaluated.Inspect() != tt.expected {
+			t.Errorf("%s: expected %s, got=%s", tt.input, tt.expected, evaluated.Inspect())
+		}
+	}
+
+	// The constant itself still cannot change from inside a function
+	evaluated := testEval("tetep a = 1; fungsi f() { a = 3 }; f()")
+	if errObj, ok := evaluated.(*object.Error); !ok || errObj.Message != "tidak bisa mengubah konstanta 'a'" {
+		t.Errorf("expected the constant error, got=%s", evaluated.Inspect())
+	}
+}
+
 func TestDivisionByZeroError(t *testing.T) {
 	input := "10 / 0"
 	evaluated := testEval(input)
@@ -322,58 +785,93 @@ func TestUndefinedVariableError(t *testing.T) {
 	}
 }
 
-func TestWhileLoop(t *testing.T) {
-	input := `
-gawe x = 0
-gawe sum = 0
-selame (x < 5) {
-    sum = sum + x
-    x = x + 1
-}
-sum
-`
-	evaluated := testEval(input)
-	testIntegerObject(t, evaluated, 10) // 0+1+2+3+4 = 10
+func TestRuntimeErrorPosition(t *testing.T) {
+	tests := []struct {
+		input  string
+		line   int
+		column int
+	}{
+		{"10 / 0", 1, 4},
+		{"x", 1, 1},
+		{"gawe a = 1;\ngawe b = a + y;", 2, 14},
+		{"gawe f = fungsi() {\n  tulakan 1 - \"a\";\n};\nf();", 2, 13},
+		{"gawe arr = [1];\narr[5] = 2;", 2, 8},
+		// Columns count characters, not bytes
+		{"gawe niláé = \"é\" + 1", 1, 18},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		errObj, ok := evaluated.(*object.Error)
+		if !ok {
+			t.Errorf("expected error object for %q, got=%T (%+v)", tt.input, evaluated, evaluated)
+			continue
+		}
+		if errObj.Line != tt.line || errObj.Column != tt.column {
+			t.Errorf("wrong position for %q. expected=%d:%d, got=%d:%d",
+				tt.input, tt.line, tt.column, errObj.Line, errObj.Column)
+		}
+	}
 }
 
-func TestForLoop(t *testing.T) {
-	input := `
-gawe sum = 0
-ojok (gawe i = 1; i <= 5; i = i + 1) {
-    sum = sum + i
+func TestRuntimeErrorStack(t *testing.T) {
+	input := `fungsi dalam(x) {
+  tulakan x / 0;
 }
-sum
-`
-	evaluated := testEval(input)
-	testIntegerObject(t, evaluated, 15) // 1+2+3+4+5 = 15
+fungsi luar(x) {
+  tulakan dalam(x);
 }
+luar(1);`
 
-func TestBuiltinFunctions(t *testing.T) {
+	evaluated := testEvalFile("main.ssk", input)
+	errObj, ok := evaluated.(*object.Error)
+	if !ok {
+		t.Fatalf("expected error object, got=%T (%+v)", evaluated, evaluated)
+	}
+
+	expected := []object.StackFrame{
+		{Function: "dalam", File: "main.ssk", Line: 5, Column: 16},
+		{Function: "luar", File: "main.ssk", Line: 7, Column: 5},
+	}
+	if len(errObj.Stack) != len(expected) {
+		t.Fatalf("wrong stack length. expected=%d, got=%d (%+v)", len(expected), len(errObj.Stack), errObj.Stack)
+	}
+	for i, frame := range expected {
+		if errObj.Stack[i] != frame {
+			t.Errorf("stack[%d] wrong. expected=%+v, got=%+v", i, frame, errObj.Stack[i])
+		}
+	}
+	if errObj.File != "main.ssk" || errObj.Line != 2 {
+		t.Errorf("wrong error location. got=%s:%d", errObj.File, errObj.Line)
+	}
+}
+
+func TestTryCatch(t *testing.T) {
 	tests := []struct {
 		input    string
 		expected interface{}
 	}{
-		{`belong("")`, 0},
-		{`belong("four")`, 4},
-		{`belong("hello world")`, 11},
-		{`belong([1, 2, 3])`, 3},
-		{`jenis(5)`, "angka"},
-		{`jenis("hello")`, "teks"},
-		{`jenis(kenak)`, "boolean"},
-		{`jenis(ndarak)`, "ndarak"},
-		{`jenis([1, 2])`, "daftar"},
+		{"coba { 10 / 0 } tangkep (e) { e[\"pesan\"] }", "pembagian dengan nol"},
+		{"coba { 1 } tangkep (e) { 2 }", 1},
+		{"coba { lempar \"gagal\" } tangkep (e) { e[\"pesan\"] }", "gagal"},
+		{"coba { lempar 42 } tangkep (e) { e[\"nilai\"] }", 42},
+		{"coba { x } tangkep { 5 }", 5},
+		{"coba {\n  lempar \"x\"\n} tangkep (e) { e[\"baris\"] }", 2},
+		{"gawe hasil = 0; coba { hasil = 1; lempar 0; hasil = 2 } tangkep { hasil += 10 }; hasil", 11},
+		{"fungsi bagi(a, b) { lamun (b == 0) { lempar \"b nol\" }; a / b }; coba { bagi(1, 0) } tangkep (e) { e[\"pesan\"] }", "b nol"},
+		{"coba { coba { lempar \"dalam\" } tangkep (e) { lempar e } } tangkep (e) { e[\"pesan\"] }", "dalam"},
+		{"coba { [1][5] = 2 } tangkep (e) { e[\"pesan\"] }", "indeks di luar batas: 5"},
 	}
 
 	for _, tt := range tests {
 		evaluated := testEval(tt.input)
-
 		switch expected := tt.expected.(type) {
 		case int:
 			testIntegerObject(t, evaluated, int64(expected))
 		case string:
 			str, ok := evaluated.(*object.String)
 			if !ok {
-				t.Errorf("object is not String. got=%T (%+v)", evaluated, evaluated)
+				t.Errorf("object is not String for %q. got=%T (%+v)", tt.input, evaluated, evaluated)
 				continue
 			}
 			if str.Value != expected {
@@ -383,16 +881,742 @@ func TestBuiltinFunctions(t *testing.T) {
 	}
 }
 
-func testEval(input string) object.Object {
-	l := lexer.New(input)
-	p := parser.New(l)
-	program := p.ParseProgram()
-	env := object.NewEnvironment()
-
-	return Eval(program, env)
-}
-
-func testIntegerObject(t *testing.T, obj object.Object, expected int64) bool {
+func TestTryFinally(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int64
+	}{
+		{"gawe n = 0; coba { n = 1 } akhirne { n += 10 }; n", 11},
+		{"gawe n = 0; coba { lempar 1 } tangkep { n = 1 } akhirne { n += 10 }; n", 11},
+		{"gawe n = 0; fungsi f() { coba { tulakan 1 } akhirne { n = 5 } }; f() + n", 6},
+		{"fungsi f() { coba { tulakan 1 } akhirne { tulakan 2 } }; f()", 2},
+		{"gawe n = 0; ojok (gawe i = 0; i < 5; i++) { coba { lamun (i == 2) { mentelah } } akhirne { n++ } }; n", 3},
+		{"gawe n = 0; coba { coba { lempar 1 } akhirne { n = 7 } } tangkep { n += 1 }; n", 8},
+	}
+
+	for _, tt := range tests {
+		testIntegerObject(t, testEval(tt.input), tt.expected)
+	}
+}
+
+func TestUncaughtThrow(t *testing.T) {
+	evaluated := testEval("gawe a = 1\nlempar \"rusak\"\na = 2")
+	errObj, ok := evaluated.(*object.Error)
+	if !ok {
+		t.Fatalf("expected error object, got=%T (%+v)", evaluated, evaluated)
+	}
+
+	if errObj.Message != "rusak" {
+		t.Errorf("wrong error message. got=%q", errObj.Message)
+	}
+
+	if errObj.Line != 2 || errObj.Column != 1 {
+		t.Errorf("wrong error position. got=%d:%d", errObj.Line, errObj.Column)
+	}
+
+	evaluated = testEval("coba { lempar 1 } akhirne { 2 }")
+	if _, ok := evaluated.(*object.Error); !ok {
+		t.Errorf("error should pass through akhirne, got=%T (%+v)", evaluated, evaluated)
+	}
+}
+
+func TestImportModule(t *testing.T) {
+	dir := t.TempDir()
+	writeModule(t, dir, "lib/matematika.ssk", `
+tetep PI = 3
+fungsi kuadrat(x) { tulakan x * x }
+`)
+	writeModule(t, dir, "lib/luas.ssk", `
+impor "matematika.ssk"
+fungsi lingkaran(r) { tulakan matematika.PI * matematika.kuadrat(r) }
+`)
+
+	tests := []struct {
+		input    string
+		expected int64
+	}{
+		{`impor "lib/matematika.ssk"; matematika.kuadrat(4)`, 16},
+		{`impor "lib/matematika" sebagai m; m.PI`, 3},
+		{`impor "lib/matematika.ssk" sebagai m; m["kuadrat"](3)`, 9},
+		{`impor "lib/luas.ssk"; luas.lingkaran(2)`, 12},
+	}
+
+	for _, tt := range tests {
+		testIntegerObject(t, testEvalInDir(dir, tt.input), tt.expected)
+	}
+}
+
+func TestImportEvaluatesOnce(t *testing.T) {
+	dir := t.TempDir()
+	writeModule(t, dir, "satu.ssk", `gawe daftar = []`)
+	writeModule(t, dir, "dua.ssk", `impor "satu.ssk"; gawe sama = satu`)
+
+	input := `
+impor "satu.ssk" sebagai a
+impor "dua.ssk"
+a == dua.sama
+`
+	testBooleanObject(t, testEvalInDir(dir, input), true)
+}
+
+func TestImportErrors(t *testing.T) {
+	dir := t.TempDir()
+	writeModule(t, dir, "a.ssk", `impor "b.ssk"`)
+	writeModule(t, dir, "b.ssk", `impor "a.ssk"`)
+	writeModule(t, dir, "rusak.ssk", `gawe = `)
+	writeModule(t, dir, "konst.ssk", `gawe x = 1`)
+	writeModule(t, dir, "nama-aneh.ssk", `gawe x = 1`)
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`impor "a.ssk"`, "impor melingkar: a.ssk -> b.ssk -> a.ssk"},
+		{`impor "konst.ssk"; konst.y`, "modul 'konst' tidak punya anggota 'y'"},
+		{`impor "konst.ssk"; konst.x = 2`, "anggota modul 'x' tidak bisa diubah"},
+		{`impor "nama-aneh.ssk"`, "nama modul 'nama-aneh' tidak valid, gunakan 'sebagai' untuk memberi nama"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEvalInDir(dir, tt.input)
+		errObj, ok := evaluated.(*object.Error)
+		if !ok {
+			t.Errorf("expected error object for %q, got=%T (%+v)", tt.input, evaluated, evaluated)
+			continue
+		}
+		if errObj.Message != tt.expected {
+			t.Errorf("wrong error message. expected=%q, got=%q", tt.expected, errObj.Message)
+		}
+	}
+
+	for _, input := range []string{`impor "ndarak.ssk"`, `impor "rusak.ssk"`} {
+		if _, ok := testEvalInDir(dir, input).(*object.Error); !ok {
+			t.Errorf("expected error object for %q", input)
+		}
+	}
+}
+
+func TestDotAccessOnMaps(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int64
+	}{
+		{`gawe m = {"a": 1}; m.a`, 1},
+		{`gawe m = {"a": {"b": 2}}; m.a.b`, 2},
+		{`gawe m = {}; m.x = 5; m["x"]`, 5},
+		{`gawe m = {"n": 1}; m.n += 2; m.n`, 3},
+		{`coba { lempar "x" } tangkep (e) { e.baris }`, 1},
+	}
+
+	for _, tt := range tests {
+		testIntegerObject(t, testEval(tt.input), tt.expected)
+	}
+}
+
+func TestWhileLoop(t *testing.T) {
+	input := `
+gawe x = 0
+gawe sum = 0
+selame (x < 5) {
+    sum = sum + x
+    x = x + 1
+}
+sum
+`
+	evaluated := testEval(input)
+	testIntegerObject(t, evaluated, 10) // 0+1+2+3+4 = 10
+}
+
+func TestForLoop(t *testing.T) {
+	input := `
+gawe sum = 0
+ojok (gawe i = 1; i <= 5; i = i + 1) {
+    sum = sum + i
+}
+sum
+`
+	evaluated := testEval(input)
+	testIntegerObject(t, evaluated, 15) // 1+2+3+4+5 = 15
+}
+
+func TestForInLoop(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`gawe s = 0; ojok (x dalem [1, 2, 3]) { s += x }; s`, "6"},
+		{`gawe s = ""; ojok (i, x dalem ["a", "b"]) { s += "${i}${x} " }; s`, "0a 1b "},
+		// One variable gets the keys of a map, in insertion order
+		{`gawe s = ""; ojok (k dalem {"b": 1, "a": 2}) { s += k }; s`, "ba"},
+		{`gawe s = 0; ojok (k, v dalem {"b": 1, "a": 2}) { s += v }; s`, "3"},
+		{`gawe s = []; ojok (i, c dalem "héy") { s = sorong(s, [i, c]) }; s`, "[[0, h], [1, é], [2, y]]"},
+		{`gawe s = []; ojok (n dalem rentang(3)) { s = sorong(s, n) }; s`, "[0, 1, 2]"},
+		{`gawe s = []; ojok (n dalem rentang(2, 10, 3)) { s = sorong(s, n) }; s`, "[2, 5, 8]"},
+		{`gawe s = []; ojok (i, n dalem rentang(3, 0, -1)) { s = sorong(s, [i, n]) }; s`, "[[0, 3], [1, 2], [2, 1]]"},
+		{`gawe s = 0; ojok (n dalem rentang(5, 5)) { s += 1 }; s`, "0"},
+		{`gawe s = 0; ojok (n dalem rentang(9223372036854775800, 9223372036854775807, 5)) { s += 1 }; s`, "2"},
+		{`gawe s = 0
+ojok (n dalem rentang(10)) {
+  lamun (n % 2 == 0) { lanjutan }
+  lamun (n > 7) { mentelah }
+  s += n
+}
+s`, "16"},
+		// The loop's value is the value of the body the last time round
+		{`ojok (x dalem [1, 2, 3]) { x * 2 }`, "6"},
+		{`ojok (x dalem [1, 2, 3]) { lamun (x == 3) { lanjutan }; x }`, "2"},
+		{`ojok (x dalem [1, 2]) { mentelah }`, "ndarak"},
+		{`ojok (x dalem []) { x }`, "ndarak"},
+		// The variables belong to the loop
+		{`gawe x = "luar"; ojok (x dalem [1]) { }; x`, "luar"},
+		{`fungsi cari(xs, y) {
+  ojok (i, x dalem xs) { lamun (x == y) { tulakan i } }
+  -1
+}
+[cari([5, 6, 7], 7), cari([], 1)]`, "[2, -1]"},
+		// Changing the map while looping does not change the entries visited
+		{`gawe m = {"a": 1, "b": 2}; gawe s = ""; ojok (k dalem m) { hapus(m, "b"); m["c"] = 3; s += k }; [s, m]`, "[ab, {a: 1, c: 3}]"},
+		{`gawe s = 0; ojok (x dalem [[1, 2], [3]]) { ojok (y dalem x) { s += y } }; s`, "6"},
+		{`[jenis(rentang(1)), rentang(4)]`, "[rentang, rentang(0, 4, 1)]"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated.Type() == object.ERROR_OBJ || evaluated.Inspect() != tt.expected {
+			t.Errorf("%s: expected %s, got=%T (%+v)", tt.input, tt.expected, evaluated, evaluated)
+		}
+	}
+}
+
+func TestForInLoopErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`ojok (x dalem 5) { }`, "ojok ... dalem butuh daftar, peta, teks, atau rentang, dapat INTEGER"},
+		{`ojok (x dalem ndarak) { }`, "ojok ... dalem butuh daftar, peta, teks, atau rentang, dapat NULL"},
+		{`ojok (x dalem [1, 0]) { 1 / x }`, "pembagian dengan nol"},
+		{`rentang(1, 5, 0)`, "langkah rentang() tidak boleh nol"},
+		{`rentang("a")`, "argumen pertama rentang() harus angka, dapat STRING"},
+		{`rentang()`, "rentang() butuh 1 sampai 3 argumen, dapat 0"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		errObj, ok := evaluated.(*object.Error)
+		if !ok || errObj.Message != tt.expected {
+			t.Errorf("%s: expected error %q, got=%T (%+v)", tt.input, tt.expected, evaluated, evaluated)
+		}
+	}
+}
+
+func TestBuiltinFunctions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`belong("")`, 0},
+		{`belong("four")`, 4},
+		{`belong("hello world")`, 11},
+		{`belong([1, 2, 3])`, 3},
+		{`jenis(5)`, "angka"},
+		{`jenis("hello")`, "teks"},
+		{`jenis(kenak)`, "boolean"},
+		{`jenis(ndarak)`, "ndarak"},
+		{`jenis([1, 2])`, "daftar"},
+		{`jenis(1.5)`, "pecahan"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case string:
+			str, ok := evaluated.(*object.String)
+			if !ok {
+				t.Errorf("object is not String. got=%T (%+v)", evaluated, evaluated)
+				continue
+			}
+			if str.Value != expected {
+				t.Errorf("wrong string. expected=%q, got=%q", expected, str.Value)
+			}
+		}
+	}
+}
+
+func TestStringBuiltins(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`gabung(pisah("a,b,,c", ","), "|")`, "a|b||c"},
+		{`gabung(pisah("  satu dua\ttelu  "), "|")`, "satu|dua|telu"},
+		{`gabung(pisah("héé", ""), "-")`, "h-é-é"},
+		{`gabung([1, "a", kenak])`, "1akenak"},
+		{`gabung([], ", ")`, ""},
+		{`rapikan("  halo \n")`, "halo"},
+		{`rapikan("--halo--", "-")`, "halo"},
+		{`huruf_besar("sasak é")`, "SASAK É"},
+		{`huruf_kecil("LOMBOK")`, "lombok"},
+		{`berisi("Lombok", "mbo")`, true},
+		{`berisi("Lombok", "x") == salak`, true},
+		{`diawali("Lombok", "Lom")`, true},
+		{`diakhiri("Lombok", "Lom")`, false},
+		{`posisi("éa", "a")`, 1},
+		{`posisi("Lombok", "x")`, -1},
+		{`ganti("a-b-c", "-", "+")`, "a+b+c"},
+		{`ganti("a-b-c", "-", "+", 1)`, "a+b-c"},
+		{`iris("Lomboké", 1, 3)`, "om"},
+		{`iris("Lomboké", -2)`, "ké"},
+		{`iris("Lombok", 4, 100)`, "ok"},
+		{`iris("Lombok", 4, 2)`, ""},
+		{`ulang("ab", 3)`, "ababab"},
+		{`ulang("ab", 0)`, ""},
+		{`jumlah_huruf("Lomboké")`, 7},
+		{`belong("Lomboké")`, 8},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+
+		switch expected := tt.expected.(type) {
+		case int:
+			testIntegerObject(t, evaluated, int64(expected))
+		case bool:
+			testBooleanObject(t, evaluated, expected)
+		case string:
+			str, ok := evaluated.(*object.String)
+			if !ok || str.Value != expected {
+				t.Errorf("%s: expected %q, got=%T (%+v)", tt.input, expected, evaluated, evaluated)
+			}
+		}
+	}
+}
+
+func TestStringBuiltinErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`pisah()`, "pisah() butuh 1 sampai 2 argumen, dapat 0"},
+		{`pisah(1, ",")`, "argumen pertama pisah() harus teks, dapat INTEGER"},
+		{`gabung("abc")`, "argumen pertama gabung() harus daftar, dapat STRING"},
+		{`huruf_besar("a", "b")`, "huruf_besar() butuh 1 argumen, dapat 2"},
+		{`ganti("a", "b", "c", "d")`, "argumen keempat ganti() harus angka, dapat STRING"},
+		{`iris("abc", "1")`, "argumen kedua iris() harus angka, dapat STRING"},
+		{`ulang("a", -1)`, "argumen kedua ulang() tidak boleh negatif"},
+		{`ulang("ab", 1000000000000)`, "hasil ulang() terlalu panjang"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		errObj, ok := evaluated.(*object.Error)
+		if !ok {
+			t.Errorf("%s: expected an error, got=%T (%+v)", tt.input, evaluated, evaluated)
+			continue
+		}
+		if errObj.Message != tt.expected {
+			t.Errorf("%s: wrong message. expected=%q, got=%q", tt.input, tt.expected, errObj.Message)
+		}
+	}
+}
+
+func TestArrayBuiltins(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`petakan([1, 2, 3], fungsi(x) { x * 2 })`, "[2, 4, 6]"},
+		{`petakan(pisah("a b"), huruf_besar)`, "[A, B]"},
+		{`saring([1, 2, 3, 4], fungsi(x) { x % 2 == 0 })`, "[2, 4]"},
+		{`lipat([1, 2, 3], fungsi(a, b) { a + b })`, "6"},
+		{`lipat([], fungsi(a, b) { a + b }, 10)`, "10"},
+		{`lipat(["a", "b"], fungsi(a, b) { b + a }, "")`, "ba"},
+		{`temukan([1, 5, 7], fungsi(x) { x > 4 })`, "5"},
+		{`temukan([1], fungsi(x) { x > 4 })`, "ndarak"},
+		{`ada([1, 5], fungsi(x) { x > 4 })`, "kenak"},
+		{`ada([], fungsi(x) { kenak })`, "salak"},
+		{`semua([1, 5], fungsi(x) { x > 4 })`, "salak"},
+		{`semua([], fungsi(x) { salak })`, "kenak"},
+		{`urutkan([3, 1.5, 2])`, "[1.5, 2, 3]"},
+		{`urutkan(["pisang", "apel"])`, "[apel, pisang]"},
+		{`urutkan([1, 3, 2], fungsi(a, b) { b - a })`, "[3, 2, 1]"},
+		// Sorting is stable
+		{`gawe xs = [[2, "a"], [1, "b"], [2, "c"]]
+petakan(urutkan(xs, fungsi(a, b) { a[0] - b[0] }), fungsi(x) { x[1] })`, "[b, a, c]"},
+		{`gawe xs = [3, 1]; urutkan(xs); xs`, "[3, 1]"},
+		{`balik([1, 2, 3])`, "[3, 2, 1]"},
+		{`balik("abé")`, "éba"},
+		{`iris([1, 2, 3, 4], 1, -1)`, "[2, 3]"},
+		{`sambung([1], [], [2, 3])`, "[1, 2, 3]"},
+		{`sambung()`, "[]"},
+		// Callbacks are closures and can call back into builtins
+		{`gawe n = 10; petakan([1, 2], fungsi(x) { petakan([x], fungsi(y) { y + n }) })`, "[[11], [12]]"},
+		{`gawe total = 0; petakan([1, 2, 3], fungsi(x) { total += x }); total`, "6"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated.Type() == object.ERROR_OBJ || evaluated.Inspect() != tt.expected {
+			t.Errorf("%s: expected %s, got=%T (%+v)", tt.input, tt.expected, evaluated, evaluated)
+		}
+	}
+}
+
+func TestArrayBuiltinErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`petakan([1], 2)`, "argumen kedua petakan() harus fungsi, dapat INTEGER"},
+		{`saring("abc", jenis)`, "argumen pertama saring() harus daftar, dapat STRING"},
+		{`lipat([], fungsi(a, b) { a })`, "lipat() dari daftar kosong butuh nilai awal"},
+		{`urutkan([1, "a"])`, "urutkan() tidak bisa membandingkan STRING dengan INTEGER"},
+		{`urutkan([1, 2], fungsi(a, b) { kenak })`, "pembanding urutkan() harus mengembalikan angka, dapat BOOLEAN"},
+		{`sambung([1], 2)`, "argumen ke-2 sambung() harus daftar, dapat INTEGER"},
+		{`petakan([1, 0], fungsi(x) { 1 / x })`, "pembagian dengan nol"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		errObj, ok := evaluated.(*object.Error)
+		if !ok || errObj.Message != tt.expected {
+			t.Errorf("%s: expected error %q, got=%T (%+v)", tt.input, tt.expected, evaluated, evaluated)
+		}
+	}
+}
+
+func TestMapOrder(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`{"b": 1, "a": 2, 3: 3}`, "{b: 1, a: 2, 3: 3}"},
+		// A repeated key keeps its first position and its last value
+		{`{"a": 1, "b": 2, "a": 3}`, "{a: 3, b: 2}"},
+		{`gawe m = {"z": 1}; m["y"] = 2; m["z"] = 3; m`, "{z: 3, y: 2}"},
+		{`gawe m = {"a": 1, "b": 2}; hapus(m, "a"); m["a"] = 3; m`, "{b: 2, a: 3}"},
+		// Entries are evaluated in the order they are written
+		{`gawe urutan = ""; fungsi f(x) { urutan += "${x} "; x }
+{f("c"): f(1), f("a"): f(2)}
+urutan`, "c 1 a 2 "},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated.Type() == object.ERROR_OBJ || evaluated.Inspect() != tt.expected {
+			t.Errorf("%s: expected %s, got=%T (%+v)", tt.input, tt.expected, evaluated, evaluated)
+		}
+	}
+}
+
+func TestMapBuiltins(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`kunci({"b": 1, "a": 2})`, "[b, a]"},
+		{`nilai({"b": 1, "a": 2})`, "[1, 2]"},
+		{`pasangan({"b": 1, kenak: "x"})`, "[[b, 1], [kenak, x]]"},
+		{`kunci({})`, "[]"},
+		{`punya_kunci({"a": ndarak}, "a")`, "kenak"},
+		{`punya_kunci({"a": 1}, "b")`, "salak"},
+		{`gawe m = {"a": 1, "b": 2}; [hapus(m, "a"), hapus(m, "a"), m]`, "[kenak, salak, {b: 2}]"},
+		{`gawe m = {}; gawe i = 0; selame (i < 20) { m[i] = i; i++ }
+i = 0; selame (i < 15) { hapus(m, i); i++ }
+[kunci(m), m[17]]`, "[[15, 16, 17, 18, 19], 17]"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated.Type() == object.ERROR_OBJ || evaluated.Inspect() != tt.expected {
+			t.Errorf("%s: expected %s, got=%T (%+v)", tt.input, tt.expected, evaluated, evaluated)
+		}
+	}
+}
+
+func TestMapBuiltinErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`kunci([1])`, "argumen pertama kunci() harus peta, dapat ARRAY"},
+		{`nilai({}, 1)`, "nilai() butuh 1 argumen, dapat 2"},
+		{`punya_kunci({}, [[1], {}])`, "argumen kedua punya_kunci() tidak bisa digunakan sebagai kunci peta, dapat ARRAY"},
+		{`hapus("a", "a")`, "argumen pertama hapus() harus peta, dapat STRING"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		errObj, ok := evaluated.(*object.Error)
+		if !ok || errObj.Message != tt.expected {
+			t.Errorf("%s: expected error %q, got=%T (%+v)", tt.input, tt.expected, evaluated, evaluated)
+		}
+	}
+}
+
+func TestMapTupleKeys(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`gawe m = {[1, 2]: "a"}; m[[1, 2]]`, "a"},
+		{`gawe m = {}; m[[0, "x"]] = 1; m[[0, "x"]] += 1; m`, "{[0, x]: 2}"},
+		{`gawe m = {[1, [2, 3]]: 1}; [m[[1, [2, 3]]], m[[1, [2]]]]`, "[1, ndarak]"},
+		{`{[1]: "int", [1.0]: "float", ["1"]: "string"}`, "{[1]: float, [1]: string}"},
+		{`gawe m = {1: "a", 1180591620717411303424: "b"}; [m[1.0], m[-0.0 + 1], m[1180591620717411303424.0]]`, "[a, a, b]"},
+		// The key is copied, so changing the array does not change the map
+		{`gawe k = [1]; gawe m = {}; m[k] = "a"; k[0] = 2; [m[[1]], m[k]]`, "[a, ndarak]"},
+		// Keys handed out by the map are copies too
+		{`gawe m = {}; m[[1, 2]] = "a"; gawe k = kunci(m)[0]; k[0] = 9; [m[[1, 2]], m[[9, 2]]]`, "[a, ndarak]"},
+		{`gawe m = {[1, 2]: "a"}; gawe p = pasangan(m)[0]; p[0][0] = 9; m[[9, 2]] = "b"; m`, "{[1, 2]: a, [9, 2]: b}"},
+		{`gawe m = {[1, [2]]: "a"}; ojok (k dalem m) { k[1][0] = 9 }; [m[[1, [2]]], m]`, "[a, {[1, [2]]: a}]"},
+		{`gawe m = {[1, 2]: "a"}; ojok (k, v dalem m) { k[0] = 9 }; m[[1, 2]]`, "a"},
+		{`gawe m = {[1, 2]: kenak}; [punya_kunci(m, [1, 2]), hapus(m, [1, 2]), m]`, "[kenak, kenak, {}]"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated.Type() == object.ERROR_OBJ || evaluated.Inspect() != tt.expected {
+			t.Errorf("%s: expected %s, got=%T (%+v)", tt.input, tt.expected, evaluated, evaluated)
+		}
+	}
+}
+
+func TestMathBuiltins(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`mutlak(-5)`, "5"},
+		{`mutlak(-2.5)`, "2.5"},
+		{`mutlak(-9223372036854775807 - 1)`, "9223372036854775808"},
+		{`terkecil(3, 1.5, 2)`, "1.5"},
+		{`terbesar(3, 1.5, 2)`, "3"},
+		{`terbesar([4, 99999999999999999999, 7])`, "99999999999999999999"},
+		{`terkecil([5])`, "5"},
+		// Integer powers are exact, other powers are floats
+		{`pangkat(2, 10)`, "1024"},
+		{`pangkat(2, 100)`, "1267650600228229401496703205376"},
+		{`pangkat(-1, 99999999999)`, "-1"},
+		{`pangkat(2, 1000000) > pangkat(2, 999999)`, "kenak"},
+		{`pangkat(2, -1)`, "0.5"},
+		{`pangkat(4, 0.5)`, "2.0"},
+		{`akar(16)`, "4.0"},
+		{`bulat_bawah(2.7)`, "2"},
+		{`bulat_bawah(-2.5)`, "-3"},
+		{`bulat_atas(2.1)`, "3"},
+		{`bulatkan(2.5)`, "3"},
+		{`bulatkan(-2.5)`, "-3"},
+		{`bulatkan(7)`, "7"},
+		{`bulat_bawah(1e20)`, "100000000000000000000"},
+		{`sin(0)`, "0.0"},
+		{`cos(0)`, "1.0"},
+		{`atan2(1, 1) * 4 == PI`, "kenak"},
+		{`log(E)`, "1.0"},
+		{`log10(1000)`, "3.0"},
+		{`log2(8)`, "3.0"},
+		{`exp(0)`, "1.0"},
+		{`fpb(12, 18)`, "6"},
+		{`fpb(-12, 18)`, "6"},
+		{`fpb(0, 0)`, "0"},
+		{`kpk(4, 6)`, "12"},
+		{`kpk(-4, 6)`, "12"},
+		{`kpk(0, 5)`, "0"},
+		{`kpk(99999999999999999999, 2)`, "199999999999999999998"},
+		{`batasi(5, 0, 10)`, "5"},
+		{`batasi(-1, 0, 10)`, "0"},
+		{`batasi(11, 0, 10.5)`, "10.5"},
+		{`PI`, "3.141592653589793"},
+		{`gawe PI = 3; PI`, "3"},
+		{`gawe r = acak_pecahan(); r >= 0 ance r < 1`, "kenak"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated.Type() == object.ERROR_OBJ || evaluated.Inspect() != tt.expected {
+			t.Errorf("%s: expected %s, got=%T (%+v)", tt.input, tt.expected, evaluated, evaluated)
+		}
+	}
+}
+
+func TestMathBuiltinErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`mutlak("a")`, "argumen pertama mutlak() harus angka, dapat STRING"},
+		{`akar()`, "akar() butuh 1 argumen, dapat 0"},
+		{`pangkat(2, "a")`, "argumen kedua pangkat() harus angka, dapat STRING"},
+		{`pangkat(10, 9999999999999)`, "hasil pangkat() terlalu besar: lebih dari 2097152 bit"},
+		// The limit holds without a memory limit too
+		{`pangkat(3, 1100000)`, "hasil pangkat() terlalu besar: lebih dari 2097152 bit"},
+		{`terkecil()`, "terkecil() butuh paling sedikit 1 argumen"},
+		{`terbesar([])`, "terbesar() tidak bisa dipakai pada daftar kosong"},
+		{`terbesar(1, "a")`, "terbesar() butuh angka, dapat STRING"},
+		{`bulatkan(1.0 / 0)`, "bulatkan() tidak bisa membulatkan +Inf"},
+		{`fpb(1.5, 2)`, "argumen pertama fpb() harus bilangan bulat, dapat FLOAT"},
+		{`batasi(1, 10, 0)`, "batas bawah batasi() lebih besar dari batas atas: 10 > 0"},
+		{`acak_pecahan(1)`, "acak_pecahan() butuh 0 argumen, dapat 1"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		errObj, ok := evaluated.(*object.Error)
+		if !ok || errObj.Message != tt.expected {
+			t.Errorf("%s: expected error %q, got=%T (%+v)", tt.input, tt.expected, evaluated, evaluated)
+		}
+	}
+}
+
+func TestRandomBuiltins(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		// After benih() the numbers are the same every run
+		{`benih(7); gawe a = [acak(1000), acak_pecahan()]; benih(7); a == [acak(1000), acak_pecahan()]`, "kenak"},
+		{`benih(1); gawe xs = [1, 2, 3, 4, 5]; gawe k = kocok(xs); [xs, urutkan(k)]`, "[[1, 2, 3, 4, 5], [1, 2, 3, 4, 5]]"},
+		{`kocok([])`, "[]"},
+		{`pilih_acak(["a"])`, "a"},
+		{`gawe ok = kenak; ojok (i dalem rentang(100)) { gawe x = pilih_acak([1, 2, 3]); ok = ok ance x >= 1 ance x <= 3 }; ok`, "kenak"},
+		{`acak_antara(5, 5)`, "5"},
+		{`gawe n = acak_antara(-3, 3); n >= -3 ance n <= 3`, "kenak"},
+		{`gawe n = acak_antara(0, 99999999999999999999); n >= 0 ance n <= 99999999999999999999`, "kenak"},
+		{`benih(3)`, "ndarak"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated.Type() == object.ERROR_OBJ || evaluated.Inspect() != tt.expected {
+			t.Errorf("%s: expected %s, got=%T (%+v)", tt.input, tt.expected, evaluated, evaluated)
+		}
+	}
+}
+
+func TestRandomBuiltinErrors(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`benih("a")`, "argumen pertama benih() harus angka, dapat STRING"},
+		{`benih()`, "benih() butuh 1 argumen, dapat 0"},
+		{`acak_antara(3, 1)`, "batas bawah acak_antara() lebih besar dari batas atas: 3 > 1"},
+		{`acak_antara(1, 2.5)`, "argumen kedua acak_antara() harus bilangan bulat, dapat FLOAT"},
+		{`pilih_acak([])`, "pilih_acak() tidak bisa dipakai pada daftar kosong"},
+		{`pilih_acak("abc")`, "argumen pertama pilih_acak() harus daftar, dapat STRING"},
+		{`kocok({})`, "argumen pertama kocok() harus daftar, dapat MAP"},
+	}
+
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		errObj, ok := evaluated.(*object.Error)
+		if !ok || errObj.Message != tt.expected {
+			t.Errorf("%s: expected error %q, got=%T (%+v)", tt.input, tt.expected, evaluated, evaluated)
+		}
+	}
+}
+
+func TestCallbackErrors(t *testing.T) {
+	input := `fungsi cek(x) {
+  lamun (x > 1) { lempar "terlalu besar" }
+  x
+}
+fungsi proses(xs) {
+  petakan(xs, cek)
+}
+proses([1, 2])`
+
+	evaluated := testEvalFile("main.ssk", input)
+	errObj, ok := evaluated.(*object.Error)
+	if !ok {
+		t.Fatalf("expected error object, got=%T (%+v)", evaluated, evaluated)
+	}
+	// The callback is called from where the builtin is called
+	expected := []object.StackFrame{
+		{Function: "cek", File: "main.ssk", Line: 6, Column: 10},
+		{Function: "proses", File: "main.ssk", Line: 8, Column: 7},
+	}
+	if !reflect.DeepEqual(errObj.Stack, expected) {
+		t.Errorf("wrong stack. expected=%+v, got=%+v", expected, errObj.Stack)
+	}
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`coba { petakan([1], fungsi(x) { lempar "x" }) } tangkep (e) { e.pesan }`, "x"},
+		{`petakan([1, 2], fungsi(x) { coba { lempar x } tangkep (e) { e.nilai * 10 } })`, "[10, 20]"},
+		{`gawe hasil = ""; coba { petakan([1], fungsi(x) { [1][5] = x }) } tangkep { hasil = "tertangkap" }; hasil`, "tertangkap"},
+	}
+	for _, tt := range tests {
+		evaluated := testEval(tt.input)
+		if evaluated.Type() == object.ERROR_OBJ || evaluated.Inspect() != tt.expected {
+			t.Errorf("%s: expected %s, got=%T (%+v)", tt.input, tt.expected, evaluated, evaluated)
+		}
+	}
+}
+
+func testEval(input string) object.Object {
+	return testEvalFile("", input)
+}
+
+// testEvalInDir evaluates input as if it were a file in dir, so that
+// imports are resolved relative to dir
+func testEvalInDir(dir, input string) object.Object {
+	return testEvalFile(filepath.Join(dir, "main.ssk"), input)
+}
+
+// testEvalFile runs input with both the evaluator and the bytecode VM and
+// returns the evaluator's result. When the two engines disagree it returns
+// an error describing the difference instead, which fails the test.
+func testEvalFile(file, input string) object.Object {
+	l := lexer.New(input)
+	p := parser.New(l)
+	program := p.ParseProgram()
+
+	env := object.NewEnvironment()
+	env.SetFile(file)
+	evaluated := evaluator.Eval(program, env)
+
+	c := compiler.New()
+	if err := c.Compile(program); err != nil {
+		return &object.Error{Message: fmt.Sprintf("compiler error: %s", err)}
+	}
+	machine := vm.New(c.Bytecode())
+	machine.SetFile(file)
+	result := machine.Run()
+
+	if describe(evaluated) != describe(result) {
+		return &object.Error{Message: fmt.Sprintf("evaluator dan vm berbeda: %s != %s", describe(evaluated), describe(result))}
+	}
+	return evaluated
+}
+
+func describe(obj object.Object) string {
+	if obj == nil {
+		obj = evaluator.NULL
+	}
+	if errObj, ok := obj.(*object.Error); ok && len(errObj.Stack) > 0 {
+		return fmt.Sprintf("%s(%s %s %+v)", obj.Type(), obj.Inspect(), errObj.File, errObj.Stack)
+	}
+	return fmt.Sprintf("%s(%s)", obj.Type(), obj.Inspect())
+}
+
+func writeModule(t *testing.T, dir, name, content string) {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func testIntegerObject(t *testing.T, obj object.Object, expected int64) bool {
 	result, ok := obj.(*object.Integer)
 	if !ok {
 		t.Errorf("object is not Integer. got=%T (%+v)", obj, obj)
@@ -405,6 +1629,19 @@ func testIntegerObject(t *testing.T, obj object.Object, expected int64) bool {
 	return true
 }
 
+func testFloatObject(t *testing.T, obj object.Object, expected float64) bool {
+	result, ok := obj.(*object.Float)
+	if !ok {
+		t.Errorf("object is not Float. got=%T (%+v)", obj, obj)
+		return false
+	}
+	if result.Value != expected {
+		t.Errorf("object has wrong value. got=%g, want=%g", result.Value, expected)
+		return false
+	}
+	return true
+}
+
 func testBooleanObject(t *testing.T, obj object.Object, expected bool) bool {
 	result, ok := obj.(*object.Boolean)
 	if !ok {
@@ -419,7 +1656,7 @@ func testBooleanObject(t *testing.T, obj object.Object, expected bool) bool {
 }
 
 func testNullObject(t *testing.T, obj object.Object) bool {
-	if obj != NULL {
+	if obj != evaluator.NULL {
 		t.Errorf("object is not NULL. got=%T (%+v)", obj, obj)
 		return false
 	}
@@ -0,0 +1,106 @@
+package evaluator
+
+import (
+	"github.com/arjunaayasa/sasaklang/pkg/sasaklang/ast"
+	"github.com/arjunaayasa/sasaklang/pkg/sasaklang/object"
+)
+
+// The functions in this file expose the evaluator's value semantics so that
+// the bytecode VM (package vm) behaves exactly like the tree-walking
+// interpreter. They take already evaluated values and never walk the AST.
+
+// Infix applies a binary operator such as "+", "<" or "==" to two values
+func Infix(rt *object.Runtime, operator string, left, right object.Object) object.Object {
+	return evalInfixExpression(rt, operator, left, right)
+}
+
+// Prefix applies a unary operator ("-", "!" or "ndek") to a value
+func Prefix(operator string, right object.Object) object.Object {
+	return evalPrefixExpression(operator, right)
+}
+
+// PostfixUpdate returns the value an operand of ++ or -- is updated to
+func PostfixUpdate(rt *object.Runtime, operator string, current object.Object) object.Object {
+	return evalPostfixUpdate(rt, operator, current)
+}
+
+// JoinTemplate builds the string of an interpolated string literal from the
+// values of its parts
+func JoinTemplate(rt *object.Runtime, parts []object.Object) object.Object {
+	return joinTemplate(rt, parts)
+}
+
+// NewIterator returns the iterator an ojok ... dalem loop goes through obj
+// with, or an error if obj cannot be looped over
+func NewIterator(rt *object.Runtime, obj object.Object) (*object.Iterator, *object.Error) {
+	return newIterator(rt, obj)
+}
+
+// LoopValues returns the values of the n variables of an ojok ... dalem
+// loop for one element
+func LoopValues(it *object.Iterator, n int, key, value object.Object) []object.Object {
+	return loopValues(it, n, key, value)
+}
+
+// Index reads left[index]
+func Index(left, index object.Object) object.Object {
+	return evalIndexExpression(left, index)
+}
+
+// IndexForUpdate reads the element a compound assignment is about to
+// overwrite; an array index out of range is an error
+func IndexForUpdate(container, index object.Object) object.Object {
+	return evalIndexForUpdate(container, index)
+}
+
+// SetIndex stores container[index] = val and returns val
+func SetIndex(rt *object.Runtime, container, index, val object.Object) object.Object {
+	return evalSetIndex(rt, container, index, val)
+}
+
+// IsTruthy reports whether a value counts as true in a condition
+func IsTruthy(obj object.Object) bool {
+	return isTruthy(obj)
+}
+
+// Throw builds the error raised by lempar at the given position
+func Throw(val object.Object, line, column int) *object.Error {
+	return throwValue(val, line, column)
+}
+
+// ErrorToMap converts a caught error into the map bound by tangkep
+func ErrorToMap(err *object.Error) *object.Map {
+	return errorToMap(err)
+}
+
+// ModuleBindingName returns the name an impor statement binds its module to
+func ModuleBindingName(node *ast.ImportStatement) (string, *object.Error) {
+	return moduleBindingName(node)
+}
+
+// ResolveModulePath turns an impor path into an absolute file path, relative
+// to the importing file when that is known
+func ResolveModulePath(path, importer string) (string, *object.Error) {
+	return resolveModulePath(path, importer)
+}
+
+// CheckImportAllowed reports an error if the host has disabled imports
+func CheckImportAllowed(rt *object.Runtime, path string) *object.Error {
+	return checkImportAllowed(rt, path)
+}
+
+// CheckImportCycle reports an error if absPath is still being loaded
+func CheckImportCycle(cache *object.ModuleCache, absPath string) *object.Error {
+	return checkImportCycle(cache, absPath)
+}
+
+// ParseModule reads and parses the module file at absPath; path is the
+// original impor path used in error messages
+func ParseModule(path, absPath string) (*ast.Program, *object.Error) {
+	return parseModule(path, absPath)
+}
+
+// NewError creates a runtime error in the evaluator's format
+func NewError(format string, a ...interface{}) *object.Error {
+	return newError(format, a...)
+}
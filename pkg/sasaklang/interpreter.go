@@ -0,0 +1,271 @@
+// Package sasaklang embeds the SasakLang interpreter in Go programs.
+//
+//	interp := sasaklang.New()
+//	interp.Stdout = os.Stdout
+//	interp.Register("salam", func(rt *object.Runtime, args ...object.Object) object.Object {
+//		return &object.String{Value: "halo dari Go"}
+//	})
+//	result, err := interp.Run(ctx, `cetak(salam())`)
+package sasaklang
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/arjunaayasa/sasaklang/pkg/sasaklang/compiler"
+	sserrors "github.com/arjunaayasa/sasaklang/pkg/sasaklang/errors"
+	"github.com/arjunaayasa/sasaklang/pkg/sasaklang/evaluator"
+	"github.com/arjunaayasa/sasaklang/pkg/sasaklang/lexer"
+	"github.com/arjunaayasa/sasaklang/pkg/sasaklang/object"
+	"github.com/arjunaayasa/sasaklang/pkg/sasaklang/parser"
+	"github.com/arjunaayasa/sasaklang/pkg/sasaklang/vm"
+)
+
+// Interpreter runs SasakLang programs on behalf of a host program. The
+// program only sees the streams and globals the host gives it.
+//
+// Several goroutines may call Run at once. The fields, Register, Set and
+// SetSeed must not be used while runs are going on.
+type Interpreter struct {
+	// Streams the builtins read from and write to. A nil Stdin reads as
+	// empty, and output to a nil Stdout is discarded.
+	Stdin  io.Reader
+	Stdout io.Writer
+
+	// Stderr receives a report of each failed run, as the command line
+	// prints it: the error with an excerpt of the source and the call
+	// stack. Nothing is written when it is nil.
+	Stderr io.Writer
+
+	// File is the path reported in errors; imports are resolved relative
+	// to it
+	File string
+
+	// AllowImports lets programs use impor to read .ssk files. It is off by
+	// default, so programs cannot read the file system.
+	AllowImports bool
+
+	// UseVM runs programs on the bytecode VM instead of the tree-walking
+	// evaluator
+	UseVM bool
+
+	// MaxSteps limits the number of loop iterations and function calls of
+	// a run; zero means no limit. MaxDepth limits how deep calls nest;
+	// zero means object.DefaultMaxDepth.
+	MaxSteps int64
+	MaxDepth int
+
+	// MaxMemory limits the bytes of strings, arrays, maps and big integers
+	// a run creates; zero means no limit
+	MaxMemory int64
+
+	// seed starts the random numbers of every run, if set
+	seed *int64
+
+	globals []global
+}
+
+type global struct {
+	name  string
+	value object.Object
+}
+
+// New creates an interpreter with no streams and no host globals
+func New() *Interpreter {
+	return &Interpreter{}
+}
+
+// Register makes fn callable from programs as the global function name
+func (i *Interpreter) Register(name string, fn object.BuiltinFunction) {
+	i.setGlobal(name, &object.Builtin{Fn: fn})
+}
+
+// Set makes value available to programs as the global variable name. The
+// value is converted with ToObject. Each run gets its own copy of the
+// arrays and maps in it, so changes a run makes are not seen by later runs.
+func (i *Interpreter) Set(name string, value interface{}) error {
+	obj, err := ToObject(value)
+	if err != nil {
+		return fmt.Errorf("variabel '%s': %w", name, err)
+	}
+	i.setGlobal(name, obj)
+	return nil
+}
+
+// SetSeed makes the random numbers of acak() and the other random builtins
+// start from seed in every run, so that runs can be reproduced. Each run
+// has its own numbers, seeded from the clock when no seed is set, and
+// benih() in one run does not change those of another.
+func (i *Interpreter) SetSeed(seed int64) {
+	i.seed = &seed
+}
+
+func (i *Interpreter) setGlobal(name string, value object.Object) {
+	for j := range i.globals {
+		if i.globals[j].name == name {
+			i.globals[j].value = value
+			return
+		}
+	}
+	i.globals = append(i.globals, global{name, value})
+}
+
+// ParseError is returned by Run when the source has syntax errors
+type ParseError struct {
+	Errors []*sserrors.ParseError
+}
+
+func (e *ParseError) Error() string {
+	msgs := make([]string, len(e.Errors))
+	for i, err := range e.Errors {
+		msgs[i] = err.Error()
+	}
+	return "ada error saat parsing: " + strings.Join(msgs, "; ")
+}
+
+// Errors a RuntimeError wraps when the run reached one of its limits. A
+// run stopped by its context wraps the context's error instead.
+var (
+	ErrStepLimit   = errors.New("batas langkah terlampaui")
+	ErrDepthLimit  = errors.New("batas kedalaman panggilan terlampaui")
+	ErrMemoryLimit = errors.New("batas memori terlampaui")
+)
+
+// RuntimeError is returned by Run when the program fails, including by an
+// uncaught lempar. Err holds the position, call stack and thrown value.
+type RuntimeError struct {
+	Err *object.Error
+
+	cause error
+}
+
+func (e *RuntimeError) Error() string {
+	return e.Err.Inspect()
+}
+
+// Unwrap returns ErrStepLimit, ErrDepthLimit, ErrMemoryLimit or the
+// context's error when the run was stopped by a limit
+func (e *RuntimeError) Unwrap() error {
+	return e.cause
+}
+
+// Run runs source as a program and returns the value of its last statement.
+// Each run starts from a fresh global scope holding only the builtins and
+// the host's globals. A host function that panics fails the run with a
+// RuntimeError. The errors Run returns are also reported to Stderr.
+func (i *Interpreter) Run(ctx context.Context, source string) (object.Object, error) {
+	result, err := i.run(ctx, source)
+	if err != nil && i.Stderr != nil {
+		i.report(err, source)
+	}
+	return result, err
+}
+
+func (i *Interpreter) run(ctx context.Context, source string) (result object.Object, err error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			result, err = nil, &RuntimeError{Err: &object.Error{Message: fmt.Sprintf("kesalahan internal: %v", r)}}
+		}
+	}()
+
+	p := parser.New(lexer.New(source))
+	program := p.ParseProgram()
+	if len(p.ParseErrors()) != 0 {
+		return nil, &ParseError{Errors: p.ParseErrors()}
+	}
+
+	rt := i.runtime(ctx)
+	copies := make(map[object.Object]object.Object)
+	if i.UseVM {
+		comp := compiler.New()
+		if err := comp.Compile(program); err != nil {
+			return nil, fmt.Errorf("gagal mengompilasi: %w", err)
+		}
+		machine := vm.NewWithRuntime(comp.Bytecode(), rt)
+		machine.SetFile(i.File)
+		for _, g := range i.globals {
+			machine.SetGlobal(g.name, copyValue(g.value, copies))
+		}
+		result = machine.Run()
+	} else {
+		env := object.NewEnvironmentWithRuntime(rt)
+		env.SetFile(i.File)
+		for _, g := range i.globals {
+			env.Set(g.name, copyValue(g.value, copies))
+		}
+		result = evaluator.Eval(program, env)
+	}
+
+	if errObj, ok := result.(*object.Error); ok {
+		err := &RuntimeError{Err: errObj}
+		switch errObj.Kind {
+		case object.STEP_LIMIT_ERR:
+			err.cause = ErrStepLimit
+		case object.DEPTH_LIMIT_ERR:
+			err.cause = ErrDepthLimit
+		case object.MEMORY_LIMIT_ERR:
+			err.cause = ErrMemoryLimit
+		case object.CANCELED_ERR:
+			err.cause = ctx.Err()
+		}
+		return nil, err
+	}
+	if result == nil {
+		result = evaluator.NULL
+	}
+	return result, nil
+}
+
+// report writes err to Stderr
+func (i *Interpreter) report(err error, source string) {
+	switch err := err.(type) {
+	case *ParseError:
+		fmt.Fprintln(i.Stderr, "Ada error saat parsing:")
+		for _, parseErr := range err.Errors {
+			fmt.Fprintf(i.Stderr, "  %s\n", parseErr)
+		}
+	case *RuntimeError:
+		fmt.Fprintln(i.Stderr, sserrors.FormatRuntimeError(err.Err, i.errorSource(err.Err, source)))
+	default:
+		fmt.Fprintf(i.Stderr, "Error: %s\n", err)
+	}
+}
+
+// errorSource returns the text of the file a runtime error occurred in,
+// which may be a module imported by the program
+func (i *Interpreter) errorSource(err *object.Error, source string) string {
+	if err.File == "" || err.File == i.File {
+		return source
+	}
+	content, readErr := os.ReadFile(err.File)
+	if readErr != nil {
+		return ""
+	}
+	return string(content)
+}
+
+// runtime creates the runtime of one run
+func (i *Interpreter) runtime(ctx context.Context) *object.Runtime {
+	rt := object.NewRuntime()
+	rt.Context = ctx
+	rt.Stdin, rt.Stdout = i.Stdin, i.Stdout
+	if rt.Stdin == nil {
+		rt.Stdin = strings.NewReader("")
+	}
+	if rt.Stdout == nil {
+		rt.Stdout = io.Discard
+	}
+	rt.DisableImports = !i.AllowImports
+	rt.MaxSteps, rt.MaxDepth, rt.MaxMemory = i.MaxSteps, i.MaxDepth, i.MaxMemory
+	if i.seed != nil {
+		rt.SetSeed(*i.seed)
+	}
+	return rt
+}
@@ -0,0 +1,625 @@
+package sasaklang_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"math"
+	"math/big"
+	"os"
+	"path/filepath"
+	"reflect"
+	goruntime "runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/arjunaayasa/sasaklang/pkg/sasaklang"
+	"github.com/arjunaayasa/sasaklang/pkg/sasaklang/object"
+)
+
+// engines runs a test against both the evaluator and the VM
+func engines(t *testing.T, test func(t *testing.T, newInterp func() *sasaklang.Interpreter)) {
+	for _, useVM := range []bool{false, true} {
+		name := "evaluator"
+		if useVM {
+			name = "vm"
+		}
+		t.Run(name, func(t *testing.T) {
+			test(t, func() *sasaklang.Interpreter {
+				interp := sasaklang.New()
+				interp.UseVM = useVM
+				return interp
+			})
+		})
+	}
+}
+
+func TestInterpreterIO(t *testing.T) {
+	engines(t, func(t *testing.T, newInterp func() *sasaklang.Interpreter) {
+		var out bytes.Buffer
+		interp := newInterp()
+		interp.Stdin = strings.NewReader("Lombok\n")
+		interp.Stdout = &out
+
+		result, err := interp.Run(context.Background(), `gawe nama = isik("Nama: ")
+cetak("Halo", nama)
+1 + 2`)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if out.String() != "Nama: Halo Lombok\n" {
+			t.Errorf("wrong output: %q", out.String())
+		}
+		if got := sasaklang.ToGo(result); got != int64(3) {
+			t.Errorf("wrong result: %#v", got)
+		}
+	})
+}
+
+func TestInterpreterStderr(t *testing.T) {
+	engines(t, func(t *testing.T, newInterp func() *sasaklang.Interpreter) {
+		tests := []struct {
+			input    string
+			expected string
+		}{
+			{"gawe x = 1\nx / 0", "Error [baris 2, kolom 3]: pembagian dengan nol\n   2 | x / 0\n     |   ^\n"},
+			{"gawe = 1", "Ada error saat parsing:\n  Parse error"},
+		}
+
+		for _, tt := range tests {
+			var stdout, stderr bytes.Buffer
+			interp := newInterp()
+			interp.Stdout, interp.Stderr = &stdout, &stderr
+			if _, err := interp.Run(context.Background(), tt.input); err == nil {
+				t.Errorf("%q: expected an error", tt.input)
+			}
+			if !strings.HasPrefix(stderr.String(), tt.expected) {
+				t.Errorf("%q: wrong report. expected prefix %q, got=%q", tt.input, tt.expected, stderr.String())
+			}
+			if stdout.Len() != 0 {
+				t.Errorf("%q: the report went to Stdout: %q", tt.input, stdout.String())
+			}
+		}
+
+		// Successful runs write nothing
+		var stderr bytes.Buffer
+		interp := newInterp()
+		interp.Stderr = &stderr
+		if _, err := interp.Run(context.Background(), "1 + 1"); err != nil || stderr.Len() != 0 {
+			t.Errorf("unexpected error %v or report %q", err, stderr.String())
+		}
+	})
+}
+
+func TestInterpreterGlobals(t *testing.T) {
+	engines(t, func(t *testing.T, newInterp func() *sasaklang.Interpreter) {
+		interp := newInterp()
+		interp.Register("gandakan", func(rt *object.Runtime, args ...object.Object) object.Object {
+			n := args[0].(*object.Integer).Value
+			return &object.Integer{Value: n * 2}
+		})
+		if err := interp.Set("data", map[string]interface{}{"angka": []int{1, 2, 3}, "nama": "sasak"}); err != nil {
+			t.Fatal(err)
+		}
+		if err := interp.Set("aktif", true); err != nil {
+			t.Fatal(err)
+		}
+
+		result, err := interp.Run(context.Background(), `fungsi jumlah(arr) {
+  gawe total = 0
+  ojok (gawe i = 0; i < belong(arr); i++) { total += arr[i] }
+  tulakan total
+}
+[gandakan(jumlah(data["angka"])), data["nama"], aktif]`)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		expected := []interface{}{int64(12), "sasak", true}
+		if got := sasaklang.ToGo(result); !reflect.DeepEqual(got, expected) {
+			t.Errorf("wrong result. expected=%#v, got=%#v", expected, got)
+		}
+	})
+}
+
+func TestInterpreterSelfContainingValues(t *testing.T) {
+	engines(t, func(t *testing.T, newInterp func() *sasaklang.Interpreter) {
+		var out bytes.Buffer
+		interp := newInterp()
+		interp.Stdout = &out
+
+		result, err := interp.Run(context.Background(), `gawe a = [1]
+a[0] = a
+gawe m = {}
+m["diri"] = m
+m["daftar"] = a
+cetak(a)
+cetak(m)
+cetak("${a}", gabung([a]))
+m`)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		expected := "[[...]]\n{diri: {...}, daftar: [[...]]}\n[[...]] [[...]]\n"
+		if out.String() != expected {
+			t.Errorf("wrong output. expected=%q, got=%q", expected, out.String())
+		}
+
+		// The Go value holds itself the same way
+		m := sasaklang.ToGo(result).(map[string]interface{})
+		if diri := m["diri"].(map[string]interface{}); len(diri) != 2 {
+			t.Errorf("wrong inner map: %d entries", len(diri))
+		}
+	})
+}
+
+func TestInterpreterGlobalsAreCopied(t *testing.T) {
+	engines(t, func(t *testing.T, newInterp func() *sasaklang.Interpreter) {
+		interp := newInterp()
+		if err := interp.Set("data", map[string]interface{}{"angka": []int{1, 2, 3}}); err != nil {
+			t.Fatal(err)
+		}
+		angka := &object.Array{Elements: []object.Object{&object.Integer{Value: 1}}}
+		if err := interp.Set("bersama", []interface{}{angka, angka}); err != nil {
+			t.Fatal(err)
+		}
+
+		// Changes made by one run are not seen by the next one
+		for run := 0; run < 2; run++ {
+			result, err := interp.Run(context.Background(), `gawe awal = [data["angka"][0], bersama[1][0]]
+data["angka"][0] = 100
+data["baru"] = kenak
+bersama[0][0] = 5
+[awal, bersama[1][0], belong(kunci(data))]`)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			// The copy keeps the two elements of bersama the same array
+			if got := result.Inspect(); got != "[[1, 1], 5, 2]" {
+				t.Errorf("run %d: expected [[1, 1], 5, 2], got=%s", run, got)
+			}
+		}
+		if angka.Elements[0].Inspect() != "1" {
+			t.Errorf("the host's array was changed: %s", angka.Inspect())
+		}
+	})
+}
+
+func TestInterpreterConcurrentRuns(t *testing.T) {
+	engines(t, func(t *testing.T, newInterp func() *sasaklang.Interpreter) {
+		interp := newInterp()
+		if err := interp.Set("data", []int{1, 2, 3}); err != nil {
+			t.Fatal(err)
+		}
+
+		errs := make(chan error, 8)
+		for g := 0; g < cap(errs); g++ {
+			go func() {
+				_, err := interp.Run(context.Background(), `benih(7)
+ojok (gawe i = 0; i < 100; i++) { data[0] = acak(10) + acak_antara(1, 5) }
+kocok(data)`)
+				errs <- err
+			}()
+		}
+		for g := 0; g < cap(errs); g++ {
+			if err := <-errs; err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		}
+	})
+}
+
+func TestInterpreterHostPanic(t *testing.T) {
+	engines(t, func(t *testing.T, newInterp func() *sasaklang.Interpreter) {
+		interp := newInterp()
+		interp.Register("meledak", func(rt *object.Runtime, args ...object.Object) object.Object {
+			panic("rusak")
+		})
+
+		for _, source := range []string{`meledak()`, `petakan([1], fungsi(x) { meledak() })`} {
+			_, err := interp.Run(context.Background(), source)
+			var runtimeErr *sasaklang.RuntimeError
+			if !errors.As(err, &runtimeErr) {
+				t.Errorf("%s: expected a RuntimeError, got=%T (%v)", source, err, err)
+				continue
+			}
+			if runtimeErr.Err.Message != "kesalahan internal: rusak" {
+				t.Errorf("%s: wrong message: %q", source, runtimeErr.Err.Message)
+			}
+		}
+	})
+}
+
+func TestInterpreterSeed(t *testing.T) {
+	engines(t, func(t *testing.T, newInterp func() *sasaklang.Interpreter) {
+		run := func(interp *sasaklang.Interpreter) string {
+			result, err := interp.Run(context.Background(), `[acak(1000000), acak_antara(1, 1000000), acak_pecahan(), pilih_acak([1, 2, 3]), kocok([1, 2, 3, 4, 5])]`)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			return result.Inspect()
+		}
+
+		a, b := newInterp(), newInterp()
+		a.SetSeed(42)
+		b.SetSeed(42)
+		first := run(a)
+		if second := run(b); first != second {
+			t.Errorf("same seed gave different runs: %s and %s", first, second)
+		}
+
+		// Every run starts from the seed, whatever other runs do
+		if _, err := a.Run(context.Background(), "benih(7)\nacak(10)"); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if next := run(a); next != first {
+			t.Errorf("second run gave %s, expected %s", next, first)
+		}
+
+		results := make(chan string, 8)
+		for g := 0; g < cap(results); g++ {
+			go func() {
+				result, err := a.Run(context.Background(), "benih(acak(1000))\nacak(1000)")
+				if err != nil {
+					results <- err.Error()
+					return
+				}
+				results <- result.Inspect()
+			}()
+		}
+		concurrent := <-results
+		for g := 1; g < cap(results); g++ {
+			if r := <-results; r != concurrent {
+				t.Errorf("concurrent seeded runs differ: %s and %s", concurrent, r)
+			}
+		}
+	})
+}
+
+func TestInterpreterLargePrograms(t *testing.T) {
+	engines(t, func(t *testing.T, newInterp func() *sasaklang.Interpreter) {
+		// Many uses of the same literals fit the VM's constant pool
+		source := "gawe x = 0\n" + strings.Repeat("x = x + 34999\n", 70000) + "x"
+		result, err := newInterp().Run(context.Background(), source)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if result.Inspect() != "2449930000" {
+			t.Errorf("expected 2449930000, got=%s", result.Inspect())
+		}
+
+		// What does not fit the VM's instructions fails to compile instead
+		// of giving a wrong result
+		elements := strings.TrimSuffix(strings.Repeat("0, ", 70000), ", ")
+		interp := newInterp()
+		result, err = interp.Run(context.Background(), "belong(["+elements+"])")
+		switch {
+		case interp.UseVM:
+			if err == nil || !strings.Contains(err.Error(), "literal terlalu besar") {
+				t.Errorf("expected a compile error, got=%v (%v)", err, result)
+			}
+		case err != nil:
+			t.Errorf("unexpected error: %v", err)
+		case result.Inspect() != "70000":
+			t.Errorf("expected 70000, got=%s", result.Inspect())
+		}
+	})
+}
+
+func TestInterpreterCallback(t *testing.T) {
+	engines(t, func(t *testing.T, newInterp func() *sasaklang.Interpreter) {
+		interp := newInterp()
+		interp.Register("duaKali", func(rt *object.Runtime, args ...object.Object) object.Object {
+			result := rt.Call(args[0], args[1])
+			if _, ok := result.(*object.Error); ok {
+				return result
+			}
+			return rt.Call(args[0], result)
+		})
+
+		result, err := interp.Run(context.Background(), "duaKali(fungsi(x) { x * 3 }, 2)")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got := sasaklang.ToGo(result); got != int64(18) {
+			t.Errorf("expected 18, got=%v", got)
+		}
+
+		_, err = interp.Run(context.Background(), `duaKali(fungsi(x) { lempar "gagal" }, 2)`)
+		var runtimeErr *sasaklang.RuntimeError
+		if !errors.As(err, &runtimeErr) || runtimeErr.Err.Message != "gagal" {
+			t.Errorf("expected the callback's error, got=%v", err)
+		}
+	})
+}
+
+func TestInterpreterErrors(t *testing.T) {
+	engines(t, func(t *testing.T, newInterp func() *sasaklang.Interpreter) {
+		interp := newInterp()
+
+		_, err := interp.Run(context.Background(), "gawe = 1")
+		var parseErr *sasaklang.ParseError
+		if !errors.As(err, &parseErr) || len(parseErr.Errors) == 0 || parseErr.Errors[0].Line != 1 {
+			t.Errorf("expected a ParseError on line 1, got=%v", err)
+		}
+
+		_, err = interp.Run(context.Background(), "gawe x = 1\nx + ndarakAda")
+		var runtimeErr *sasaklang.RuntimeError
+		if !errors.As(err, &runtimeErr) {
+			t.Fatalf("expected a RuntimeError, got=%v", err)
+		}
+		if runtimeErr.Err.Line != 2 || !strings.Contains(runtimeErr.Err.Message, "ndarakAda") {
+			t.Errorf("wrong runtime error: %v", runtimeErr)
+		}
+
+		_, err = interp.Run(context.Background(), `lempar {"kode": 42}`)
+		if !errors.As(err, &runtimeErr) || sasaklang.ToGo(runtimeErr.Err.Value) == nil {
+			t.Errorf("expected the thrown value in the RuntimeError, got=%v", err)
+		}
+	})
+}
+
+func TestInterpreterImports(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "lib.ssk"), []byte("gawe nilai = 7\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	engines(t, func(t *testing.T, newInterp func() *sasaklang.Interpreter) {
+		interp := newInterp()
+		interp.File = filepath.Join(dir, "main.ssk")
+
+		_, err := interp.Run(context.Background(), `impor "lib"`)
+		if err == nil || !strings.Contains(err.Error(), "impor tidak diizinkan") {
+			t.Errorf("expected imports to be disabled, got=%v", err)
+		}
+
+		interp.AllowImports = true
+		result, err := interp.Run(context.Background(), "impor \"lib\"\nlib.nilai")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if got := sasaklang.ToGo(result); got != int64(7) {
+			t.Errorf("wrong result: %#v", got)
+		}
+	})
+}
+
+func TestInterpreterFreshScope(t *testing.T) {
+	interp := sasaklang.New()
+	if _, err := interp.Run(context.Background(), "gawe x = 1"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := interp.Run(context.Background(), "x"); err == nil {
+		t.Errorf("expected variables not to survive between runs")
+	}
+}
+
+func TestInterpreterCanceledContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	var out bytes.Buffer
+	interp := sasaklang.New()
+	interp.Stdout = &out
+	if _, err := interp.Run(ctx, `cetak("jalan")`); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled, got=%v", err)
+	}
+	if out.Len() != 0 {
+		t.Errorf("expected the program not to run, got output %q", out.String())
+	}
+}
+
+func TestToObject(t *testing.T) {
+	if _, err := sasaklang.ToObject(struct{}{}); err == nil {
+		t.Errorf("expected an error for a struct")
+	}
+	if _, err := sasaklang.ToObject(map[int]string{}); err == nil {
+		t.Errorf("expected an error for a map with integer keys")
+	}
+
+	obj, err := sasaklang.ToObject([]interface{}{1, 2.5, "a", nil, false})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if obj.Inspect() != "[1, 2.5, a, ndarak, salak]" {
+		t.Errorf("wrong conversion: %s", obj.Inspect())
+	}
+
+	// Integers beyond int64 become big integers, and back
+	huge, _ := new(big.Int).SetString("123456789012345678901234567890", 10)
+	obj, err = sasaklang.ToObject([]interface{}{huge, uint64(math.MaxUint64), big.NewInt(7)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if obj.Inspect() != "[123456789012345678901234567890, 18446744073709551615, 7]" {
+		t.Errorf("wrong conversion: %s", obj.Inspect())
+	}
+	got := sasaklang.ToGo(obj).([]interface{})
+	if n, ok := got[0].(*big.Int); !ok || n.Cmp(huge) != 0 {
+		t.Errorf("expected %s as *big.Int, got=%T (%v)", huge, got[0], got[0])
+	}
+	if got[2] != int64(7) {
+		t.Errorf("expected int64 7, got=%T (%v)", got[2], got[2])
+	}
+}
+
+func TestInterpreterStepLimit(t *testing.T) {
+	engines(t, func(t *testing.T, newInterp func() *sasaklang.Interpreter) {
+		tests := []struct {
+			input    string
+			maxSteps int64
+			ok       bool
+		}{
+			// Each loop iteration and each function call is a step
+			{"gawe i = 0\nselame (i < 10) { i++ }", 10, true},
+			{"gawe i = 0\nselame (i < 10) { i++ }", 9, false},
+			{"ojok (gawe i = 0; i < 5; i++) { lamun (i == 2) { lanjutan } }", 5, true},
+			{"ojok (gawe i = 0; i < 5; i++) { lamun (i == 2) { lanjutan } }", 4, false},
+			{"fungsi f() { 1 }\nf()\nf()", 2, true},
+			{"fungsi f() { 1 }\nf()\nf()", 1, false},
+			{"selame (kenak) { }", 1000, false},
+			{"ojok (x dalem [1, 2, 3]) { lamun (x == 2) { lanjutan } }", 3, true},
+			{"ojok (x dalem [1, 2, 3]) { lamun (x == 2) { lanjutan } }", 2, false},
+			{"ojok (x dalem rentang(1000000000000)) { }", 1000, false},
+			// Functions called back by builtins count too
+			{"petakan([1, 2, 3], fungsi(x) { x })", 3, true},
+			{"petakan([1, 2, 3], fungsi(x) { x })", 2, false},
+		}
+
+		for _, tt := range tests {
+			interp := newInterp()
+			interp.MaxSteps = tt.maxSteps
+			_, err := interp.Run(context.Background(), tt.input)
+			if tt.ok && err != nil {
+				t.Errorf("%q with %d steps: unexpected error %v", tt.input, tt.maxSteps, err)
+			}
+			if !tt.ok && !errors.Is(err, sasaklang.ErrStepLimit) {
+				t.Errorf("%q with %d steps: expected ErrStepLimit, got=%v", tt.input, tt.maxSteps, err)
+			}
+		}
+	})
+}
+
+func TestInterpreterDepthLimit(t *testing.T) {
+	engines(t, func(t *testing.T, newInterp func() *sasaklang.Interpreter) {
+		interp := newInterp()
+		interp.MaxDepth = 50
+		input := "fungsi f(n) { lamun (n > 0) { f(n - 1) } }\n"
+
+		if _, err := interp.Run(context.Background(), input+"f(49)"); err != nil {
+			t.Errorf("unexpected error for 50 nested calls: %v", err)
+		}
+		_, err := interp.Run(context.Background(), input+"f(50)")
+		var runtimeErr *sasaklang.RuntimeError
+		if !errors.Is(err, sasaklang.ErrDepthLimit) || !errors.As(err, &runtimeErr) {
+			t.Fatalf("expected ErrDepthLimit, got=%v", err)
+		}
+		if len(runtimeErr.Err.Stack) != 50 {
+			t.Errorf("expected 50 stack frames, got=%d", len(runtimeErr.Err.Stack))
+		}
+
+		// Without a limit set, runaway recursion still stops with an error
+		interp.MaxDepth = 0
+		if _, err := interp.Run(context.Background(), "fungsi g() { g() }\ng()"); !errors.Is(err, sasaklang.ErrDepthLimit) {
+			t.Errorf("expected ErrDepthLimit with the default limit, got=%v", err)
+		}
+	})
+}
+
+func TestInterpreterTimeout(t *testing.T) {
+	engines(t, func(t *testing.T, newInterp func() *sasaklang.Interpreter) {
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+
+		// The error can be caught, but the program cannot carry on looping
+		_, err := newInterp().Run(ctx, `selame (kenak) {
+  coba { selame (kenak) { } } tangkep (e) { }
+}`)
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("expected context.DeadlineExceeded, got=%v", err)
+		}
+	})
+}
+
+func TestInterpreterTimeoutWhileSleeping(t *testing.T) {
+	engines(t, func(t *testing.T, newInterp func() *sasaklang.Interpreter) {
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+
+		start := time.Now()
+		_, err := newInterp().Run(ctx, `coba { tedem(60000) } tangkep (e) { }
+tedem(60000)`)
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("expected context.DeadlineExceeded, got=%v", err)
+		}
+		if elapsed := time.Since(start); elapsed > 5*time.Second {
+			t.Errorf("tedem() kept sleeping after the deadline: %s", elapsed)
+		}
+	})
+}
+
+func TestInterpreterCatchLimit(t *testing.T) {
+	engines(t, func(t *testing.T, newInterp func() *sasaklang.Interpreter) {
+		interp := newInterp()
+		interp.MaxSteps = 100
+		interp.MaxDepth = 10
+
+		result, err := interp.Run(context.Background(), `gawe jenis = []
+fungsi f() { f() }
+coba { f() } tangkep (e) { jenis = sorong(jenis, e.jenis) }
+coba { selame (kenak) { } } tangkep (e) { jenis = sorong(jenis, e.jenis) }
+jenis`)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		expected := []interface{}{object.DEPTH_LIMIT_ERR, object.STEP_LIMIT_ERR}
+		if got := sasaklang.ToGo(result); !reflect.DeepEqual(got, expected) {
+			t.Errorf("wrong kinds. expected=%v, got=%v", expected, got)
+		}
+	})
+}
+
+func TestInterpreterMemoryLimit(t *testing.T) {
+	engines(t, func(t *testing.T, newInterp func() *sasaklang.Interpreter) {
+		tests := []string{
+			"gawe s = \"x\"\nselame (kenak) { s = s + s }",
+			"gawe arr = []\nselame (kenak) { arr = sorong(arr, 1) }",
+			"gawe m = {}\ngawe i = 0\nselame (kenak) { m[i] = i; i++ }",
+			"gawe m = {}\ngawe i = 0\nselame (kenak) { ngatur(m, i, i); i++ }",
+			"gawe daftar = []\nselame (kenak) { daftar = [daftar, daftar, {\"a\": daftar}] }",
+			"gawe n = 99999999999999999999\nselame (kenak) { n = n * n }",
+		}
+
+		for _, input := range tests {
+			interp := newInterp()
+			interp.MaxMemory = 1 << 20
+			interp.MaxSteps = 1000000
+			if _, err := interp.Run(context.Background(), input); !errors.Is(err, sasaklang.ErrMemoryLimit) {
+				t.Errorf("%q: expected ErrMemoryLimit, got=%v", input, err)
+			}
+		}
+
+		// A failed allocation does not count, so smaller values still fit
+		interp := newInterp()
+		interp.MaxMemory = 1 << 10
+		result, err := interp.Run(context.Background(), `gawe s = "abcdefghijklmnopqrstuvwxyz"
+gawe jenis = ndarak
+coba { selame (kenak) { s = s + s } } tangkep (e) { jenis = e.jenis }
+[jenis, "a" + "b"]`)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		expected := []interface{}{object.MEMORY_LIMIT_ERR, "ab"}
+		if got := sasaklang.ToGo(result); !reflect.DeepEqual(got, expected) {
+			t.Errorf("expected=%v, got=%v", expected, got)
+		}
+	})
+}
+
+func TestInterpreterMemoryLimitBeforeBuilding(t *testing.T) {
+	engines(t, func(t *testing.T, newInterp func() *sasaklang.Interpreter) {
+		// These texts would take gigabytes; they fail before being built
+		blowup := "gawe a = [1]\nojok (i dalem rentang(26)) { a = [a, a] }\n"
+		tests := []string{
+			blowup + "\"${a}\"",
+			blowup + "gabung([a, a], \"-\")",
+			`ganti(ulang("a", 1000), "a", ulang("b", 1000000))`,
+		}
+
+		for _, input := range tests {
+			interp := newInterp()
+			interp.MaxMemory = 1 << 20
+
+			var before, after goruntime.MemStats
+			goruntime.ReadMemStats(&before)
+			if _, err := interp.Run(context.Background(), input); !errors.Is(err, sasaklang.ErrMemoryLimit) {
+				t.Errorf("%q: expected ErrMemoryLimit, got=%v", input, err)
+			}
+			goruntime.ReadMemStats(&after)
+			if allocated := after.TotalAlloc - before.TotalAlloc; allocated > 64<<20 {
+				t.Errorf("%q: allocated %d bytes before failing", input, allocated)
+			}
+		}
+	})
+}
@@ -1,17 +1,37 @@
 package lexer
 
 import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/arjunaayasa/sasaklang/pkg/sasaklang/errors"
 	"github.com/arjunaayasa/sasaklang/pkg/sasaklang/token"
 )
 
-// Lexer tokenizes source code
+// Lexer tokenizes source code. It reads the input as UTF-8, and columns
+// count characters rather than bytes.
 type Lexer struct {
 	input        string
 	position     int  // current position in input (points to current char)
 	readPosition int  // current reading position in input (after current char)
-	ch           byte // current char under examination
+	ch           rune // current char under examination
 	line         int  // current line number
 	column       int  // current column number
+
+	errors []*errors.ParseError
+
+	templates  []template // interpolated strings being lexed, innermost last
+	unfinished bool       // the input ended inside a string
+}
+
+// template is an interpolated string whose interpolation is being lexed
+type template struct {
+	quote        rune // the quote that closes the string
+	line, column int  // where the string starts
+	braces       int  // braces opened and not yet closed in the interpolation
 }
 
 // New creates a new Lexer
@@ -23,28 +43,59 @@ func New(input string) *Lexer {
 
 // readChar reads the next character and advances position
 func (l *Lexer) readChar() {
-	if l.readPosition >= len(l.input) {
-		l.ch = 0
-	} else {
-		l.ch = l.input[l.readPosition]
-	}
-	l.position = l.readPosition
-	l.readPosition++
-
+	// A newline belongs to the line it ends; the next character starts a
+	// new one
 	if l.ch == '\n' {
 		l.line++
-		l.column = 0
+		l.column = 1
 	} else {
 		l.column++
 	}
+
+	l.position = l.readPosition
+	if l.readPosition >= len(l.input) {
+		l.ch = 0
+		l.readPosition++
+		return
+	}
+	ch, size := utf8.DecodeRuneInString(l.input[l.readPosition:])
+	l.ch = ch
+	l.readPosition += size
 }
 
 // peekChar returns the next character without advancing
-func (l *Lexer) peekChar() byte {
-	if l.readPosition >= len(l.input) {
+func (l *Lexer) peekChar() rune {
+	return l.peekCharAt(1)
+}
+
+// peekCharAt returns the character n positions after the current one
+func (l *Lexer) peekCharAt(n int) rune {
+	pos := l.readPosition
+	for ; n > 1 && pos < len(l.input); n-- {
+		_, size := utf8.DecodeRuneInString(l.input[pos:])
+		pos += size
+	}
+	if pos >= len(l.input) {
 		return 0
 	}
-	return l.input[l.readPosition]
+	ch, _ := utf8.DecodeRuneInString(l.input[pos:])
+	return ch
+}
+
+// Errors returns the errors found in string literals, such as unknown
+// escape sequences. The lexer still returns a token for such a string.
+func (l *Lexer) Errors() []*errors.ParseError {
+	return l.errors
+}
+
+// Unfinished reports whether the input ended inside a string literal or an
+// interpolation, so that more input could complete it
+func (l *Lexer) Unfinished() bool {
+	return l.unfinished || len(l.templates) > 0
+}
+
+func (l *Lexer) addError(line, column int, format string, a ...interface{}) {
+	l.errors = append(l.errors, errors.NewParseError(fmt.Sprintf(format, a...), line, column, ""))
 }
 
 // NextToken returns the next token from the input
@@ -66,15 +117,46 @@ func (l *Lexer) NextToken() token.Token {
 			tok = newToken(token.ASSIGN, l.ch, line, column)
 		}
 	case '+':
-		tok = newToken(token.PLUS, l.ch, line, column)
+		if l.peekChar() == '=' {
+			l.readChar()
+			tok = token.Token{Type: token.PLUS_ASSIGN, Literal: "+=", Line: line, Column: column}
+		} else if l.peekChar() == '+' {
+			l.readChar()
+			tok = token.Token{Type: token.INCREMENT, Literal: "++", Line: line, Column: column}
+		} else {
+			tok = newToken(token.PLUS, l.ch, line, column)
+		}
 	case '-':
-		tok = newToken(token.MINUS, l.ch, line, column)
+		if l.peekChar() == '=' {
+			l.readChar()
+			tok = token.Token{Type: token.MINUS_ASSIGN, Literal: "-=", Line: line, Column: column}
+		} else if l.peekChar() == '-' {
+			l.readChar()
+			tok = token.Token{Type: token.DECREMENT, Literal: "--", Line: line, Column: column}
+		} else {
+			tok = newToken(token.MINUS, l.ch, line, column)
+		}
 	case '*':
-		tok = newToken(token.ASTERISK, l.ch, line, column)
+		if l.peekChar() == '=' {
+			l.readChar()
+			tok = token.Token{Type: token.ASTERISK_ASSIGN, Literal: "*=", Line: line, Column: column}
+		} else {
+			tok = newToken(token.ASTERISK, l.ch, line, column)
+		}
 	case '/':
-		tok = newToken(token.SLASH, l.ch, line, column)
+		if l.peekChar() == '=' {
+			l.readChar()
+			tok = token.Token{Type: token.SLASH_ASSIGN, Literal: "/=", Line: line, Column: column}
+		} else {
+			tok = newToken(token.SLASH, l.ch, line, column)
+		}
 	case '%':
-		tok = newToken(token.MODULO, l.ch, line, column)
+		if l.peekChar() == '=' {
+			l.readChar()
+			tok = token.Token{Type: token.MODULO_ASSIGN, Literal: "%=", Line: line, Column: column}
+		} else {
+			tok = newToken(token.MODULO, l.ch, line, column)
+		}
 	case '!':
 		if l.peekChar() == '=' {
 			l.readChar()
@@ -116,24 +198,32 @@ func (l *Lexer) NextToken() token.Token {
 		tok = newToken(token.SEMICOLON, l.ch, line, column)
 	case ':':
 		tok = newToken(token.COLON, l.ch, line, column)
+	case '.':
+		tok = newToken(token.DOT, l.ch, line, column)
 	case '(':
 		tok = newToken(token.LPAREN, l.ch, line, column)
 	case ')':
 		tok = newToken(token.RPAREN, l.ch, line, column)
 	case '{':
+		if n := len(l.templates); n > 0 {
+			l.templates[n-1].braces++
+		}
 		tok = newToken(token.LBRACE, l.ch, line, column)
 	case '}':
+		// A '}' that closes an interpolation goes back to the string
+		if n := len(l.templates); n > 0 {
+			if l.templates[n-1].braces == 0 {
+				return l.readStringPart(line, column)
+			}
+			l.templates[n-1].braces--
+		}
 		tok = newToken(token.RBRACE, l.ch, line, column)
 	case '[':
 		tok = newToken(token.LBRACKET, l.ch, line, column)
 	case ']':
 		tok = newToken(token.RBRACKET, l.ch, line, column)
-	case '"':
-		tok.Type = token.STRING
-		tok.Literal = l.readString()
-		tok.Line = line
-		tok.Column = column
-		return tok
+	case '"', '`':
+		return l.readStringStart(line, column)
 	case '#':
 		l.skipComment()
 		return l.NextToken()
@@ -152,8 +242,7 @@ func (l *Lexer) NextToken() token.Token {
 			tok.Column = column
 			return tok
 		} else if isDigit(l.ch) {
-			tok.Literal = l.readNumber()
-			tok.Type = token.INT
+			tok.Literal, tok.Type = l.readNumber()
 			tok.Line = line
 			tok.Column = column
 			return tok
@@ -183,50 +272,174 @@ func (l *Lexer) skipComment() {
 // readIdentifier reads an identifier
 func (l *Lexer) readIdentifier() string {
 	position := l.position
-	for isLetter(l.ch) || isDigit(l.ch) || l.ch == '_' {
+	for isIdentifierChar(l.ch) {
 		l.readChar()
 	}
 	return l.input[position:l.position]
 }
 
-// readNumber reads a number
-func (l *Lexer) readNumber() string {
+// readNumber reads an integer or a floating-point number
+func (l *Lexer) readNumber() (string, token.TokenType) {
 	position := l.position
+	tokType := token.INT
 	for isDigit(l.ch) {
 		l.readChar()
 	}
-	return l.input[position:l.position]
+
+	// Fractional part, only when a digit follows the dot
+	if l.ch == '.' && isDigit(l.peekChar()) {
+		tokType = token.FLOAT
+		l.readChar()
+		for isDigit(l.ch) {
+			l.readChar()
+		}
+	}
+
+	// Exponent part, e.g. 1.5e3 or 2e-4
+	if l.ch == 'e' || l.ch == 'E' {
+		if isDigit(l.peekChar()) || ((l.peekChar() == '+' || l.peekChar() == '-') && isDigit(l.peekCharAt(2))) {
+			tokType = token.FLOAT
+			l.readChar()
+			if l.ch == '+' || l.ch == '-' {
+				l.readChar()
+			}
+			for isDigit(l.ch) {
+				l.readChar()
+			}
+		}
+	}
+
+	return l.input[position:l.position], tokType
+}
+
+// escapes maps the character after a backslash to the character it stands for
+var escapes = map[rune]rune{
+	'n':  '\n',
+	't':  '\t',
+	'r':  '\r',
+	'"':  '"',
+	'\\': '\\',
+	'$':  '$',
+}
+
+// readStringStart reads a string literal up to its closing quote or its
+// first interpolation. Strings in backquotes are raw: they have no escape
+// sequences.
+func (l *Lexer) readStringStart(line, column int) token.Token {
+	quote := l.ch
+	text, interpolated := l.readString(quote, line, column)
+	if !interpolated {
+		return token.Token{Type: token.STRING, Literal: text, Line: line, Column: column}
+	}
+	l.templates = append(l.templates, template{quote: quote, line: line, column: column})
+	return token.Token{Type: token.TEMPLATE_START, Literal: text, Line: line, Column: column}
 }
 
-// readString reads a string literal
-func (l *Lexer) readString() string {
-	position := l.position + 1
+// readStringPart reads the text after the '}' that ends an interpolation,
+// up to the next interpolation or the end of the string
+func (l *Lexer) readStringPart(line, column int) token.Token {
+	t := l.templates[len(l.templates)-1]
+	text, interpolated := l.readString(t.quote, t.line, t.column)
+	if interpolated {
+		return token.Token{Type: token.TEMPLATE_MIDDLE, Literal: text, Line: line, Column: column}
+	}
+	l.templates = l.templates[:len(l.templates)-1]
+	return token.Token{Type: token.TEMPLATE_END, Literal: text, Line: line, Column: column}
+}
+
+// readString reads the text of the string that starts at line and column
+// and is closed by quote, with the escape sequences decoded. It stops after
+// the closing quote or after a "${" that starts an interpolation, and
+// reports which of the two it found.
+func (l *Lexer) readString(quote rune, line, column int) (string, bool) {
+	raw := quote == '`'
+	var out strings.Builder
 	for {
 		l.readChar()
-		if l.ch == '"' || l.ch == 0 {
-			break
-		}
-		// Handle escape sequences
-		if l.ch == '\\' && l.peekChar() != 0 {
+		switch {
+		case l.ch == 0:
+			l.addError(line, column, "string tidak ditutup, kurang '%c'", quote)
+			l.unfinished = true
+			return out.String(), false
+		case l.ch == quote:
+			l.readChar() // consume closing quote
+			return out.String(), false
+		case l.ch == '$' && l.peekChar() == '{':
 			l.readChar()
+			l.readChar() // consume "${"
+			return out.String(), true
+		case l.ch == '\\' && !raw:
+			l.readEscape(&out)
+		case l.ch == '\r' && raw:
+			// Raw strings keep their text as written, except for the
+			// carriage returns of Windows line endings
+		default:
+			out.WriteRune(l.ch)
+		}
+	}
+}
+
+// readEscape decodes the escape sequence starting at the current backslash
+func (l *Lexer) readEscape(out *strings.Builder) {
+	line, column := l.line, l.column
+	l.readChar()
+
+	if ch, ok := escapes[l.ch]; ok {
+		out.WriteRune(ch)
+		return
+	}
+	if l.ch != 'u' {
+		if l.ch == 0 {
+			return
 		}
+		l.addError(line, column, "escape tidak dikenal: \\%c", l.ch)
+		out.WriteRune(l.ch)
+		return
 	}
-	str := l.input[position:l.position]
-	l.readChar() // consume closing quote
-	return str
+
+	// \u{...} holds the hexadecimal code of a Unicode character
+	if l.peekChar() != '{' {
+		l.addError(line, column, "escape \\u harus ditulis \\u{kode hex}")
+		return
+	}
+	l.readChar()
+	var hex strings.Builder
+	for l.peekChar() != '}' && l.peekChar() != '"' && l.peekChar() != 0 {
+		l.readChar()
+		hex.WriteRune(l.ch)
+	}
+	if l.peekChar() != '}' {
+		l.addError(line, column, "escape \\u{%s tidak ditutup, kurang '}'", hex.String())
+		return
+	}
+	l.readChar()
+
+	code, err := strconv.ParseUint(hex.String(), 16, 32)
+	if err != nil || hex.Len() > 6 || !utf8.ValidRune(rune(code)) {
+		l.addError(line, column, "kode Unicode tidak valid: \\u{%s}", hex.String())
+		return
+	}
+	out.WriteRune(rune(code))
 }
 
 // newToken creates a new token
-func newToken(tokenType token.TokenType, ch byte, line, column int) token.Token {
+func newToken(tokenType token.TokenType, ch rune, line, column int) token.Token {
 	return token.Token{Type: tokenType, Literal: string(ch), Line: line, Column: column}
 }
 
-// isLetter checks if a character is a letter
-func isLetter(ch byte) bool {
-	return 'a' <= ch && ch <= 'z' || 'A' <= ch && ch <= 'Z' || ch == '_'
+// isLetter checks if a character can start an identifier: a Unicode letter
+// or an underscore
+func isLetter(ch rune) bool {
+	return unicode.IsLetter(ch) || ch == '_'
+}
+
+// isIdentifierChar checks if a character can continue an identifier, which
+// also allows digits and combining marks such as accents
+func isIdentifierChar(ch rune) bool {
+	return isLetter(ch) || unicode.IsDigit(ch) || unicode.Is(unicode.Mn, ch) || unicode.Is(unicode.Mc, ch)
 }
 
 // isDigit checks if a character is a digit
-func isDigit(ch byte) bool {
+func isDigit(ch rune) bool {
 	return '0' <= ch && ch <= '9'
 }
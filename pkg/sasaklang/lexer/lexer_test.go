@@ -263,10 +263,15 @@ gawe y = 10`
 		tok = l.NextToken()
 	}
 
+	// the newline ends the first line
+	if tok.Line != 1 || tok.Column != 11 {
+		t.Errorf("expected newline at 1:11, got %d:%d", tok.Line, tok.Column)
+	}
+
 	// gawe on second line
 	tok = l.NextToken()
-	if tok.Line != 2 {
-		t.Errorf("expected line 2, got %d", tok.Line)
+	if tok.Line != 2 || tok.Column != 1 {
+		t.Errorf("expected 2:1, got %d:%d", tok.Line, tok.Column)
 	}
 }
 
@@ -296,3 +301,237 @@ gawe y = 10`
 		}
 	}
 }
+
+func TestFloatNumbers(t *testing.T) {
+	input := `3.14 10 0.5 2.5e3 1e-2 7.foo`
+
+	tests := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+	}{
+		{token.FLOAT, "3.14"},
+		{token.INT, "10"},
+		{token.FLOAT, "0.5"},
+		{token.FLOAT, "2.5e3"},
+		{token.FLOAT, "1e-2"},
+		{token.INT, "7"},
+		{token.DOT, "."},
+		{token.IDENT, "foo"},
+		{token.EOF, ""},
+	}
+
+	l := New(input)
+
+	for i, tt := range tests {
+		tok := l.NextToken()
+
+		if tok.Type != tt.expectedType {
+			t.Fatalf("tests[%d] - tokentype wrong. expected=%q, got=%q",
+				i, tt.expectedType, tok.Type)
+		}
+
+		if tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - literal wrong. expected=%q, got=%q",
+				i, tt.expectedLiteral, tok.Literal)
+		}
+	}
+}
+
+func TestCompoundAssignmentTokens(t *testing.T) {
+	input := `x += 1 x -= 2 x *= 3 x /= 4 x %= 5 i++ i-- a - -b`
+
+	expected := []token.TokenType{
+		token.IDENT, token.PLUS_ASSIGN, token.INT,
+		token.IDENT, token.MINUS_ASSIGN, token.INT,
+		token.IDENT, token.ASTERISK_ASSIGN, token.INT,
+		token.IDENT, token.SLASH_ASSIGN, token.INT,
+		token.IDENT, token.MODULO_ASSIGN, token.INT,
+		token.IDENT, token.INCREMENT,
+		token.IDENT, token.DECREMENT,
+		token.IDENT, token.MINUS, token.MINUS, token.IDENT,
+		token.EOF,
+	}
+
+	l := New(input)
+
+	for i, exp := range expected {
+		tok := l.NextToken()
+		if tok.Type != exp {
+			t.Fatalf("tests[%d] - expected %q, got %q", i, exp, tok.Type)
+		}
+	}
+}
+
+func TestStringEscapes(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`"a\nb"`, "a\nb"},
+		{`"kolom\tkedua\r"`, "kolom\tkedua\r"},
+		{`"dia bilang \"halo\""`, `dia bilang "halo"`},
+		{`"C:\\sasak"`, `C:\sasak`},
+		{`"\u{e9}t\u{E9} \u{1F600}"`, "été 😀"},
+		{`"tanpa escape"`, "tanpa escape"},
+	}
+
+	for _, tt := range tests {
+		l := New(tt.input)
+		tok := l.NextToken()
+		if tok.Type != token.STRING || tok.Literal != tt.expected {
+			t.Errorf("%s: expected STRING %q, got=%s %q", tt.input, tt.expected, tok.Type, tok.Literal)
+		}
+		if len(l.Errors()) != 0 {
+			t.Errorf("%s: unexpected errors %v", tt.input, l.Errors()[0])
+		}
+		if next := l.NextToken(); next.Type != token.EOF {
+			t.Errorf("%s: expected EOF after the string, got=%s", tt.input, next.Type)
+		}
+	}
+}
+
+func TestStringErrors(t *testing.T) {
+	tests := []struct {
+		input   string
+		column  int
+		message string
+	}{
+		{`x = "a\qb"`, 7, `escape tidak dikenal: \q`},
+		{`"\u{110000}"`, 2, `kode Unicode tidak valid: \u{110000}`},
+		{`"\u{zz}"`, 2, `kode Unicode tidak valid: \u{zz}`},
+		{`"\u41"`, 2, `escape \u harus ditulis \u{kode hex}`},
+		{`"\u{41"`, 2, `escape \u{41 tidak ditutup, kurang '}'`},
+		{`cetak("abc`, 7, `string tidak ditutup, kurang '"'`},
+	}
+
+	for _, tt := range tests {
+		l := New(tt.input)
+		for tok := l.NextToken(); tok.Type != token.EOF; tok = l.NextToken() {
+		}
+
+		errs := l.Errors()
+		if len(errs) != 1 {
+			t.Errorf("%s: expected 1 error, got=%d", tt.input, len(errs))
+			continue
+		}
+		if errs[0].Line != 1 || errs[0].Column != tt.column || errs[0].Message != tt.message {
+			t.Errorf("%s: wrong error %d:%d %q", tt.input, errs[0].Line, errs[0].Column, errs[0].Message)
+		}
+	}
+}
+
+func TestUnicode(t *testing.T) {
+	input := `gawe niláé = "Lombok ñ"
+gawe 名前 = niláé + ñ2`
+
+	tests := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+		line, column    int
+	}{
+		{token.GAWE, "gawe", 1, 1},
+		{token.IDENT, "niláé", 1, 6},
+		{token.ASSIGN, "=", 1, 12},
+		{token.STRING, "Lombok ñ", 1, 14},
+		{token.NEWLINE, "\n", 1, 24},
+		{token.GAWE, "gawe", 2, 1},
+		{token.IDENT, "名前", 2, 6},
+		{token.ASSIGN, "=", 2, 9},
+		{token.IDENT, "niláé", 2, 11},
+		{token.PLUS, "+", 2, 17},
+		{token.IDENT, "ñ2", 2, 19},
+		{token.EOF, "", 2, 21},
+	}
+
+	l := New(input)
+	for i, tt := range tests {
+		tok := l.NextToken()
+		if tok.Type != tt.expectedType || tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - expected %s %q, got=%s %q", i, tt.expectedType, tt.expectedLiteral, tok.Type, tok.Literal)
+		}
+		if tok.Line != tt.line || tok.Column != tt.column {
+			t.Errorf("tests[%d] - %q: expected %d:%d, got=%d:%d", i, tok.Literal, tt.line, tt.column, tok.Line, tok.Column)
+		}
+	}
+}
+
+func TestTemplateStrings(t *testing.T) {
+	input := `"Halo, ${nama}!" "${a}${ {"k": "${b}"} }" "\${x}"`
+
+	tests := []struct {
+		expectedType    token.TokenType
+		expectedLiteral string
+		column          int
+	}{
+		{token.TEMPLATE_START, "Halo, ", 1},
+		{token.IDENT, "nama", 10},
+		{token.TEMPLATE_END, "!", 14},
+		{token.TEMPLATE_START, "", 18},
+		{token.IDENT, "a", 21},
+		{token.TEMPLATE_MIDDLE, "", 22},
+		{token.LBRACE, "{", 26},
+		{token.STRING, "k", 27},
+		{token.COLON, ":", 30},
+		{token.TEMPLATE_START, "", 32},
+		{token.IDENT, "b", 35},
+		{token.TEMPLATE_END, "", 36},
+		{token.RBRACE, "}", 38},
+		{token.TEMPLATE_END, "", 40},
+		{token.STRING, "${x}", 43},
+		{token.EOF, "", 50},
+	}
+
+	l := New(input)
+	for i, tt := range tests {
+		tok := l.NextToken()
+		if tok.Type != tt.expectedType || tok.Literal != tt.expectedLiteral {
+			t.Fatalf("tests[%d] - expected %s %q, got=%s %q", i, tt.expectedType, tt.expectedLiteral, tok.Type, tok.Literal)
+		}
+		if tok.Column != tt.column {
+			t.Errorf("tests[%d] - %q: expected column %d, got=%d", i, tok.Literal, tt.column, tok.Column)
+		}
+	}
+	if len(l.Errors()) != 0 || l.Unfinished() {
+		t.Errorf("unexpected errors %v", l.Errors())
+	}
+}
+
+func TestRawStrings(t *testing.T) {
+	input := "`C:\\sasak\\n\r\nbaris ${n}`"
+
+	expected := []token.Token{
+		{Type: token.TEMPLATE_START, Literal: "C:\\sasak\\n\nbaris ", Line: 1, Column: 1},
+		{Type: token.IDENT, Literal: "n", Line: 2, Column: 9},
+		{Type: token.TEMPLATE_END, Literal: "", Line: 2, Column: 10},
+		{Type: token.EOF, Literal: "", Line: 2, Column: 12},
+	}
+
+	l := New(input)
+	for i, exp := range expected {
+		if tok := l.NextToken(); tok != exp {
+			t.Fatalf("tests[%d] - expected %+v, got=%+v", i, exp, tok)
+		}
+	}
+}
+
+func TestUnfinished(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected bool
+	}{
+		{`"selesai ${x}"`, false},
+		{"`baris satu", true},
+		{`"halo ${nama`, true},
+		{`"halo ${ {"a": 1}`, true},
+		{`{`, false},
+	}
+
+	for _, tt := range tests {
+		l := New(tt.input)
+		for tok := l.NextToken(); tok.Type != token.EOF; tok = l.NextToken() {
+		}
+		if l.Unfinished() != tt.expected {
+			t.Errorf("%s: expected Unfinished()=%t", tt.input, tt.expected)
+		}
+	}
+}
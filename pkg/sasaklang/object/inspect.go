@@ -0,0 +1,102 @@
+package object
+
+import "strings"
+
+// InspectJoin returns the text of values as Inspect prints them, joined by
+// sep; an array or map inside itself is printed as [...] or {...}. The
+// text is only built up to limit bytes: when it would be longer,
+// InspectJoin stops and reports false. A negative limit means no limit.
+func InspectJoin(values []Object, sep string, limit int64) (string, bool) {
+	p := &inspector{limit: limit}
+	for i, v := range values {
+		if i > 0 {
+			p.write(sep)
+		}
+		p.inspect(v)
+	}
+	return p.out.String(), !p.over
+}
+
+// inspector writes the text of values, stopping once it passes its limit.
+// Arrays and maps are written element by element, so that a value holding
+// the same array many times over is not printed whole before the limit is
+// checked.
+type inspector struct {
+	out   strings.Builder
+	limit int64
+	over  bool
+	outer []Object // the arrays and maps being printed further up
+}
+
+// enter reports whether obj is not printed further up already, and if so
+// adds it to the outer values until leave is called
+func (p *inspector) enter(obj Object) bool {
+	for _, o := range p.outer {
+		if o == obj {
+			return false
+		}
+	}
+	p.outer = append(p.outer, obj)
+	return true
+}
+
+func (p *inspector) leave() {
+	p.outer = p.outer[:len(p.outer)-1]
+}
+
+func (p *inspector) write(s string) {
+	if p.over {
+		return
+	}
+	if p.limit >= 0 && int64(p.out.Len())+int64(len(s)) > p.limit {
+		p.over = true
+		return
+	}
+	p.out.WriteString(s)
+}
+
+func (p *inspector) inspect(obj Object) {
+	if p.over {
+		return
+	}
+	switch obj := obj.(type) {
+	case *Array:
+		if !p.enter(obj) {
+			p.write("[...]")
+			return
+		}
+		defer p.leave()
+		p.write("[")
+		for i, el := range obj.Elements {
+			if i > 0 {
+				p.write(", ")
+			}
+			p.inspect(el)
+			if p.over {
+				return
+			}
+		}
+		p.write("]")
+	case *Map:
+		if !p.enter(obj) {
+			p.write("{...}")
+			return
+		}
+		defer p.leave()
+		p.write("{")
+		for i, pair := range obj.pairs() {
+			if i > 0 {
+				p.write(", ")
+			}
+			p.inspect(pair.Key)
+			p.write(": ")
+			p.inspect(pair.Value)
+			if p.over {
+				return
+			}
+		}
+		p.write("}")
+	default:
+		p.write(obj.Inspect())
+	}
+}
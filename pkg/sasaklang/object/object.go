@@ -2,11 +2,17 @@ package object
 
 import (
 	"bytes"
+	"encoding/binary"
 	"fmt"
 	"hash/fnv"
+	"math"
+	"math/big"
+	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/arjunaayasa/sasaklang/pkg/sasaklang/ast"
+	"github.com/arjunaayasa/sasaklang/pkg/sasaklang/code"
 )
 
 // ObjectType represents the type of an object
@@ -14,6 +20,8 @@ type ObjectType string
 
 const (
 	INTEGER_OBJ      ObjectType = "INTEGER"
+	BIG_INTEGER_OBJ  ObjectType = "BIG_INTEGER"
+	FLOAT_OBJ        ObjectType = "FLOAT"
 	STRING_OBJ       ObjectType = "STRING"
 	BOOLEAN_OBJ      ObjectType = "BOOLEAN"
 	NULL_OBJ         ObjectType = "NULL"
@@ -23,8 +31,14 @@ const (
 	BUILTIN_OBJ      ObjectType = "BUILTIN"
 	ARRAY_OBJ        ObjectType = "ARRAY"
 	MAP_OBJ          ObjectType = "MAP"
+	RANGE_OBJ        ObjectType = "RANGE"
 	BREAK_OBJ        ObjectType = "BREAK"
 	CONTINUE_OBJ     ObjectType = "CONTINUE"
+	MODULE_OBJ       ObjectType = "MODULE"
+
+	COMPILED_FUNCTION_OBJ ObjectType = "COMPILED_FUNCTION"
+	CELL_OBJ              ObjectType = "CELL"
+	ITERATOR_OBJ          ObjectType = "ITERATOR"
 )
 
 // Object is the interface all objects implement
@@ -33,7 +47,8 @@ type Object interface {
 	Inspect() string
 }
 
-// Hashable is implemented by objects that can be map keys
+// Hashable is implemented by the scalar values that can be map keys. Use
+// HashOf to hash any key, arrays included.
 type Hashable interface {
 	HashKey() HashKey
 }
@@ -55,6 +70,105 @@ func (i *Integer) HashKey() HashKey {
 	return HashKey{Type: i.Type(), Value: uint64(i.Value)}
 }
 
+// BigInteger is an integer outside the range of Integer. Integer arithmetic
+// switches to it when a result overflows int64; build values with
+// NewInteger, so that a result that fits again becomes an Integer.
+type BigInteger struct {
+	Value *big.Int
+}
+
+func (b *BigInteger) Type() ObjectType { return BIG_INTEGER_OBJ }
+func (b *BigInteger) Inspect() string  { return b.Value.String() }
+func (b *BigInteger) HashKey() HashKey {
+	h := fnv.New64a()
+	if b.Value.Sign() < 0 {
+		h.Write([]byte{'-'})
+	}
+	h.Write(b.Value.Bytes())
+	return HashKey{Type: b.Type(), Value: h.Sum64()}
+}
+
+// NewInteger returns n as an Integer if it fits in int64, and as a
+// BigInteger otherwise
+func NewInteger(n *big.Int) Object {
+	if n.IsInt64() {
+		return &Integer{Value: n.Int64()}
+	}
+	return &BigInteger{Value: n}
+}
+
+// BigValue returns the value of an Integer or BigInteger as a big.Int,
+// which the caller may modify
+func BigValue(obj Object) (*big.Int, bool) {
+	switch obj := obj.(type) {
+	case *Integer:
+		return big.NewInt(obj.Value), true
+	case *BigInteger:
+		return new(big.Int).Set(obj.Value), true
+	}
+	return nil, false
+}
+
+// Float represents a floating-point value (pecahan)
+type Float struct {
+	Value float64
+}
+
+func (f *Float) Type() ObjectType { return FLOAT_OBJ }
+func (f *Float) Inspect() string {
+	s := strconv.FormatFloat(f.Value, 'g', -1, 64)
+	// Always show a fractional part so floats are distinguishable from integers
+	if !strings.ContainsAny(s, ".eEIN") {
+		s += ".0"
+	}
+	return s
+}
+func (f *Float) HashKey() HashKey {
+	// A float with an integer value hashes like that integer, as they are
+	// the same key: 1.0 == 1
+	if f.Value == math.Trunc(f.Value) && !math.IsInf(f.Value, 0) {
+		if f.Value >= -(1<<63) && f.Value < 1<<63 {
+			return (&Integer{Value: int64(f.Value)}).HashKey()
+		}
+		n, _ := big.NewFloat(f.Value).Int(nil)
+		return (&BigInteger{Value: n}).HashKey()
+	}
+	return HashKey{Type: f.Type(), Value: math.Float64bits(f.Value)}
+}
+
+// NumbersEqual reports whether a and b are numbers with the same value.
+// Integers and floats are compared exactly, so 1 == 1.0 but an integer
+// that a float cannot hold is not equal to the float nearest to it.
+func NumbersEqual(a, b Object) bool {
+	af, aFloat := a.(*Float)
+	bf, bFloat := b.(*Float)
+	switch {
+	case aFloat && bFloat:
+		return af.Value == bf.Value
+	case aFloat:
+		return floatEqualsInteger(af.Value, b)
+	case bFloat:
+		return floatEqualsInteger(bf.Value, a)
+	}
+	if ai, ok := a.(*Integer); ok {
+		if bi, ok := b.(*Integer); ok {
+			return ai.Value == bi.Value
+		}
+	}
+	an, aok := BigValue(a)
+	bn, bok := BigValue(b)
+	return aok && bok && an.Cmp(bn) == 0
+}
+
+// floatEqualsInteger reports whether f is equal to the integer obj
+func floatEqualsInteger(f float64, obj Object) bool {
+	n, ok := BigValue(obj)
+	if !ok || math.IsNaN(f) || math.IsInf(f, 0) {
+		return false
+	}
+	return new(big.Float).SetInt(n).Cmp(big.NewFloat(f)) == 0
+}
+
 // String represents a string value
 type String struct {
 	Value string
@@ -93,6 +207,22 @@ func (b *Boolean) HashKey() HashKey {
 // Null represents null (kosong)
 type Null struct{}
 
+// The values of kenak, salak and ndarak. Booleans and null are compared by
+// identity, so every kenak must be TRUE, and so on.
+var (
+	NULL  = &Null{}
+	TRUE  = &Boolean{Value: true}
+	FALSE = &Boolean{Value: false}
+)
+
+// NativeBool returns TRUE or FALSE
+func NativeBool(b bool) *Boolean {
+	if b {
+		return TRUE
+	}
+	return FALSE
+}
+
 func (n *Null) Type() ObjectType { return NULL_OBJ }
 func (n *Null) Inspect() string  { return "ndarak" }
 
@@ -109,6 +239,26 @@ type Error struct {
 	Message string
 	Line    int
 	Column  int
+	File    string       // source file the error occurred in, if known
+	Value   Object       // the value given to lempar, nil for runtime errors
+	Stack   []StackFrame // calls that were active, innermost first
+	Kind    string       // one of the *_ERR kinds below, "" for other errors
+}
+
+// Kinds of the errors raised when a run reaches one of its limits
+const (
+	CANCELED_ERR     = "DIBATALKAN"
+	STEP_LIMIT_ERR   = "BATAS_LANGKAH"
+	DEPTH_LIMIT_ERR  = "BATAS_KEDALAMAN"
+	MEMORY_LIMIT_ERR = "BATAS_MEMORI"
+)
+
+// StackFrame is a function call that was active when an error occurred
+type StackFrame struct {
+	Function string // name of the called function
+	File     string // position of the call
+	Line     int
+	Column   int
 }
 
 func (e *Error) Type() ObjectType { return ERROR_OBJ }
@@ -156,8 +306,85 @@ func (f *Function) Inspect() string {
 	return out.String()
 }
 
-// BuiltinFunction is the type for builtin functions
-type BuiltinFunction func(args ...Object) Object
+// CompiledFunction holds the bytecode of a function compiled for the VM
+type CompiledFunction struct {
+	Instructions  code.Instructions
+	Positions     []code.Position // source positions of the instructions
+	Constants     []Object        // constant pool of the program it belongs to
+	NumLocals     int
+	NumParameters int
+	Name          string
+	Parameters    []string
+	LocalNames    []string // names of the local slots, for error messages
+	FreeNames     []string // names of the captured variables, for error messages
+}
+
+func (cf *CompiledFunction) Type() ObjectType { return COMPILED_FUNCTION_OBJ }
+func (cf *CompiledFunction) Inspect() string {
+	return fmt.Sprintf("CompiledFunction[%p]", cf)
+}
+
+// Globals holds the global variables of one compiled program or module
+type Globals struct {
+	Values   []Object
+	Names    []string
+	TopLevel map[string]int // slots of the top-level variables, by name
+	File     string         // source file of the program or module
+}
+
+// Cell boxes a variable that is captured by a closure, so that the closure
+// and the scope that declared the variable share it
+type Cell struct {
+	Value Object
+}
+
+func (c *Cell) Type() ObjectType { return CELL_OBJ }
+func (c *Cell) Inspect() string {
+	if c.Value == nil {
+		return "ndarak"
+	}
+	return c.Value.Inspect()
+}
+
+// Iterator goes through the elements of a value for an ojok ... dalem loop
+type Iterator struct {
+	// Next returns the key (index) and value of the next element, and false
+	// once there are none left. The value is an *Error when the element
+	// could not be created.
+	Next func() (key, value Object, ok bool)
+
+	// KeysOnly makes a loop with one variable get the keys instead of the
+	// values, as it does for maps
+	KeysOnly bool
+}
+
+func (it *Iterator) Type() ObjectType { return ITERATOR_OBJ }
+func (it *Iterator) Inspect() string  { return "iterator" }
+
+// Closure is a compiled function together with the variables it captured.
+// To scripts it is indistinguishable from a Function.
+type Closure struct {
+	Fn      *CompiledFunction
+	Free    []*Cell
+	Globals *Globals // globals of the program or module that defined it
+}
+
+func (c *Closure) Type() ObjectType { return FUNCTION_OBJ }
+func (c *Closure) Inspect() string {
+	var out bytes.Buffer
+	out.WriteString("fungsi")
+	if c.Fn.Name != "" {
+		out.WriteString(" " + c.Fn.Name)
+	}
+	out.WriteString("(")
+	out.WriteString(strings.Join(c.Fn.Parameters, ", "))
+	out.WriteString(") { ... }")
+	return out.String()
+}
+
+// BuiltinFunction is the type for builtin functions. rt is the runtime of
+// the program making the call.
+type BuiltinFunction func(rt *Runtime, args ...Object) Object
 
 // Builtin represents a builtin function
 type Builtin struct {
@@ -174,15 +401,20 @@ type Array struct {
 
 func (a *Array) Type() ObjectType { return ARRAY_OBJ }
 func (a *Array) Inspect() string {
-	var out bytes.Buffer
-	elements := []string{}
-	for _, e := range a.Elements {
-		elements = append(elements, e.Inspect())
-	}
-	out.WriteString("[")
-	out.WriteString(strings.Join(elements, ", "))
-	out.WriteString("]")
-	return out.String()
+	text, _ := InspectJoin([]Object{a}, "", -1)
+	return text
+}
+
+// Range is the sequence of integers from Start up to, but not including,
+// End, going by Step. Its numbers are produced one at a time, so a range
+// takes no memory for them.
+type Range struct {
+	Start, End, Step int64
+}
+
+func (r *Range) Type() ObjectType { return RANGE_OBJ }
+func (r *Range) Inspect() string {
+	return fmt.Sprintf("rentang(%d, %d, %d)", r.Start, r.End, r.Step)
 }
 
 // MapPair represents a key-value pair in a map
@@ -191,47 +423,318 @@ type MapPair struct {
 	Value Object
 }
 
-// Map represents a map/dictionary
+// HashOf returns the hash key of obj and reports whether obj can be a map
+// key. Arrays can be keys (tuples) when all their elements can; an array
+// that contains itself cannot.
+func HashOf(obj Object) (HashKey, bool) {
+	return hashOf(obj, nil)
+}
+
+// hashOf hashes obj, where outer holds the arrays that contain it
+func hashOf(obj Object, outer []*Array) (HashKey, bool) {
+	arr, ok := obj.(*Array)
+	if !ok {
+		hashable, ok := obj.(Hashable)
+		if !ok {
+			return HashKey{}, false
+		}
+		return hashable.HashKey(), true
+	}
+	for _, a := range outer {
+		if a == arr {
+			return HashKey{}, false
+		}
+	}
+	outer = append(outer, arr)
+
+	h := fnv.New64a()
+	var buf [8]byte
+	for _, el := range arr.Elements {
+		key, ok := hashOf(el, outer)
+		if !ok {
+			return HashKey{}, false
+		}
+		h.Write([]byte(key.Type))
+		binary.LittleEndian.PutUint64(buf[:], key.Value)
+		h.Write(buf[:])
+	}
+	return HashKey{Type: ARRAY_OBJ, Value: h.Sum64()}, true
+}
+
+// keysEqual reports whether a and b are the same map key. Both must have
+// passed HashOf, so that arrays do not contain themselves.
+func keysEqual(a, b Object) bool {
+	switch a := a.(type) {
+	case *Integer, *BigInteger:
+		return NumbersEqual(a, b)
+	case *Float:
+		// NaN is not equal to itself, but it is still the same key
+		if b, ok := b.(*Float); ok && math.Float64bits(a.Value) == math.Float64bits(b.Value) {
+			return true
+		}
+		return NumbersEqual(a, b)
+	case *String:
+		b, ok := b.(*String)
+		return ok && a.Value == b.Value
+	case *Boolean:
+		b, ok := b.(*Boolean)
+		return ok && a.Value == b.Value
+	case *Array:
+		b, ok := b.(*Array)
+		if !ok || len(a.Elements) != len(b.Elements) {
+			return false
+		}
+		for i := range a.Elements {
+			if !keysEqual(a.Elements[i], b.Elements[i]) {
+				return false
+			}
+		}
+		return true
+	}
+	return a == b
+}
+
+// frozenKey returns key as it is stored in a map: arrays are copied, so
+// that changing the array later does not change the key. key must have
+// passed HashOf.
+func frozenKey(key Object) Object {
+	arr, ok := key.(*Array)
+	if !ok {
+		return key
+	}
+	elements := make([]Object, len(arr.Elements))
+	for i, el := range arr.Elements {
+		elements[i] = frozenKey(el)
+	}
+	return &Array{Elements: elements}
+}
+
+// Map represents a map/dictionary. Its entries keep the order in which
+// their keys were first added. The zero Map is empty and ready to use.
 type Map struct {
-	Pairs map[HashKey]MapPair
+	entries []MapPair         // in insertion order; deleted entries have a nil Key
+	index   map[HashKey][]int // positions in entries of the keys with each hash
+	deleted int               // number of deleted entries
+}
+
+// find returns the position in entries of key, or -1
+func (m *Map) find(hash HashKey, key Object) int {
+	for _, i := range m.index[hash] {
+		if keysEqual(m.entries[i].Key, key) {
+			return i
+		}
+	}
+	return -1
+}
+
+// Get returns the value stored under key
+func (m *Map) Get(key Object) (Object, bool) {
+	hash, ok := HashOf(key)
+	if !ok {
+		return nil, false
+	}
+	i := m.find(hash, key)
+	if i < 0 {
+		return nil, false
+	}
+	return m.entries[i].Value, true
+}
+
+// Set stores value under key. It returns an error if key cannot be a map
+// key (see HashOf). A key that is already there keeps its position.
+func (m *Map) Set(key, value Object) *Error {
+	hash, ok := HashOf(key)
+	if !ok {
+		return &Error{Message: fmt.Sprintf("tipe %s tidak bisa digunakan sebagai kunci map", key.Type())}
+	}
+	if i := m.find(hash, key); i >= 0 {
+		m.entries[i].Value = value
+		return nil
+	}
+
+	if m.index == nil {
+		m.index = make(map[HashKey][]int)
+	}
+	m.index[hash] = append(m.index[hash], len(m.entries))
+	m.entries = append(m.entries, MapPair{Key: frozenKey(key), Value: value})
+	return nil
+}
+
+// Delete removes key from the map and reports whether it was there
+func (m *Map) Delete(key Object) bool {
+	hash, ok := HashOf(key)
+	if !ok {
+		return false
+	}
+	i := m.find(hash, key)
+	if i < 0 {
+		return false
+	}
+
+	m.unindex(hash, i)
+	m.entries[i] = MapPair{}
+	m.deleted++
+	// Drop the deleted entries once they make up most of the map
+	if m.deleted > 8 && m.deleted > len(m.entries)/2 {
+		m.entries = m.pairs()
+		m.index = make(map[HashKey][]int, len(m.entries))
+		for j, pair := range m.entries {
+			hash, _ := HashOf(pair.Key)
+			m.index[hash] = append(m.index[hash], j)
+		}
+		m.deleted = 0
+	}
+	return true
+}
+
+// unindex removes position i from the positions of hash
+func (m *Map) unindex(hash HashKey, i int) {
+	positions := m.index[hash]
+	for j, pos := range positions {
+		if pos == i {
+			positions = append(positions[:j], positions[j+1:]...)
+			break
+		}
+	}
+	if len(positions) == 0 {
+		delete(m.index, hash)
+	} else {
+		m.index[hash] = positions
+	}
+}
+
+// Len returns the number of entries
+func (m *Map) Len() int {
+	return len(m.entries) - m.deleted
+}
+
+// Pairs returns the entries in insertion order. Changing the map later does
+// not change the returned slice, and keys that are arrays are copies, so
+// that changing them does not change the map.
+func (m *Map) Pairs() []MapPair {
+	pairs := m.pairs()
+	for i := range pairs {
+		pairs[i].Key = frozenKey(pairs[i].Key)
+	}
+	return pairs
+}
+
+// pairs returns the entries in insertion order, with the keys as they are
+// stored
+func (m *Map) pairs() []MapPair {
+	pairs := make([]MapPair, 0, m.Len())
+	for _, pair := range m.entries {
+		if pair.Key != nil {
+			pairs = append(pairs, pair)
+		}
+	}
+	return pairs
 }
 
 func (m *Map) Type() ObjectType { return MAP_OBJ }
 func (m *Map) Inspect() string {
-	var out bytes.Buffer
-	pairs := []string{}
-	for _, pair := range m.Pairs {
-		pairs = append(pairs, fmt.Sprintf("%s: %s", pair.Key.Inspect(), pair.Value.Inspect()))
+	text, _ := InspectJoin([]Object{m}, "", -1)
+	return text
+}
+
+// Module represents an imported .ssk file; its members are the file's
+// top-level bindings
+type Module struct {
+	Name    string
+	Path    string
+	Env     *Environment
+	Globals *Globals // used instead of Env for modules run by the VM
+}
+
+func (m *Module) Type() ObjectType { return MODULE_OBJ }
+func (m *Module) Inspect() string  { return fmt.Sprintf("modul %s", m.Name) }
+
+// Member returns a top-level binding of the module
+func (m *Module) Member(name string) (Object, bool) {
+	if m.Globals != nil {
+		i, ok := m.Globals.TopLevel[name]
+		if !ok || m.Globals.Values[i] == nil {
+			return nil, false
+		}
+		return m.Globals.Values[i], true
 	}
-	out.WriteString("{")
-	out.WriteString(strings.Join(pairs, ", "))
-	out.WriteString("}")
-	return out.String()
+	return m.Env.GetLocal(name)
+}
+
+// ModuleCache records the modules imported during one run so that each file
+// is evaluated only once
+type ModuleCache struct {
+	Modules map[string]*Module // keyed by absolute path
+	Loading []string           // files currently being evaluated, to detect import cycles
+}
+
+// NewModuleCache creates an empty module cache
+func NewModuleCache() *ModuleCache {
+	return &ModuleCache{Modules: make(map[string]*Module)}
 }
 
 // Environment stores variable bindings
 type Environment struct {
-	store  map[string]Object
-	consts map[string]bool // tracks which variables are constants
-	outer  *Environment
+	store   map[string]Object
+	consts  map[string]bool // tracks which variables are constants
+	outer   *Environment
+	file    string   // source file the code in this scope comes from
+	runtime *Runtime // shared by every scope of one run
 }
 
-// NewEnvironment creates a new environment
+// NewEnvironment creates a new environment for a run using the process's
+// standard streams
 func NewEnvironment() *Environment {
+	return NewEnvironmentWithRuntime(NewRuntime())
+}
+
+// NewEnvironmentWithRuntime creates the global environment of a run that
+// uses rt
+func NewEnvironmentWithRuntime(rt *Runtime) *Environment {
 	return &Environment{
-		store:  make(map[string]Object),
-		consts: make(map[string]bool),
-		outer:  nil,
+		store:   make(map[string]Object),
+		consts:  make(map[string]bool),
+		runtime: rt,
 	}
 }
 
 // NewEnclosedEnvironment creates a new environment with an outer scope
 func NewEnclosedEnvironment(outer *Environment) *Environment {
-	env := NewEnvironment()
+	env := NewEnvironmentWithRuntime(outer.runtime)
 	env.outer = outer
+	env.file = outer.file
+	return env
+}
+
+// NewModuleEnvironment creates the top-level scope of an imported file. It
+// does not see the importer's bindings but shares its runtime.
+func NewModuleEnvironment(importer *Environment, file string) *Environment {
+	env := NewEnvironmentWithRuntime(importer.runtime)
+	env.file = file
 	return env
 }
 
+// File returns the path of the source file this scope belongs to, if known
+func (e *Environment) File() string {
+	return e.file
+}
+
+// SetFile records the source file this scope belongs to; imports are
+// resolved relative to it
+func (e *Environment) SetFile(file string) {
+	e.file = file
+}
+
+// Modules returns the module cache of the current run
+func (e *Environment) Modules() *ModuleCache {
+	return e.runtime.Modules
+}
+
+// Runtime returns the runtime of the current run
+func (e *Environment) Runtime() *Runtime {
+	return e.runtime
+}
+
 // Get retrieves a variable from the environment
 func (e *Environment) Get(name string) (Object, bool) {
 	obj, ok := e.store[name]
@@ -241,6 +744,22 @@ func (e *Environment) Get(name string) (Object, bool) {
 	return obj, ok
 }
 
+// GetLocal retrieves a variable from this scope only, ignoring outer scopes
+func (e *Environment) GetLocal(name string) (Object, bool) {
+	obj, ok := e.store[name]
+	return obj, ok
+}
+
+// Names returns the sorted names bound in this scope only
+func (e *Environment) Names() []string {
+	names := make([]string, 0, len(e.store))
+	for name := range e.store {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
 // Set sets a variable in the environment
 func (e *Environment) Set(name string, val Object) Object {
 	e.store[name] = val
@@ -254,10 +773,11 @@ func (e *Environment) SetConst(name string, val Object) Object {
 	return val
 }
 
-// IsConst checks if a variable is a constant
+// IsConst checks if a variable is a constant. Only the innermost scope
+// binding name decides, so a local variable may hide an outer constant.
 func (e *Environment) IsConst(name string) bool {
-	if isConst, ok := e.consts[name]; ok && isConst {
-		return true
+	if _, ok := e.store[name]; ok {
+		return e.consts[name]
 	}
 	if e.outer != nil {
 		return e.outer.IsConst(name)
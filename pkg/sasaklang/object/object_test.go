@@ -0,0 +1,123 @@
+package object
+
+import (
+	"fmt"
+	"math"
+	"testing"
+)
+
+// collidingKey is a key whose hash is the same for every value
+type collidingKey struct {
+	name string
+}
+
+func (k *collidingKey) Type() ObjectType { return "COLLIDING" }
+func (k *collidingKey) Inspect() string  { return k.name }
+func (k *collidingKey) HashKey() HashKey { return HashKey{Type: "COLLIDING", Value: 1} }
+
+func TestMapHashCollisions(t *testing.T) {
+	a, b, c := &collidingKey{"a"}, &collidingKey{"b"}, &collidingKey{"c"}
+
+	m := &Map{}
+	m.Set(a, &Integer{Value: 1})
+	m.Set(b, &Integer{Value: 2})
+	if m.Len() != 2 {
+		t.Fatalf("colliding keys overwrote each other: %s", m.Inspect())
+	}
+	if val, ok := m.Get(a); !ok || val.(*Integer).Value != 1 {
+		t.Errorf("wrong value for a: %v, %v", val, ok)
+	}
+	if val, ok := m.Get(b); !ok || val.(*Integer).Value != 2 {
+		t.Errorf("wrong value for b: %v, %v", val, ok)
+	}
+	if _, ok := m.Get(c); ok {
+		t.Errorf("found c, which was never set")
+	}
+
+	if !m.Delete(a) {
+		t.Fatalf("a was not deleted")
+	}
+	if val, ok := m.Get(b); !ok || val.(*Integer).Value != 2 {
+		t.Errorf("deleting a lost b: %v, %v", val, ok)
+	}
+	m.Set(c, &Integer{Value: 3})
+	if got := m.Inspect(); got != "{b: 2, c: 3}" {
+		t.Errorf("wrong map. expected={b: 2, c: 3}, got=%s", got)
+	}
+}
+
+func TestMapArrayKeys(t *testing.T) {
+	key := &Array{Elements: []Object{&Integer{Value: 1}, &String{Value: "a"}}}
+
+	m := &Map{}
+	m.Set(key, TRUE)
+	// An equal array finds the entry
+	same := &Array{Elements: []Object{&Integer{Value: 1}, &String{Value: "a"}}}
+	if _, ok := m.Get(same); !ok {
+		t.Errorf("equal array key not found")
+	}
+	// Changing the array after it was used as a key does not change the key
+	key.Elements[0] = &Integer{Value: 2}
+	if _, ok := m.Get(same); !ok {
+		t.Errorf("key changed with the array it was made from")
+	}
+	if _, ok := m.Get(key); ok {
+		t.Errorf("found the changed array")
+	}
+
+	for _, key := range []Object{
+		&Array{Elements: []Object{&Map{}}},
+		&Array{Elements: []Object{&Array{Elements: []Object{NULL}}}},
+	} {
+		if _, ok := HashOf(key); ok {
+			t.Errorf("%s can be a key", key.Inspect())
+		}
+	}
+	// An array that contains itself cannot be a key; one that contains
+	// another array twice can
+	inner := &Array{Elements: []Object{&Integer{Value: 1}}}
+	if _, ok := HashOf(&Array{Elements: []Object{inner, inner}}); !ok {
+		t.Errorf("an array with a repeated element cannot be a key")
+	}
+	cyclic := &Array{Elements: []Object{NULL}}
+	cyclic.Elements[0] = &Array{Elements: []Object{cyclic}}
+	if _, ok := HashOf(cyclic); ok {
+		t.Errorf("an array that contains itself can be a key")
+	}
+	if err := m.Set(cyclic, TRUE); err == nil || err.Message != "tipe ARRAY tidak bisa digunakan sebagai kunci map" {
+		t.Errorf("wrong error setting an array that contains itself: %v", err)
+	}
+	// Numbers are the same key when they are equal
+	m.Set(&Array{Elements: []Object{&Integer{Value: 1}}}, TRUE)
+	if _, ok := m.Get(&Array{Elements: []Object{&Float{Value: 1}}}); !ok {
+		t.Errorf("[1.0] did not find the key [1]")
+	}
+	m.Set(&Float{Value: math.NaN()}, TRUE)
+	if _, ok := m.Get(&Float{Value: math.NaN()}); !ok {
+		t.Errorf("NaN did not find the key NaN")
+	}
+}
+
+func TestRuntimeSeed(t *testing.T) {
+	draw := func(rt *Runtime) []int64 {
+		numbers := make([]int64, 5)
+		for i := range numbers {
+			numbers[i] = rt.Rand().Int63n(1000)
+		}
+		return numbers
+	}
+
+	a, b := NewRuntime(), NewRuntime()
+	a.SetSeed(42)
+	b.SetSeed(42)
+	first := draw(a)
+	if second := draw(b); fmt.Sprint(first) != fmt.Sprint(second) {
+		t.Errorf("same seed gave different numbers: %v and %v", first, second)
+	}
+
+	// Seeding again starts the numbers over
+	a.SetSeed(42)
+	if again := draw(a); fmt.Sprint(first) != fmt.Sprint(again) {
+		t.Errorf("reseeding gave different numbers: %v and %v", first, again)
+	}
+}
@@ -0,0 +1,263 @@
+package object
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"math/rand"
+	"os"
+	"strings"
+	"time"
+)
+
+// Runtime is the state shared by all code of one run, including imported
+// modules: the module cache and the streams builtins read from and write to
+type Runtime struct {
+	Modules *ModuleCache
+
+	Stdin  io.Reader
+	Stdout io.Writer
+
+	// DisableImports makes impor fail, for hosts that must keep programs
+	// away from the file system
+	DisableImports bool
+
+	// Limits of the run. The run stops when Context is done or after
+	// MaxSteps steps, where a step is one loop iteration or one function
+	// call; everything else takes time bounded by the length of the code.
+	// A zero MaxSteps means no budget. Calls nest at most MaxDepth deep,
+	// DefaultMaxDepth if it is zero.
+	Context  context.Context
+	MaxSteps int64
+	MaxDepth int
+
+	// MaxMemory limits the bytes of strings, arrays, maps and big integers
+	// the program creates over the whole run, whether or not they are still
+	// in use; zero means no limit. Sizes are estimates, see StringSize and
+	// friends.
+	MaxMemory int64
+
+	// Random is the source of the random numbers of acak() and the other
+	// random builtins; a nil Random is seeded from the clock on first use.
+	// Each run needs its own, so that benih() in one run does not change
+	// the numbers of another.
+	Random *rand.Rand
+
+	stdin    *bufio.Reader // wraps Stdin so that input read ahead is not lost
+	steps    int64
+	depth    int
+	canceled *Error
+	memory   int64
+	caller   Caller
+}
+
+// Caller runs user functions on behalf of builtins. The engine running the
+// program installs itself as the caller of each builtin it calls.
+type Caller interface {
+	CallFunction(fn Object, args []Object) Object
+}
+
+// DefaultMaxDepth is the call depth limit of a runtime without MaxDepth
+const DefaultMaxDepth = 10000
+
+// Estimated sizes of values, in bytes, for memory accounting
+const (
+	valueHeaderSize = 24 // a value's own struct, pointed to by an Object
+	elementSize     = 16 // an Object in an array
+	MapPairSize     = 64 // an entry of a map: hash key, key and value
+)
+
+// StringSize is the estimated size of a string of n bytes
+func StringSize(n int) int64 {
+	return valueHeaderSize + int64(n)
+}
+
+// ArraySize is the estimated size of an array of n elements
+func ArraySize(n int) int64 {
+	return valueHeaderSize + int64(n)*elementSize
+}
+
+// MapSize is the estimated size of a map of n entries
+func MapSize(n int) int64 {
+	return valueHeaderSize + int64(n)*MapPairSize
+}
+
+// BigIntegerSize is the estimated size of an integer of n bits
+func BigIntegerSize(bits int) int64 {
+	return valueHeaderSize + int64(bits+7)/8
+}
+
+// How often Step checks the context, in steps
+const contextCheckInterval = 256
+
+// NewRuntime creates a runtime using the process's standard streams
+func NewRuntime() *Runtime {
+	return &Runtime{
+		Modules: NewModuleCache(),
+		Stdin:   os.Stdin,
+		Stdout:  os.Stdout,
+	}
+}
+
+// Rand returns Random, creating it first if needed
+func (rt *Runtime) Rand() *rand.Rand {
+	if rt.Random == nil {
+		rt.Random = rand.New(rand.NewSource(time.Now().UnixNano()))
+	}
+	return rt.Random
+}
+
+// SetSeed restarts the run's random numbers from seed; the same seed gives
+// the same numbers
+func (rt *Runtime) SetSeed(seed int64) {
+	rt.Random = rand.New(rand.NewSource(seed))
+}
+
+// ReadLine reads a line from Stdin, without the line ending
+func (rt *Runtime) ReadLine() (string, error) {
+	if rt.stdin == nil {
+		rt.stdin = bufio.NewReader(rt.Stdin)
+	}
+
+	line, err := rt.stdin.ReadString('\n')
+	if err != nil && (err != io.EOF || line == "") {
+		return "", err
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+// Step counts one step of the program. It returns an error once the step
+// budget is used up or the context is done; the error is raised again on
+// every later step, so a tangkep block cannot keep the program going.
+func (rt *Runtime) Step() *Error {
+	rt.steps++
+	if rt.MaxSteps > 0 && rt.steps > rt.MaxSteps {
+		return &Error{Message: fmt.Sprintf("batas langkah terlampaui: lebih dari %d langkah", rt.MaxSteps), Kind: STEP_LIMIT_ERR}
+	}
+
+	if rt.canceled == nil && rt.Context != nil && rt.steps%contextCheckInterval == 0 {
+		if err := rt.Context.Err(); err != nil {
+			rt.cancel(err)
+		}
+	}
+	if rt.canceled != nil {
+		errObj := *rt.canceled
+		return &errObj
+	}
+	return nil
+}
+
+// Sleep waits for d to pass. It returns early, with the error of Step, when
+// the context is done.
+func (rt *Runtime) Sleep(d time.Duration) *Error {
+	if rt.Context == nil {
+		time.Sleep(d)
+		return nil
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-rt.Context.Done():
+		if rt.canceled == nil {
+			rt.cancel(rt.Context.Err())
+		}
+		errObj := *rt.canceled
+		return &errObj
+	}
+}
+
+// cancel makes every later step fail because of err
+func (rt *Runtime) cancel(err error) {
+	rt.canceled = &Error{Message: fmt.Sprintf("eksekusi dibatalkan: %s", err), Kind: CANCELED_ERR}
+}
+
+// CallDepth returns the maximum depth of nested function calls
+func (rt *Runtime) CallDepth() int {
+	if rt.MaxDepth > 0 {
+		return rt.MaxDepth
+	}
+	return DefaultMaxDepth
+}
+
+// DepthError is the error raised by a call beyond the depth limit
+func DepthError() *Error {
+	return &Error{Message: "stack overflow: rekursi terlalu dalam", Kind: DEPTH_LIMIT_ERR}
+}
+
+// EnterCall counts a function call that is about to start, failing when it
+// would go beyond the depth limit or the step budget. Each successful
+// EnterCall must be matched by a LeaveCall.
+func (rt *Runtime) EnterCall() *Error {
+	if rt.depth >= rt.CallDepth() {
+		return DepthError()
+	}
+	if err := rt.Step(); err != nil {
+		return err
+	}
+	rt.depth++
+	return nil
+}
+
+// LeaveCall counts a function call that has returned
+func (rt *Runtime) LeaveCall() {
+	rt.depth--
+}
+
+// SetCaller makes c the caller used by Call and returns the previous one,
+// which the engine restores once the builtin has returned
+func (rt *Runtime) SetCaller(c Caller) Caller {
+	prev := rt.caller
+	rt.caller = c
+	return prev
+}
+
+// Call calls fn, a function of the program or a builtin, with args. It lets
+// builtins take functions as arguments. Errors raised by fn are returned as
+// the result, with the call recorded in their call stack.
+func (rt *Runtime) Call(fn Object, args ...Object) Object {
+	if rt.caller != nil {
+		return rt.caller.CallFunction(fn, args)
+	}
+	if builtin, ok := fn.(*Builtin); ok {
+		return builtin.Fn(rt, args...)
+	}
+	return &Error{Message: fmt.Sprintf("bukan fungsi: %s", fn.Type())}
+}
+
+// Allocate counts size bytes of new strings, arrays or maps, failing when
+// they would take the run beyond MaxMemory. Values are only created after
+// their memory has been allocated.
+func (rt *Runtime) Allocate(size int64) *Error {
+	if rt.MaxMemory > 0 && rt.memory+size > rt.MaxMemory {
+		return rt.memoryLimitError()
+	}
+	rt.memory += size
+	return nil
+}
+
+// AllocateText returns the text of values as Inspect prints them, joined by
+// sep, after allocating a string for it. The text is not built past the
+// memory still available, so values that print as a huge text, such as an
+// array holding another many times over, fail before using that memory.
+func (rt *Runtime) AllocateText(values []Object, sep string) (string, *Error) {
+	limit := int64(-1)
+	if rt.MaxMemory > 0 {
+		limit = max(rt.MaxMemory-rt.memory-StringSize(0), 0)
+	}
+	text, ok := InspectJoin(values, sep, limit)
+	if !ok {
+		return "", rt.memoryLimitError()
+	}
+	if errObj := rt.Allocate(StringSize(len(text))); errObj != nil {
+		return "", errObj
+	}
+	return text, nil
+}
+
+func (rt *Runtime) memoryLimitError() *Error {
+	return &Error{Message: fmt.Sprintf("batas memori terlampaui: lebih dari %d byte", rt.MaxMemory), Kind: MEMORY_LIMIT_ERR}
+}
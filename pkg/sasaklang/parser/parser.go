@@ -2,9 +2,13 @@ package parser
 
 import (
 	"fmt"
+	"math/big"
+	"sort"
 	"strconv"
+	"strings"
 
 	"github.com/arjunaayasa/sasaklang/pkg/sasaklang/ast"
+	"github.com/arjunaayasa/sasaklang/pkg/sasaklang/errors"
 	"github.com/arjunaayasa/sasaklang/pkg/sasaklang/lexer"
 	"github.com/arjunaayasa/sasaklang/pkg/sasaklang/token"
 )
@@ -21,27 +25,36 @@ const (
 	SUM         // + -
 	PRODUCT     // * / %
 	PREFIX      // -X !X
+	POSTFIX     // X++ X--
 	CALL        // myFunction(X)
 	INDEX       // array[index]
 )
 
 var precedences = map[token.TokenType]int{
-	token.ASSIGN:   ASSIGN_PREC,
-	token.OR:       OR_PREC,
-	token.AND:      AND_PREC,
-	token.EQ:       EQUALS,
-	token.NEQ:      EQUALS,
-	token.LT:       LESSGREATER,
-	token.GT:       LESSGREATER,
-	token.LTE:      LESSGREATER,
-	token.GTE:      LESSGREATER,
-	token.PLUS:     SUM,
-	token.MINUS:    SUM,
-	token.SLASH:    PRODUCT,
-	token.ASTERISK: PRODUCT,
-	token.MODULO:   PRODUCT,
-	token.LPAREN:   CALL,
-	token.LBRACKET: INDEX,
+	token.ASSIGN:          ASSIGN_PREC,
+	token.PLUS_ASSIGN:     ASSIGN_PREC,
+	token.MINUS_ASSIGN:    ASSIGN_PREC,
+	token.ASTERISK_ASSIGN: ASSIGN_PREC,
+	token.SLASH_ASSIGN:    ASSIGN_PREC,
+	token.MODULO_ASSIGN:   ASSIGN_PREC,
+	token.OR:              OR_PREC,
+	token.AND:             AND_PREC,
+	token.EQ:              EQUALS,
+	token.NEQ:             EQUALS,
+	token.LT:              LESSGREATER,
+	token.GT:              LESSGREATER,
+	token.LTE:             LESSGREATER,
+	token.GTE:             LESSGREATER,
+	token.PLUS:            SUM,
+	token.MINUS:           SUM,
+	token.SLASH:           PRODUCT,
+	token.ASTERISK:        PRODUCT,
+	token.MODULO:          PRODUCT,
+	token.INCREMENT:       POSTFIX,
+	token.DECREMENT:       POSTFIX,
+	token.LPAREN:          CALL,
+	token.LBRACKET:        INDEX,
+	token.DOT:             INDEX,
 }
 
 type (
@@ -52,11 +65,20 @@ type (
 // Parser parses tokens into an AST
 type Parser struct {
 	l      *lexer.Lexer
-	errors []string
+	errors []*errors.ParseError
 
 	curToken  token.Token
 	peekToken token.Token
 
+	// depth counts the braces opened up to and including curToken, and
+	// parens the parentheses and brackets opened on the current line; error
+	// recovery uses them to find the end of a statement
+	depth  int
+	parens int
+	// recovering is set after an error until the parser has skipped to the
+	// next statement, so one mistake is reported only once
+	recovering bool
+
 	prefixParseFns map[token.TokenType]prefixParseFn
 	infixParseFns  map[token.TokenType]infixParseFn
 }
@@ -65,13 +87,15 @@ type Parser struct {
 func New(l *lexer.Lexer) *Parser {
 	p := &Parser{
 		l:      l,
-		errors: []string{},
+		errors: []*errors.ParseError{},
 	}
 
 	p.prefixParseFns = make(map[token.TokenType]prefixParseFn)
 	p.registerPrefix(token.IDENT, p.parseIdentifier)
 	p.registerPrefix(token.INT, p.parseIntegerLiteral)
+	p.registerPrefix(token.FLOAT, p.parseFloatLiteral)
 	p.registerPrefix(token.STRING, p.parseStringLiteral)
+	p.registerPrefix(token.TEMPLATE_START, p.parseTemplateLiteral)
 	p.registerPrefix(token.BENER, p.parseBoolean)
 	p.registerPrefix(token.SALAH, p.parseBoolean)
 	p.registerPrefix(token.KOSONG, p.parseNullLiteral)
@@ -99,7 +123,15 @@ func New(l *lexer.Lexer) *Parser {
 	p.registerInfix(token.OR, p.parseInfixExpression)
 	p.registerInfix(token.LPAREN, p.parseCallExpression)
 	p.registerInfix(token.LBRACKET, p.parseIndexExpression)
+	p.registerInfix(token.DOT, p.parseMemberExpression)
 	p.registerInfix(token.ASSIGN, p.parseAssignmentExpression)
+	p.registerInfix(token.PLUS_ASSIGN, p.parseAssignmentExpression)
+	p.registerInfix(token.MINUS_ASSIGN, p.parseAssignmentExpression)
+	p.registerInfix(token.ASTERISK_ASSIGN, p.parseAssignmentExpression)
+	p.registerInfix(token.SLASH_ASSIGN, p.parseAssignmentExpression)
+	p.registerInfix(token.MODULO_ASSIGN, p.parseAssignmentExpression)
+	p.registerInfix(token.INCREMENT, p.parsePostfixExpression)
+	p.registerInfix(token.DECREMENT, p.parsePostfixExpression)
 
 	// Read two tokens to initialize curToken and peekToken
 	p.nextToken()
@@ -119,6 +151,27 @@ func (p *Parser) registerInfix(tokenType token.TokenType, fn infixParseFn) {
 func (p *Parser) nextToken() {
 	p.curToken = p.peekToken
 	p.peekToken = p.l.NextToken()
+
+	switch p.curToken.Type {
+	case token.LBRACE:
+		p.depth++
+	case token.RBRACE:
+		p.depth--
+	case token.LPAREN, token.LBRACKET:
+		p.parens++
+	case token.RPAREN, token.RBRACKET:
+		p.parens--
+	case token.NEWLINE:
+		p.parens = 0
+	}
+}
+
+// skipNewlines moves past the newlines ahead, for places where an
+// expression may continue on the next line
+func (p *Parser) skipNewlines() {
+	for p.peekTokenIs(token.NEWLINE) {
+		p.nextToken()
+	}
 }
 
 func (p *Parser) curTokenIs(t token.TokenType) bool {
@@ -138,22 +191,104 @@ func (p *Parser) expectPeek(t token.TokenType) bool {
 	return false
 }
 
-func (p *Parser) peekError(t token.TokenType) {
-	msg := fmt.Sprintf("baris %d, kolom %d: diharapkan %s, dapat %s",
-		p.peekToken.Line, p.peekToken.Column, t, p.peekToken.Type)
-	p.errors = append(p.errors, msg)
+func (p *Parser) peekError(expected ...token.TokenType) {
+	names := make([]string, len(expected))
+	for i, t := range expected {
+		names[i] = describeType(t)
+	}
+	msg := fmt.Sprintf("diharapkan %s, dapat %s", strings.Join(names, " atau "), describeType(p.peekToken.Type))
+	p.addError(p.peekToken, msg, expected)
 }
 
 func (p *Parser) noPrefixParseFnError(t token.TokenType) {
-	msg := fmt.Sprintf("baris %d, kolom %d: tidak ada prefix parse function untuk %s",
-		p.curToken.Line, p.curToken.Column, t)
-	p.errors = append(p.errors, msg)
+	expected := make([]token.TokenType, 0, len(p.prefixParseFns))
+	for tt := range p.prefixParseFns {
+		expected = append(expected, tt)
+	}
+	sort.Slice(expected, func(i, j int) bool { return expected[i] < expected[j] })
+
+	p.addError(p.curToken, fmt.Sprintf("diharapkan ekspresi, dapat %s", describeType(t)), expected)
+}
+
+// addError records an error at tok. Errors are dropped while the parser is
+// recovering from an earlier one in the same statement.
+func (p *Parser) addError(tok token.Token, msg string, expected []token.TokenType) {
+	if p.recovering {
+		return
+	}
+	p.recovering = true
+
+	text := tok.Literal
+	switch tok.Type {
+	case token.EOF:
+		text = ""
+	case token.NEWLINE:
+		text = `\n`
+	case token.TEMPLATE_MIDDLE, token.TEMPLATE_END:
+		text = "}"
+	}
+
+	err := errors.NewParseError(msg, tok.Line, tok.Column, text)
+	err.Expected = expected
+	p.errors = append(p.errors, err)
+}
+
+// describeType names a token type for error messages
+func describeType(t token.TokenType) string {
+	switch t {
+	case token.IDENT:
+		return "nama"
+	case token.INT, token.FLOAT:
+		return "angka"
+	case token.STRING, token.TEMPLATE_START:
+		return "teks"
+	case token.TEMPLATE_MIDDLE, token.TEMPLATE_END:
+		return "'}'"
+	case token.NEWLINE:
+		return "baris baru"
+	case token.EOF:
+		return "akhir file"
+	}
+	if keyword, ok := token.Keyword(t); ok {
+		return keyword
+	}
+	return "'" + string(t) + "'"
 }
 
+// Errors returns the parse errors as messages
 func (p *Parser) Errors() []string {
+	msgs := make([]string, len(p.errors))
+	for i, err := range p.errors {
+		msgs[i] = err.Error()
+	}
+	return msgs
+}
+
+// ParseErrors returns the parse errors with their positions and the tokens
+// that were expected
+func (p *Parser) ParseErrors() []*errors.ParseError {
 	return p.errors
 }
 
+// synchronize skips the rest of a statement that failed to parse, which
+// started at brace depth start and paren depth parens. It stops at the
+// beginning of the next statement, or at the brace closing the enclosing
+// block.
+func (p *Parser) synchronize(start, parens int) {
+	p.recovering = false
+
+	for !p.curTokenIs(token.EOF) {
+		if p.depth < start {
+			return
+		}
+		if p.depth == start && (p.curTokenIs(token.NEWLINE) || p.curTokenIs(token.SEMICOLON) && p.parens <= parens) {
+			p.nextToken()
+			return
+		}
+		p.nextToken()
+	}
+}
+
 func (p *Parser) peekPrecedence() int {
 	if p, ok := precedences[p.peekToken.Type]; ok {
 		return p
@@ -181,16 +316,41 @@ func (p *Parser) ParseProgram() *ast.Program {
 		if p.curTokenIs(token.EOF) {
 			break
 		}
-		stmt := p.parseStatement()
-		if stmt != nil {
-			program.Statements = append(program.Statements, stmt)
+		if stmt, ok := p.parseStatementOrRecover(); ok {
+			if stmt != nil {
+				program.Statements = append(program.Statements, stmt)
+			}
+			p.nextToken()
 		}
-		p.nextToken()
+	}
+
+	// Errors in string literals come from the lexer, which runs ahead of
+	// the parser, so both are put in source order
+	if lexErrors := p.l.Errors(); len(lexErrors) > 0 {
+		p.errors = append(p.errors, lexErrors...)
+		sort.SliceStable(p.errors, func(i, j int) bool {
+			a, b := p.errors[i], p.errors[j]
+			return a.Line < b.Line || a.Line == b.Line && a.Column < b.Column
+		})
 	}
 
 	return program
 }
 
+// parseStatementOrRecover parses a statement. When it fails, the parser
+// skips to the start of the next statement and ok is false.
+func (p *Parser) parseStatementOrRecover() (stmt ast.Statement, ok bool) {
+	start, parens := p.depth, p.parens
+
+	stmt = p.parseStatement()
+	if !p.recovering {
+		return stmt, true
+	}
+
+	p.synchronize(start, parens)
+	return nil, false
+}
+
 func (p *Parser) parseStatement() ast.Statement {
 	switch p.curToken.Type {
 	case token.GAWE:
@@ -207,6 +367,12 @@ func (p *Parser) parseStatement() ast.Statement {
 		return p.parseWhileStatement()
 	case token.KANGGO:
 		return p.parseForStatement()
+	case token.COBA:
+		return p.parseTryStatement()
+	case token.LEMPAR:
+		return p.parseThrowStatement()
+	case token.IMPOR:
+		return p.parseImportStatement()
 	default:
 		return p.parseExpressionStatement()
 	}
@@ -225,6 +391,7 @@ func (p *Parser) parseLetStatement() *ast.LetStatement {
 		return nil
 	}
 
+	p.skipNewlines()
 	p.nextToken()
 	stmt.Value = p.parseExpression(LOWEST)
 
@@ -248,6 +415,7 @@ func (p *Parser) parseConstStatement() *ast.ConstStatement {
 		return nil
 	}
 
+	p.skipNewlines()
 	p.nextToken()
 	stmt.Value = p.parseExpression(LOWEST)
 
@@ -317,7 +485,7 @@ func (p *Parser) parseWhileStatement() *ast.WhileStatement {
 	return stmt
 }
 
-func (p *Parser) parseForStatement() *ast.ForStatement {
+func (p *Parser) parseForStatement() ast.Statement {
 	stmt := &ast.ForStatement{Token: p.curToken}
 
 	if !p.expectPeek(token.LPAREN) {
@@ -326,6 +494,11 @@ func (p *Parser) parseForStatement() *ast.ForStatement {
 
 	p.nextToken()
 
+	// ojok (x dalem xs) and ojok (i, x dalem xs) go through a value
+	if p.curTokenIs(token.IDENT) && (p.peekTokenIs(token.DALEM) || p.peekTokenIs(token.COMMA)) {
+		return p.parseForInStatement(stmt.Token)
+	}
+
 	// Parse init statement
 	if !p.curTokenIs(token.SEMICOLON) {
 		stmt.Init = p.parseStatement()
@@ -366,6 +539,125 @@ func (p *Parser) parseForStatement() *ast.ForStatement {
 	return stmt
 }
 
+// parseForInStatement parses an ojok ... dalem loop from its first variable
+func (p *Parser) parseForInStatement(tok token.Token) *ast.ForInStatement {
+	stmt := &ast.ForInStatement{Token: tok}
+	stmt.Variables = append(stmt.Variables, &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal})
+
+	if p.peekTokenIs(token.COMMA) {
+		p.nextToken()
+		if !p.expectPeek(token.IDENT) {
+			return nil
+		}
+		stmt.Variables = append(stmt.Variables, &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal})
+	}
+
+	if !p.expectPeek(token.DALEM) {
+		return nil
+	}
+
+	p.nextToken()
+	stmt.Iterable = p.parseExpression(LOWEST)
+
+	if !p.expectPeek(token.RPAREN) {
+		return nil
+	}
+
+	if !p.expectPeek(token.LBRACE) {
+		return nil
+	}
+
+	stmt.Body = p.parseBlockStatement()
+
+	return stmt
+}
+
+func (p *Parser) parseTryStatement() *ast.TryStatement {
+	stmt := &ast.TryStatement{Token: p.curToken}
+
+	if !p.expectPeek(token.LBRACE) {
+		return nil
+	}
+
+	stmt.Block = p.parseBlockStatement()
+
+	if p.peekTokenIs(token.TANGKEP) {
+		p.nextToken()
+
+		// The error binding is optional: tangkep { ... }
+		if p.peekTokenIs(token.LPAREN) {
+			p.nextToken()
+			if !p.expectPeek(token.IDENT) {
+				return nil
+			}
+			stmt.CatchParam = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+			if !p.expectPeek(token.RPAREN) {
+				return nil
+			}
+		}
+
+		if !p.expectPeek(token.LBRACE) {
+			return nil
+		}
+
+		stmt.Catch = p.parseBlockStatement()
+	}
+
+	if p.peekTokenIs(token.AKHIRNE) {
+		p.nextToken()
+
+		if !p.expectPeek(token.LBRACE) {
+			return nil
+		}
+
+		stmt.Finally = p.parseBlockStatement()
+	}
+
+	if stmt.Catch == nil && stmt.Finally == nil {
+		p.addError(stmt.Token, "coba butuh tangkep atau akhirne", []token.TokenType{token.TANGKEP, token.AKHIRNE})
+		return nil
+	}
+
+	return stmt
+}
+
+func (p *Parser) parseThrowStatement() *ast.ThrowStatement {
+	stmt := &ast.ThrowStatement{Token: p.curToken}
+
+	p.nextToken()
+	stmt.Value = p.parseExpression(LOWEST)
+
+	if p.peekTokenIs(token.SEMICOLON) {
+		p.nextToken()
+	}
+
+	return stmt
+}
+
+func (p *Parser) parseImportStatement() *ast.ImportStatement {
+	stmt := &ast.ImportStatement{Token: p.curToken}
+
+	if !p.expectPeek(token.STRING) {
+		return nil
+	}
+
+	stmt.Path = p.curToken.Literal
+
+	if p.peekTokenIs(token.SEBAGAI) {
+		p.nextToken()
+		if !p.expectPeek(token.IDENT) {
+			return nil
+		}
+		stmt.Alias = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+	}
+
+	if p.peekTokenIs(token.SEMICOLON) {
+		p.nextToken()
+	}
+
+	return stmt
+}
+
 func (p *Parser) parseExpressionStatement() *ast.ExpressionStatement {
 	stmt := &ast.ExpressionStatement{Token: p.curToken}
 	stmt.Expression = p.parseExpression(LOWEST)
@@ -405,10 +697,27 @@ func (p *Parser) parseIntegerLiteral() ast.Expression {
 	lit := &ast.IntegerLiteral{Token: p.curToken}
 
 	value, err := strconv.ParseInt(p.curToken.Literal, 0, 64)
+	if err == nil {
+		lit.Value = value
+		return lit
+	}
+
+	// Literals beyond the range of int64 are big integers
+	n, ok := new(big.Int).SetString(p.curToken.Literal, 0)
+	if !ok {
+		p.addError(p.curToken, fmt.Sprintf("tidak bisa parse %q sebagai integer", p.curToken.Literal), nil)
+		return nil
+	}
+	lit.Big = n
+	return lit
+}
+
+func (p *Parser) parseFloatLiteral() ast.Expression {
+	lit := &ast.FloatLiteral{Token: p.curToken}
+
+	value, err := strconv.ParseFloat(p.curToken.Literal, 64)
 	if err != nil {
-		msg := fmt.Sprintf("baris %d: tidak bisa parse %q sebagai integer",
-			p.curToken.Line, p.curToken.Literal)
-		p.errors = append(p.errors, msg)
+		p.addError(p.curToken, fmt.Sprintf("tidak bisa parse %q sebagai pecahan", p.curToken.Literal), nil)
 		return nil
 	}
 
@@ -420,6 +729,32 @@ func (p *Parser) parseStringLiteral() ast.Expression {
 	return &ast.StringLiteral{Token: p.curToken, Value: p.curToken.Literal}
 }
 
+// parseTemplateLiteral parses an interpolated string, from its
+// TEMPLATE_START token to its TEMPLATE_END token
+func (p *Parser) parseTemplateLiteral() ast.Expression {
+	template := &ast.TemplateLiteral{Token: p.curToken}
+
+	for {
+		if p.curToken.Literal != "" {
+			template.Parts = append(template.Parts, &ast.StringLiteral{Token: p.curToken, Value: p.curToken.Literal})
+		}
+		if p.curTokenIs(token.TEMPLATE_END) {
+			return template
+		}
+
+		p.skipNewlines()
+		p.nextToken()
+		template.Parts = append(template.Parts, p.parseExpression(LOWEST))
+		p.skipNewlines()
+
+		if !p.peekTokenIs(token.TEMPLATE_MIDDLE) && !p.peekTokenIs(token.TEMPLATE_END) {
+			p.peekError(token.TEMPLATE_END)
+			return nil
+		}
+		p.nextToken()
+	}
+}
+
 func (p *Parser) parseBoolean() ast.Expression {
 	return &ast.Boolean{Token: p.curToken, Value: p.curTokenIs(token.BENER)}
 }
@@ -448,6 +783,7 @@ func (p *Parser) parseInfixExpression(left ast.Expression) ast.Expression {
 	}
 
 	precedence := p.curPrecedence()
+	p.skipNewlines()
 	p.nextToken()
 	expression.Right = p.parseExpression(precedence)
 
@@ -455,28 +791,52 @@ func (p *Parser) parseInfixExpression(left ast.Expression) ast.Expression {
 }
 
 func (p *Parser) parseAssignmentExpression(left ast.Expression) ast.Expression {
-	ident, ok := left.(*ast.Identifier)
-	if !ok {
-		msg := fmt.Sprintf("baris %d: tidak bisa assign ke %T", p.curToken.Line, left)
-		p.errors = append(p.errors, msg)
+	if !p.checkAssignable(left) {
 		return nil
 	}
 
 	expression := &ast.AssignmentExpression{
-		Token: p.curToken,
-		Name:  ident,
+		Token:    p.curToken,
+		Target:   left,
+		Operator: strings.TrimSuffix(p.curToken.Literal, "="),
 	}
 
+	p.skipNewlines()
 	p.nextToken()
 	expression.Value = p.parseExpression(LOWEST)
 
 	return expression
 }
 
+func (p *Parser) parsePostfixExpression(left ast.Expression) ast.Expression {
+	if !p.checkAssignable(left) {
+		return nil
+	}
+
+	return &ast.PostfixExpression{
+		Token:    p.curToken,
+		Target:   left,
+		Operator: p.curToken.Literal,
+	}
+}
+
+// checkAssignable reports whether left can appear on the left of an assignment
+func (p *Parser) checkAssignable(left ast.Expression) bool {
+	switch left.(type) {
+	case *ast.Identifier, *ast.IndexExpression, *ast.MemberExpression:
+		return true
+	default:
+		p.addError(p.curToken, fmt.Sprintf("tidak bisa assign ke %T", left), nil)
+		return false
+	}
+}
+
 func (p *Parser) parseGroupedExpression() ast.Expression {
+	p.skipNewlines()
 	p.nextToken()
 	exp := p.parseExpression(LOWEST)
 
+	p.skipNewlines()
 	if !p.expectPeek(token.RPAREN) {
 		return nil
 	}
@@ -528,14 +888,19 @@ func (p *Parser) parseBlockStatement() *ast.BlockStatement {
 		for p.curTokenIs(token.NEWLINE) {
 			p.nextToken()
 		}
-		if p.curTokenIs(token.RBRACE) {
+		if p.curTokenIs(token.RBRACE) || p.curTokenIs(token.EOF) {
 			break
 		}
-		stmt := p.parseStatement()
-		if stmt != nil {
-			block.Statements = append(block.Statements, stmt)
+		if stmt, ok := p.parseStatementOrRecover(); ok {
+			if stmt != nil {
+				block.Statements = append(block.Statements, stmt)
+			}
+			p.nextToken()
 		}
-		p.nextToken()
+	}
+
+	if p.curTokenIs(token.EOF) {
+		p.addError(p.curToken, "diharapkan '}', dapat akhir file", []token.TokenType{token.RBRACE})
 	}
 
 	return block
@@ -601,6 +966,7 @@ func (p *Parser) parseCallExpression(function ast.Expression) ast.Expression {
 func (p *Parser) parseExpressionList(end token.TokenType) []ast.Expression {
 	list := []ast.Expression{}
 
+	p.skipNewlines()
 	if p.peekTokenIs(end) {
 		p.nextToken()
 		return list
@@ -608,16 +974,21 @@ func (p *Parser) parseExpressionList(end token.TokenType) []ast.Expression {
 
 	p.nextToken()
 	list = append(list, p.parseExpression(LOWEST))
+	p.skipNewlines()
 
 	for p.peekTokenIs(token.COMMA) {
 		p.nextToken()
+		p.skipNewlines()
 		p.nextToken()
 		list = append(list, p.parseExpression(LOWEST))
+		p.skipNewlines()
 	}
 
-	if !p.expectPeek(end) {
+	if !p.peekTokenIs(end) {
+		p.peekError(token.COMMA, end)
 		return nil
 	}
+	p.nextToken()
 
 	return list
 }
@@ -631,9 +1002,11 @@ func (p *Parser) parseArrayLiteral() ast.Expression {
 func (p *Parser) parseIndexExpression(left ast.Expression) ast.Expression {
 	exp := &ast.IndexExpression{Token: p.curToken, Left: left}
 
+	p.skipNewlines()
 	p.nextToken()
 	exp.Index = p.parseExpression(LOWEST)
 
+	p.skipNewlines()
 	if !p.expectPeek(token.RBRACKET) {
 		return nil
 	}
@@ -641,21 +1014,28 @@ func (p *Parser) parseIndexExpression(left ast.Expression) ast.Expression {
 	return exp
 }
 
-func (p *Parser) parseMapLiteral() ast.Expression {
-	mapLit := &ast.MapLiteral{Token: p.curToken}
-	mapLit.Pairs = make(map[ast.Expression]ast.Expression)
+func (p *Parser) parseMemberExpression(left ast.Expression) ast.Expression {
+	exp := &ast.MemberExpression{Token: p.curToken, Left: left}
 
-	for !p.peekTokenIs(token.RBRACE) {
-		p.nextToken()
+	if !p.expectPeek(token.IDENT) {
+		return nil
+	}
 
-		// Skip newlines inside map
-		for p.curTokenIs(token.NEWLINE) {
-			p.nextToken()
-		}
+	exp.Property = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+
+	return exp
+}
 
-		if p.curTokenIs(token.RBRACE) {
+func (p *Parser) parseMapLiteral() ast.Expression {
+	mapLit := &ast.MapLiteral{Token: p.curToken}
+
+	// Pairs may be spread over several lines, with an optional trailing comma
+	for {
+		p.skipNewlines()
+		if p.peekTokenIs(token.RBRACE) {
 			break
 		}
+		p.nextToken()
 
 		key := p.parseExpression(LOWEST)
 
@@ -663,14 +1043,21 @@ func (p *Parser) parseMapLiteral() ast.Expression {
 			return nil
 		}
 
+		p.skipNewlines()
 		p.nextToken()
 		value := p.parseExpression(LOWEST)
 
-		mapLit.Pairs[key] = value
+		mapLit.Pairs = append(mapLit.Pairs, ast.MapLiteralPair{Key: key, Value: value})
 
-		if !p.peekTokenIs(token.RBRACE) && !p.expectPeek(token.COMMA) {
+		p.skipNewlines()
+		if p.peekTokenIs(token.RBRACE) {
+			break
+		}
+		if !p.peekTokenIs(token.COMMA) {
+			p.peekError(token.COMMA, token.RBRACE)
 			return nil
 		}
+		p.nextToken()
 	}
 
 	if !p.expectPeek(token.RBRACE) {
@@ -1,10 +1,13 @@
 package parser
 
 import (
+	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/arjunaayasa/sasaklang/pkg/sasaklang/ast"
 	"github.com/arjunaayasa/sasaklang/pkg/sasaklang/lexer"
+	"github.com/arjunaayasa/sasaklang/pkg/sasaklang/token"
 )
 
 func TestLetStatements(t *testing.T) {
@@ -300,6 +303,302 @@ func TestForStatement(t *testing.T) {
 	}
 }
 
+func TestForInStatement(t *testing.T) {
+	tests := []struct {
+		input     string
+		variables []string
+		expected  string
+	}{
+		{"ojok (x dalem xs) { cetak(x) }", []string{"x"}, "ojok (x dalem xs) cetak(x)"},
+		{"ojok (i, x dalem f(1)) { }", []string{"i", "x"}, "ojok (i, x dalem f(1)) "},
+		{"ojok (k, v dalem {\"a\": 1}) { k }", []string{"k", "v"}, "ojok (k, v dalem {\"a\":1}) k"},
+	}
+
+	for _, tt := range tests {
+		p := New(lexer.New(tt.input))
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		stmt, ok := program.Statements[0].(*ast.ForInStatement)
+		if !ok {
+			t.Fatalf("program.Statements[0] is not *ast.ForInStatement. got=%T", program.Statements[0])
+		}
+		if len(stmt.Variables) != len(tt.variables) {
+			t.Fatalf("expected %d variables, got=%d", len(tt.variables), len(stmt.Variables))
+		}
+		for i, name := range tt.variables {
+			if stmt.Variables[i].Value != name {
+				t.Errorf("variables[%d]: expected %s, got=%s", i, name, stmt.Variables[i].Value)
+			}
+		}
+		if actual := stmt.String(); actual != tt.expected {
+			t.Errorf("expected=%q, got=%q", tt.expected, actual)
+		}
+	}
+
+	for _, input := range []string{
+		"ojok (x, dalem xs) { }",
+		"ojok (i, x, y dalem xs) { }",
+		"ojok (x dalem) { }",
+		"ojok (x dalem xs) cetak(x)",
+	} {
+		p := New(lexer.New(input))
+		p.ParseProgram()
+		if len(p.Errors()) == 0 {
+			t.Errorf("%q: expected parse errors", input)
+		}
+	}
+}
+
+func TestFloatLiteral(t *testing.T) {
+	input := `2.5`
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	if len(program.Statements) != 1 {
+		t.Fatalf("program.Statements does not contain 1 statement. got=%d",
+			len(program.Statements))
+	}
+
+	stmt, ok := program.Statements[0].(*ast.ExpressionStatement)
+	if !ok {
+		t.Fatalf("program.Statements[0] is not *ast.ExpressionStatement. got=%T",
+			program.Statements[0])
+	}
+
+	literal, ok := stmt.Expression.(*ast.FloatLiteral)
+	if !ok {
+		t.Fatalf("stmt.Expression is not *ast.FloatLiteral. got=%T",
+			stmt.Expression)
+	}
+
+	if literal.Value != 2.5 {
+		t.Errorf("literal.Value not 2.5. got=%g", literal.Value)
+	}
+}
+
+func TestBigIntegerLiteral(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"9223372036854775807", ""},
+		{"9223372036854775808", "9223372036854775808"},
+		{"123456789012345678901234567890", "123456789012345678901234567890"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		stmt := program.Statements[0].(*ast.ExpressionStatement)
+		literal, ok := stmt.Expression.(*ast.IntegerLiteral)
+		if !ok {
+			t.Fatalf("stmt.Expression is not *ast.IntegerLiteral. got=%T", stmt.Expression)
+		}
+
+		switch {
+		case tt.expected == "" && literal.Big != nil:
+			t.Errorf("%s: expected an int64 literal, got big %s", tt.input, literal.Big)
+		case tt.expected != "" && (literal.Big == nil || literal.Big.String() != tt.expected):
+			t.Errorf("%s: expected big literal %s, got=%v", tt.input, tt.expected, literal.Big)
+		}
+	}
+}
+
+func TestAssignmentTargets(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"x = 5", "x = 5"},
+		{"arr[0] = 1", "(arr[0]) = 1"},
+		{`peta["k"] = v + 1`, `(peta["k"]) = (v + 1)`},
+		{"a[i][j] = b[j]", "((a[i])[j]) = (b[j])"},
+		{"a = b = 1", "a = b = 1"},
+		{"x += 2 * 3", "x += (2 * 3)"},
+		{"arr[i] -= 1", "(arr[i]) -= 1"},
+		{"x *= y %= 2", "x *= y %= 2"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		stmt, ok := program.Statements[0].(*ast.ExpressionStatement)
+		if !ok {
+			t.Fatalf("program.Statements[0] is not *ast.ExpressionStatement. got=%T",
+				program.Statements[0])
+		}
+
+		if _, ok := stmt.Expression.(*ast.AssignmentExpression); !ok {
+			t.Fatalf("stmt.Expression is not *ast.AssignmentExpression. got=%T",
+				stmt.Expression)
+		}
+
+		if actual := program.String(); actual != tt.expected {
+			t.Errorf("expected=%q, got=%q", tt.expected, actual)
+		}
+	}
+}
+
+func TestPostfixExpressions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"i++", "(i++)"},
+		{"i--", "(i--)"},
+		{"arr[0]++", "((arr[0])++)"},
+		{"-i++", "(-(i++))"},
+		{"i++ + 1", "((i++) + 1)"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		if actual := program.String(); actual != tt.expected {
+			t.Errorf("expected=%q, got=%q", tt.expected, actual)
+		}
+	}
+}
+
+func TestForStatementWithIncrement(t *testing.T) {
+	input := `ojok (gawe i = 0; i < 10; i++) { cetak(i) }`
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	stmt, ok := program.Statements[0].(*ast.ForStatement)
+	if !ok {
+		t.Fatalf("program.Statements[0] is not *ast.ForStatement. got=%T",
+			program.Statements[0])
+	}
+
+	if _, ok := stmt.Update.(*ast.PostfixExpression); !ok {
+		t.Fatalf("stmt.Update is not *ast.PostfixExpression. got=%T", stmt.Update)
+	}
+}
+
+func TestInvalidAssignmentTarget(t *testing.T) {
+	for _, input := range []string{"f() = 1", "5 += 1", "(a + b)++"} {
+		l := lexer.New(input)
+		p := New(l)
+		p.ParseProgram()
+
+		if len(p.Errors()) == 0 {
+			t.Errorf("expected parser error for invalid assignment target in %q", input)
+		}
+	}
+}
+
+func TestTryStatement(t *testing.T) {
+	input := `coba { risiko() } tangkep (e) { cetak(e) } akhirne { beres() }`
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	if len(program.Statements) != 1 {
+		t.Fatalf("program.Statements does not contain 1 statement. got=%d",
+			len(program.Statements))
+	}
+
+	stmt, ok := program.Statements[0].(*ast.TryStatement)
+	if !ok {
+		t.Fatalf("program.Statements[0] is not *ast.TryStatement. got=%T",
+			program.Statements[0])
+	}
+
+	if stmt.CatchParam == nil || stmt.CatchParam.Value != "e" {
+		t.Fatalf("stmt.CatchParam is not 'e'. got=%v", stmt.CatchParam)
+	}
+
+	if stmt.Catch == nil || stmt.Finally == nil {
+		t.Fatalf("stmt.Catch or stmt.Finally is nil")
+	}
+}
+
+func TestTryStatementErrors(t *testing.T) {
+	for _, input := range []string{"coba { 1 }", "coba { 1 } tangkep (1) { 2 }", "coba 1"} {
+		l := lexer.New(input)
+		p := New(l)
+		p.ParseProgram()
+
+		if len(p.Errors()) == 0 {
+			t.Errorf("expected parser error for %q", input)
+		}
+	}
+}
+
+func TestThrowStatement(t *testing.T) {
+	input := `lempar "gagal"`
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	stmt, ok := program.Statements[0].(*ast.ThrowStatement)
+	if !ok {
+		t.Fatalf("program.Statements[0] is not *ast.ThrowStatement. got=%T",
+			program.Statements[0])
+	}
+
+	if stmt.Value.String() != `"gagal"` {
+		t.Errorf("stmt.Value wrong. got=%q", stmt.Value.String())
+	}
+}
+
+func TestImportStatement(t *testing.T) {
+	tests := []struct {
+		input         string
+		expectedPath  string
+		expectedAlias string
+	}{
+		{`impor "util.ssk"`, "util.ssk", ""},
+		{`impor "lib/teks.ssk" sebagai t`, "lib/teks.ssk", "t"},
+	}
+
+	for _, tt := range tests {
+		l := lexer.New(tt.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		stmt, ok := program.Statements[0].(*ast.ImportStatement)
+		if !ok {
+			t.Fatalf("program.Statements[0] is not *ast.ImportStatement. got=%T",
+				program.Statements[0])
+		}
+
+		if stmt.Path != tt.expectedPath {
+			t.Errorf("stmt.Path wrong. expected=%q, got=%q", tt.expectedPath, stmt.Path)
+		}
+
+		alias := ""
+		if stmt.Alias != nil {
+			alias = stmt.Alias.Value
+		}
+		if alias != tt.expectedAlias {
+			t.Errorf("stmt.Alias wrong. expected=%q, got=%q", tt.expectedAlias, alias)
+		}
+	}
+}
+
 func TestOperatorPrecedenceParsing(t *testing.T) {
 	tests := []struct {
 		input    string
@@ -315,6 +614,9 @@ func TestOperatorPrecedenceParsing(t *testing.T) {
 		{"a + b * c + d / e - f", "(((a + (b * c)) + (d / e)) - f)"},
 		{"5 > 4 == 3 < 4", "((5 > 4) == (3 < 4))"},
 		{"3 + 4 * 5 == 3 * 1 + 4 * 5", "((3 + (4 * 5)) == ((3 * 1) + (4 * 5)))"},
+		{"a.b.c(1)", "((a.b).c)(1)"},
+		{"-m.x * 2", "((-(m.x)) * 2)"},
+		{"m.x[0]", "((m.x)[0])"},
 	}
 
 	for _, tt := range tests {
@@ -330,6 +632,161 @@ func TestOperatorPrecedenceParsing(t *testing.T) {
 	}
 }
 
+func TestTemplateLiteral(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{`"Halo, ${nama}!"`, `"Halo, ${nama}!"`},
+		{`"${a + b * 2}"`, `"${(a + (b * 2))}"`},
+		{`"${a}-${f(1)}"`, `"${a}-${f(1)}"`},
+		{`"luar ${"dalam ${x}"}"`, `"luar ${"dalam ${x}"}"`},
+		{"`baris\n${\n  x\n}`", "\"baris\n${x}\""},
+	}
+
+	for _, tt := range tests {
+		p := New(lexer.New(tt.input))
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		if actual := program.String(); actual != tt.expected {
+			t.Errorf("expected=%q, got=%q", tt.expected, actual)
+		}
+	}
+
+	p := New(lexer.New(`"a${1}b${x}"`))
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+	template, ok := program.Statements[0].(*ast.ExpressionStatement).Expression.(*ast.TemplateLiteral)
+	if !ok {
+		t.Fatalf("expression is not ast.TemplateLiteral. got=%T", program.Statements[0].(*ast.ExpressionStatement).Expression)
+	}
+	if len(template.Parts) != 4 {
+		t.Fatalf("expected 4 parts, got=%d", len(template.Parts))
+	}
+	if text, ok := template.Parts[0].(*ast.StringLiteral); !ok || text.Value != "a" {
+		t.Errorf("parts[0] is not the text \"a\". got=%s", template.Parts[0])
+	}
+	if lit, ok := template.Parts[1].(*ast.IntegerLiteral); !ok || lit.Value != 1 {
+		t.Errorf("parts[1] is not the integer 1. got=%s", template.Parts[1])
+	}
+	if ident, ok := template.Parts[3].(*ast.Identifier); !ok || ident.Value != "x" {
+		t.Errorf("parts[3] is not the identifier x. got=%s", template.Parts[3])
+	}
+}
+
+func TestMapLiteral(t *testing.T) {
+	p := New(lexer.New(`{"b": 1, "a": 2 + 3, "b": x}`))
+	program := p.ParseProgram()
+	checkParserErrors(t, p)
+
+	mapLit, ok := program.Statements[0].(*ast.ExpressionStatement).Expression.(*ast.MapLiteral)
+	if !ok {
+		t.Fatalf("expression is not ast.MapLiteral. got=%T", program.Statements[0].(*ast.ExpressionStatement).Expression)
+	}
+	// Pairs keep their source order, repeated keys included
+	expected := []string{`"b":1`, `"a":(2 + 3)`, `"b":x`}
+	if len(mapLit.Pairs) != len(expected) {
+		t.Fatalf("expected %d pairs, got=%d", len(expected), len(mapLit.Pairs))
+	}
+	for i, pair := range mapLit.Pairs {
+		if actual := pair.Key.String() + ":" + pair.Value.String(); actual != expected[i] {
+			t.Errorf("pairs[%d]: expected=%q, got=%q", i, expected[i], actual)
+		}
+	}
+}
+
+func TestMultiLineExpressions(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"gawe x = 1 +\n  2", "gawe x = (1 + 2);"},
+		{"gawe x =\n  5", "gawe x = 5;"},
+		{"f(\n  1,\n  2\n)", "f(1, 2)"},
+		{"[1,\n 2]", "[1, 2]"},
+		{"arr[\n 0\n]", "(arr[0])"},
+		{"{\n  \"a\": 1,\n}", "{\"a\":1}"},
+	}
+
+	for _, tt := range tests {
+		p := New(lexer.New(tt.input))
+		program := p.ParseProgram()
+		checkParserErrors(t, p)
+
+		if actual := program.String(); actual != tt.expected {
+			t.Errorf("expected=%q, got=%q", tt.expected, actual)
+		}
+	}
+}
+
+func TestParseErrorDetails(t *testing.T) {
+	tests := []struct {
+		input    string
+		line     int
+		column   int
+		token    string
+		expected []token.TokenType
+	}{
+		{"gawe x 5", 1, 8, "5", []token.TokenType{token.ASSIGN}},
+		{"cetak(1 2)", 1, 9, "2", []token.TokenType{token.COMMA, token.RPAREN}},
+		{"gawe m = {a: 1 b: 2}", 1, 16, "b", []token.TokenType{token.COMMA, token.RBRACE}},
+		{"fungsi f() {", 1, 13, "", []token.TokenType{token.RBRACE}},
+		{`"a ${x y}"`, 1, 8, "y", []token.TokenType{token.TEMPLATE_END}},
+	}
+
+	for _, tt := range tests {
+		p := New(lexer.New(tt.input))
+		p.ParseProgram()
+
+		errs := p.ParseErrors()
+		if len(errs) != 1 {
+			t.Errorf("expected 1 error for %q, got=%d %v", tt.input, len(errs), p.Errors())
+			continue
+		}
+		err := errs[0]
+		if err.Line != tt.line || err.Column != tt.column || err.Token != tt.token {
+			t.Errorf("wrong error for %q. got=%d:%d %q", tt.input, err.Line, err.Column, err.Token)
+		}
+		if !reflect.DeepEqual(err.Expected, tt.expected) {
+			t.Errorf("wrong expected tokens for %q. expected=%v, got=%v", tt.input, tt.expected, err.Expected)
+		}
+	}
+}
+
+func TestParseErrorRecovery(t *testing.T) {
+	input := `gawe x 5
+gawe = 2
+fungsi f(a) {
+  gawe z = a +* 2
+  tulakan z
+}
+ojok (gawe i = 0 i < 3; i++) {
+  cetak(i)
+}
+gawe ok = 1`
+
+	p := New(lexer.New(input))
+	program := p.ParseProgram()
+
+	expectedLines := []int{1, 2, 4, 7}
+	errs := p.ParseErrors()
+	if len(errs) != len(expectedLines) {
+		t.Fatalf("expected %d errors, got=%d %v", len(expectedLines), len(errs), p.Errors())
+	}
+	for i, line := range expectedLines {
+		if errs[i].Line != line {
+			t.Errorf("errors[%d] on wrong line. expected=%d, got=%d", i, line, errs[i].Line)
+		}
+	}
+
+	// The statements around the broken ones are still parsed
+	last := program.Statements[len(program.Statements)-1]
+	if stmt, ok := last.(*ast.LetStatement); !ok || stmt.Name.Value != "ok" {
+		t.Errorf("last statement is not 'gawe ok = 1'. got=%s", last)
+	}
+}
+
 func checkParserErrors(t *testing.T, p *Parser) {
 	errors := p.Errors()
 	if len(errors) == 0 {
@@ -342,3 +799,22 @@ func checkParserErrors(t *testing.T, p *Parser) {
 	}
 	t.FailNow()
 }
+
+func TestLexerErrors(t *testing.T) {
+	input := `gawe a = "x\qy"
+gawe b 2`
+
+	p := New(lexer.New(input))
+	p.ParseProgram()
+
+	errs := p.ParseErrors()
+	if len(errs) != 2 {
+		t.Fatalf("expected 2 errors, got=%d %v", len(errs), p.Errors())
+	}
+	if errs[0].Line != 1 || errs[0].Column != 12 || !strings.Contains(errs[0].Message, `escape tidak dikenal: \q`) {
+		t.Errorf("wrong first error: %s", errs[0])
+	}
+	if errs[1].Line != 2 {
+		t.Errorf("expected the second error on line 2, got=%s", errs[1])
+	}
+}
@@ -0,0 +1,142 @@
+package repl
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/arjunaayasa/sasaklang/pkg/sasaklang/ast"
+	"github.com/arjunaayasa/sasaklang/pkg/sasaklang/lexer"
+	"github.com/arjunaayasa/sasaklang/pkg/sasaklang/parser"
+	"github.com/arjunaayasa/sasaklang/pkg/sasaklang/token"
+)
+
+// command is a REPL meta-command, typed as ":name argument"
+type command struct {
+	usage string
+	help  string
+	run   func(s *session, arg string)
+}
+
+var commands map[string]command
+
+func init() {
+	// Assigned here because :bantuan lists the commands itself
+	commands = map[string]command{
+		"vars":    {":vars", "tampilkan semua variabel di sesi ini", (*session).showVars},
+		"ast":     {":ast <kode>", "tampilkan pohon sintaks (AST) dari kode", (*session).showAST},
+		"tokens":  {":tokens <kode>", "tampilkan token hasil lexer dari kode", (*session).showTokens},
+		"load":    {":load <file>", "jalankan file ke dalam sesi ini", (*session).load},
+		"reset":   {":reset", "hapus semua variabel dan mulai sesi baru", (*session).reset},
+		"time":    {":time <kode>", "jalankan kode dan tampilkan lama waktunya", (*session).time},
+		"bantuan": {":bantuan", "tampilkan daftar perintah ini", (*session).showHelp},
+	}
+	commands["help"] = commands["bantuan"]
+}
+
+// commandOrder is the order :bantuan lists the commands in
+var commandOrder = []string{"vars", "ast", "tokens", "load", "reset", "time", "bantuan"}
+
+// runCommand handles a line starting with ':'
+func (s *session) runCommand(line string) {
+	name, arg, _ := strings.Cut(strings.TrimPrefix(line, ":"), " ")
+	arg = strings.TrimSpace(arg)
+
+	cmd, ok := commands[name]
+	if !ok {
+		fmt.Fprintf(s.out, "Perintah tidak dikenal: :%s (ketik :bantuan untuk daftar perintah)\n", name)
+		return
+	}
+	if strings.Contains(cmd.usage, "<") && arg == "" {
+		fmt.Fprintf(s.out, "Penggunaan: %s\n", cmd.usage)
+		return
+	}
+	cmd.run(s, arg)
+}
+
+func (s *session) showHelp(string) {
+	fmt.Fprintln(s.out, "Perintah REPL:")
+	for _, name := range commandOrder {
+		cmd := commands[name]
+		fmt.Fprintf(s.out, "  %-16s %s\n", cmd.usage, cmd.help)
+	}
+}
+
+func (s *session) showVars(string) {
+	names := s.env.Names()
+	if len(names) == 0 {
+		fmt.Fprintln(s.out, "(belum ada variabel)")
+		return
+	}
+
+	for _, name := range names {
+		val, _ := s.env.GetLocal(name)
+		kind := "gawe"
+		if s.env.IsConst(name) {
+			kind = "tetep"
+		}
+		fmt.Fprintf(s.out, "%s %s = %s\n", kind, name, firstLine(val.Inspect()))
+	}
+}
+
+func (s *session) showAST(source string) {
+	p := parser.New(lexer.New(source))
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		printParserErrors(s.out, p.Errors())
+		return
+	}
+	fmt.Fprint(s.out, ast.Dump(program))
+}
+
+func (s *session) showTokens(source string) {
+	l := lexer.New(source)
+	for tok := l.NextToken(); tok.Type != token.EOF; tok = l.NextToken() {
+		literal := tok.Literal
+		switch tok.Type {
+		case token.NEWLINE:
+			literal = `\n`
+		case token.STRING, token.TEMPLATE_START, token.TEMPLATE_MIDDLE, token.TEMPLATE_END:
+			literal = strconv.Quote(literal)
+		}
+		fmt.Fprintf(s.out, "%d:%-4d %-10s %s\n", tok.Line, tok.Column, tok.Type, literal)
+	}
+}
+
+func (s *session) load(path string) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		fmt.Fprintf(s.out, "Gagal membaca file: %s\n", err)
+		return
+	}
+
+	// Imports in the file are resolved relative to it
+	if absPath, err := filepath.Abs(path); err == nil {
+		prev := s.env.File()
+		s.env.SetFile(absPath)
+		defer s.env.SetFile(prev)
+	}
+	s.eval(string(content))
+}
+
+func (s *session) reset(string) {
+	s.env = s.newEnvironment()
+	fmt.Fprintln(s.out, "Sesi diulang dari awal.")
+}
+
+func (s *session) time(source string) {
+	start := time.Now()
+	s.eval(source)
+	fmt.Fprintf(s.out, "Waktu: %s\n", time.Since(start))
+}
+
+// firstLine shortens multi-line values so each variable takes one line
+func firstLine(s string) string {
+	if i := strings.IndexByte(s, '\n'); i >= 0 {
+		return s[:i] + " ..."
+	}
+	return s
+}
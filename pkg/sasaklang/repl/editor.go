@@ -0,0 +1,173 @@
+package repl
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// errInterrupted is returned by readLine when the user presses Ctrl+C
+var errInterrupted = errors.New("dibatalkan")
+
+// lineEditor reads lines from a terminal in raw mode. It supports moving the
+// cursor, editing in the middle of the line and recalling earlier lines with
+// the up and down arrows.
+type lineEditor struct {
+	in      *bufio.Reader
+	out     io.Writer
+	history []string
+
+	prompt string
+	buf    []rune
+	pos    int // cursor position in buf
+}
+
+func newLineEditor(in io.Reader, out io.Writer, history []string) *lineEditor {
+	return &lineEditor{in: bufio.NewReader(in), out: out, history: history}
+}
+
+// addHistory records line for recall, skipping blanks and repeats
+func (e *lineEditor) addHistory(line string) {
+	if line == "" || (len(e.history) > 0 && e.history[len(e.history)-1] == line) {
+		return
+	}
+	e.history = append(e.history, line)
+}
+
+// readLine shows prompt and returns the line typed by the user. It returns
+// io.EOF for Ctrl+D on an empty line and errInterrupted for Ctrl+C.
+func (e *lineEditor) readLine(prompt string) (string, error) {
+	e.prompt = prompt
+	e.buf = e.buf[:0]
+	e.pos = 0
+
+	histIdx := len(e.history)
+	current := "" // the line being typed, kept while browsing the history
+
+	fmt.Fprint(e.out, prompt)
+	for {
+		r, _, err := e.in.ReadRune()
+		if err != nil {
+			return "", err
+		}
+
+		switch r {
+		case '\r', '\n':
+			fmt.Fprint(e.out, "\r\n")
+			return string(e.buf), nil
+		case 3: // Ctrl+C
+			fmt.Fprint(e.out, "^C\r\n")
+			return "", errInterrupted
+		case 4: // Ctrl+D
+			if len(e.buf) == 0 {
+				fmt.Fprint(e.out, "\r\n")
+				return "", io.EOF
+			}
+			e.deleteAt(e.pos)
+		case 127, 8: // Backspace
+			if e.pos > 0 {
+				e.pos--
+				e.deleteAt(e.pos)
+			}
+		case 1: // Ctrl+A
+			e.moveTo(0)
+		case 5: // Ctrl+E
+			e.moveTo(len(e.buf))
+		case 11: // Ctrl+K
+			e.buf = e.buf[:e.pos]
+			e.redraw()
+		case 21: // Ctrl+U
+			e.buf = append(e.buf[:0], e.buf[e.pos:]...)
+			e.pos = 0
+			e.redraw()
+		case 27: // escape sequence
+			switch e.readEscape() {
+			case "A": // up
+				if histIdx > 0 {
+					if histIdx == len(e.history) {
+						current = string(e.buf)
+					}
+					histIdx--
+					e.setLine(e.history[histIdx])
+				}
+			case "B": // down
+				if histIdx < len(e.history) {
+					histIdx++
+					if histIdx == len(e.history) {
+						e.setLine(current)
+					} else {
+						e.setLine(e.history[histIdx])
+					}
+				}
+			case "C": // right
+				e.moveTo(e.pos + 1)
+			case "D": // left
+				e.moveTo(e.pos - 1)
+			case "H", "1~", "7~":
+				e.moveTo(0)
+			case "F", "4~", "8~":
+				e.moveTo(len(e.buf))
+			case "3~": // delete
+				e.deleteAt(e.pos)
+			}
+		default:
+			if r >= ' ' {
+				e.buf = append(e.buf, 0)
+				copy(e.buf[e.pos+1:], e.buf[e.pos:])
+				e.buf[e.pos] = r
+				e.pos++
+				e.redraw()
+			}
+		}
+	}
+}
+
+// readEscape reads the rest of an escape sequence such as "\x1b[A" and
+// returns the part after the bracket
+func (e *lineEditor) readEscape() string {
+	r, _, err := e.in.ReadRune()
+	if err != nil || (r != '[' && r != 'O') {
+		return ""
+	}
+
+	var seq []rune
+	for {
+		r, _, err := e.in.ReadRune()
+		if err != nil {
+			return ""
+		}
+		seq = append(seq, r)
+		if r >= 0x40 && r <= 0x7e {
+			return string(seq)
+		}
+	}
+}
+
+func (e *lineEditor) deleteAt(i int) {
+	if i < len(e.buf) {
+		e.buf = append(e.buf[:i], e.buf[i+1:]...)
+		e.redraw()
+	}
+}
+
+func (e *lineEditor) moveTo(pos int) {
+	if pos >= 0 && pos <= len(e.buf) {
+		e.pos = pos
+		e.redraw()
+	}
+}
+
+func (e *lineEditor) setLine(line string) {
+	e.buf = append(e.buf[:0], []rune(line)...)
+	e.pos = len(e.buf)
+	e.redraw()
+}
+
+// redraw repaints the prompt and line and puts the cursor back in place
+func (e *lineEditor) redraw() {
+	fmt.Fprintf(e.out, "\r%s%s\x1b[K", e.prompt, string(e.buf))
+	if n := len(e.buf) - e.pos; n > 0 {
+		fmt.Fprintf(e.out, "\x1b[%dD", n)
+	}
+}
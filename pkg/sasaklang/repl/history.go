@@ -0,0 +1,62 @@
+package repl
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// HISTORY_FILE is the name of the history file in the user's home directory
+const HISTORY_FILE = ".sasaklang_history"
+
+// maxHistory is the number of lines kept in the history file
+const maxHistory = 1000
+
+// historyPath returns the path of the history file, or "" when the home
+// directory is unknown
+func historyPath() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, HISTORY_FILE)
+}
+
+// loadHistory reads the lines saved by earlier sessions, trimming the file
+// when it has grown past maxHistory lines
+func loadHistory(path string) []string {
+	if path == "" {
+		return nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+
+	var lines []string
+	for _, line := range strings.Split(string(data), "\n") {
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+
+	if len(lines) > maxHistory {
+		lines = lines[len(lines)-maxHistory:]
+		_ = os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0o600)
+	}
+	return lines
+}
+
+// appendHistory adds line to the history file. Failing to save history is
+// not worth interrupting the session for, so errors are ignored.
+func appendHistory(path, line string) {
+	if path == "" {
+		return
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	_, _ = f.WriteString(line + "\n")
+}
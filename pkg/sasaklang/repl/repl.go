@@ -4,16 +4,24 @@ import (
 	"bufio"
 	"fmt"
 	"io"
+	"os"
 	"strings"
 
+	"golang.org/x/term"
+
+	sserrors "github.com/arjunaayasa/sasaklang/pkg/sasaklang/errors"
 	"github.com/arjunaayasa/sasaklang/pkg/sasaklang/evaluator"
 	"github.com/arjunaayasa/sasaklang/pkg/sasaklang/lexer"
 	"github.com/arjunaayasa/sasaklang/pkg/sasaklang/object"
 	"github.com/arjunaayasa/sasaklang/pkg/sasaklang/parser"
+	"github.com/arjunaayasa/sasaklang/pkg/sasaklang/token"
 )
 
 const PROMPT = "sasak>> "
 
+// CONT_PROMPT is shown while an unfinished entry continues on the next line
+const CONT_PROMPT = "sasak.. "
+
 const LOGO = `
    _____                 __   __                   
   / ___/____ _________ _/ /__/ /   ____ _____  ____ _
@@ -23,53 +31,226 @@ const LOGO = `
                                              /____/   
 `
 
-// Start starts the REPL
+// Options configure a REPL session
+type Options struct {
+	// MaxMemory limits the bytes of strings, arrays, maps and big integers
+	// the session creates until it is reset; zero means no limit
+	MaxMemory int64
+
+	// Seed, when not nil, makes the random numbers of the session the same
+	// every time, starting over when the session is reset
+	Seed *int64
+}
+
+// Start starts the REPL. When in and out are a terminal, lines can be
+// edited and earlier lines recalled, and the history is kept across
+// sessions in HISTORY_FILE.
 func Start(in io.Reader, out io.Writer) {
-	scanner := bufio.NewScanner(in)
-	env := object.NewEnvironment()
+	StartWithOptions(in, out, Options{})
+}
+
+// StartWithOptions starts the REPL with the session configured by opts
+func StartWithOptions(in io.Reader, out io.Writer, opts Options) {
+	reader := newLineReader(in, out)
+	s := &session{out: out, opts: opts}
+	s.env = s.newEnvironment()
 
 	fmt.Fprint(out, LOGO)
 	fmt.Fprintln(out, "Selamat datang di SasakLang REPL!")
-	fmt.Fprintln(out, "Ketik 'exit' atau tekan Ctrl+D untuk keluar.")
+	fmt.Fprintln(out, "Ketik 'exit' atau tekan Ctrl+D untuk keluar, ':bantuan' untuk daftar perintah.")
 	fmt.Fprintln(out)
 
+	// Lines of an entry that is not finished yet
+	var pending []string
+
 	for {
-		fmt.Fprint(out, PROMPT)
-		scanned := scanner.Scan()
-		if !scanned {
+		prompt := PROMPT
+		if len(pending) > 0 {
+			prompt = CONT_PROMPT
+		}
+
+		line, err := reader.readLine(prompt)
+		if err == errInterrupted {
+			pending = nil
+			continue
+		}
+		if err != nil {
+			if len(pending) > 0 {
+				fmt.Fprintln(out)
+				s.eval(strings.Join(pending, "\n"))
+			}
 			fmt.Fprintln(out, "\nSampai jumpa!")
 			return
 		}
 
-		line := scanner.Text()
-		line = strings.TrimSpace(line)
+		if len(pending) == 0 {
+			line = strings.TrimSpace(line)
+			if line == "" {
+				continue
+			}
+			if line == "exit" || line == "keluar" {
+				fmt.Fprintln(out, "Sampai jumpa!")
+				return
+			}
+			if strings.HasPrefix(line, ":") {
+				s.runCommand(line)
+				continue
+			}
+		}
 
-		if line == "" {
+		pending = append(pending, line)
+		source := strings.Join(pending, "\n")
+		if needsMoreInput(source) {
 			continue
 		}
+		pending = nil
 
-		if line == "exit" || line == "keluar" {
-			fmt.Fprintln(out, "Sampai jumpa!")
-			return
+		s.eval(source)
+	}
+}
+
+// session is the state of one REPL run
+type session struct {
+	out  io.Writer
+	opts Options
+	env  *object.Environment
+}
+
+// newEnvironment creates an empty global scope whose programs print to
+// the session's output
+func (s *session) newEnvironment() *object.Environment {
+	rt := object.NewRuntime()
+	rt.Stdout = s.out
+	rt.MaxMemory = s.opts.MaxMemory
+	if s.opts.Seed != nil {
+		rt.SetSeed(*s.opts.Seed)
+	}
+	return object.NewEnvironmentWithRuntime(rt)
+}
+
+// eval runs one complete entry and prints its result
+func (s *session) eval(source string) {
+	l := lexer.New(source)
+	p := parser.New(l)
+
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		printParserErrors(s.out, p.Errors())
+		return
+	}
+
+	evaluated := evaluator.Eval(program, s.env)
+	if errObj, ok := evaluated.(*object.Error); ok {
+		// Errors raised inside functions or imported files may point into
+		// other text, so only errors from this entry get an excerpt
+		excerpt := ""
+		if len(errObj.Stack) == 0 && errObj.File == s.env.File() {
+			excerpt = source
+		}
+		fmt.Fprintln(s.out, sserrors.FormatRuntimeError(errObj, excerpt))
+		return
+	}
+	if evaluated != nil {
+		// Don't print null for expression statements
+		if evaluated.Type() != object.NULL_OBJ {
+			fmt.Fprintln(s.out, evaluated.Inspect())
 		}
+	}
+}
+
+// continuesLine holds the tokens that cannot end an entry: an entry ending
+// in one of them goes on on the next line
+var continuesLine = map[token.TokenType]bool{
+	token.ASSIGN: true, token.PLUS_ASSIGN: true, token.MINUS_ASSIGN: true,
+	token.ASTERISK_ASSIGN: true, token.SLASH_ASSIGN: true, token.MODULO_ASSIGN: true,
+	token.PLUS: true, token.MINUS: true, token.ASTERISK: true, token.SLASH: true, token.MODULO: true,
+	token.EQ: true, token.NEQ: true, token.LT: true, token.GT: true, token.LTE: true, token.GTE: true,
+	token.AND: true, token.OR: true, token.COMMA: true,
+}
 
-		l := lexer.New(line)
-		p := parser.New(l)
+// needsMoreInput reports whether source is unfinished: it has unclosed
+// braces, brackets, parentheses or strings, or ends with an operator
+func needsMoreInput(source string) bool {
+	l := lexer.New(source)
+	depth := 0
+	var last token.Token
 
-		program := p.ParseProgram()
-		if len(p.Errors()) != 0 {
-			printParserErrors(out, p.Errors())
+	for tok := l.NextToken(); tok.Type != token.EOF; tok = l.NextToken() {
+		switch tok.Type {
+		case token.LBRACE, token.LBRACKET, token.LPAREN:
+			depth++
+		case token.RBRACE, token.RBRACKET, token.RPAREN:
+			depth--
+		case token.NEWLINE:
 			continue
 		}
+		last = tok
+	}
 
-		evaluated := evaluator.Eval(program, env)
-		if evaluated != nil {
-			// Don't print null for expression statements
-			if evaluated.Type() != object.NULL_OBJ {
-				fmt.Fprintln(out, evaluated.Inspect())
-			}
+	return depth > 0 || l.Unfinished() || continuesLine[last.Type]
+}
+
+// lineReader reads the REPL's input one line at a time
+type lineReader interface {
+	readLine(prompt string) (string, error)
+}
+
+// newLineReader returns a line editor when in and out are a terminal, and
+// a plain line scanner otherwise
+func newLineReader(in io.Reader, out io.Writer) lineReader {
+	inFile, inOK := in.(*os.File)
+	outFile, outOK := out.(*os.File)
+	if inOK && outOK && term.IsTerminal(int(inFile.Fd())) && term.IsTerminal(int(outFile.Fd())) {
+		path := historyPath()
+		return &terminalReader{
+			fd:          int(inFile.Fd()),
+			editor:      newLineEditor(in, out, loadHistory(path)),
+			historyPath: path,
+		}
+	}
+	return &scannerReader{scanner: bufio.NewScanner(in), out: out}
+}
+
+type scannerReader struct {
+	scanner *bufio.Scanner
+	out     io.Writer
+}
+
+func (r *scannerReader) readLine(prompt string) (string, error) {
+	fmt.Fprint(r.out, prompt)
+	if !r.scanner.Scan() {
+		if err := r.scanner.Err(); err != nil {
+			return "", err
+		}
+		return "", io.EOF
+	}
+	return r.scanner.Text(), nil
+}
+
+// terminalReader puts the terminal in raw mode while a line is edited and
+// saves each entered line to the history file
+type terminalReader struct {
+	fd          int
+	editor      *lineEditor
+	historyPath string
+}
+
+func (r *terminalReader) readLine(prompt string) (string, error) {
+	state, err := term.MakeRaw(r.fd)
+	if err != nil {
+		return "", err
+	}
+	line, err := r.editor.readLine(prompt)
+	_ = term.Restore(r.fd, state)
+
+	if err == nil && strings.TrimSpace(line) != "" {
+		n := len(r.editor.history)
+		r.editor.addHistory(line)
+		if len(r.editor.history) > n {
+			appendHistory(r.historyPath, line)
 		}
 	}
+	return line, err
 }
 
 func printParserErrors(out io.Writer, errors []string) {
@@ -0,0 +1,172 @@
+package repl
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestNeedsMoreInput(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected bool
+	}{
+		{"gawe x = 5", false},
+		{"fungsi f(a) {", true},
+		{"fungsi f(a) {\n  tulakan a\n}", false},
+		{"gawe arr = [1, 2,", true},
+		{"cetak(1 +", true},
+		{"gawe x = 1 +", true},
+		{"gawe x =", true},
+		{"x ance", true},
+		{"cetak(x)", false},
+		{"}", false},
+		{"gawe s = `baris satu", true},
+		{"gawe s = `baris satu\nbaris dua`", false},
+		{`cetak("${x`, true},
+	}
+
+	for _, tt := range tests {
+		if got := needsMoreInput(tt.input); got != tt.expected {
+			t.Errorf("needsMoreInput(%q) wrong. expected=%t, got=%t", tt.input, tt.expected, got)
+		}
+	}
+}
+
+func TestStartMultiLine(t *testing.T) {
+	input := `fungsi tambah(a, b) {
+  tulakan a + b
+}
+tambah(2,
+  3)
+gawe x = 10 *
+  4
+x
+`
+	var out bytes.Buffer
+	Start(strings.NewReader(input), &out)
+
+	output := out.String()
+	for _, expected := range []string{"5\n", "40\n", CONT_PROMPT} {
+		if !strings.Contains(output, expected) {
+			t.Errorf("output does not contain %q:\n%s", expected, output)
+		}
+	}
+	if strings.Contains(output, "error") {
+		t.Errorf("unexpected error in output:\n%s", output)
+	}
+}
+
+func TestStartMultiLineString(t *testing.T) {
+	input := "gawe x = 40\ngawe teks = `satu\n  ${x}`\nteks\n"
+
+	var out bytes.Buffer
+	Start(strings.NewReader(input), &out)
+
+	if !strings.Contains(out.String(), "satu\n  40\n") {
+		t.Errorf("output does not contain the multi-line string:\n%s", out.String())
+	}
+}
+
+func TestStartWithOptions(t *testing.T) {
+	seed := int64(42)
+	run := func(input string, opts Options) string {
+		var out bytes.Buffer
+		StartWithOptions(strings.NewReader(input), &out, opts)
+		return out.String()
+	}
+
+	// The seed makes sessions repeat, and :reset starts the numbers over
+	first := run("acak(1000000)\n:reset\nacak(1000000)\n", Options{Seed: &seed})
+	second := run("acak(1000000)\n", Options{Seed: &seed})
+	numbers := regexp.MustCompile(`\d{2,}`).FindAllString(first, -1)
+	if len(numbers) != 2 || numbers[0] != numbers[1] || !strings.Contains(second, numbers[0]+"\n") {
+		t.Errorf("seeded sessions differ:\n%s\n%s", first, second)
+	}
+
+	output := run("gawe s = ulang(\"x\", 1000)\n", Options{MaxMemory: 512})
+	if !strings.Contains(output, "batas memori terlampaui") {
+		t.Errorf("output does not contain the memory limit error:\n%s", output)
+	}
+}
+
+func TestLineEditor(t *testing.T) {
+	tests := []struct {
+		name     string
+		keys     string
+		expected string
+	}{
+		{"plain", "gawe\r", "gawe"},
+		{"backspace", "gawx\x7fe\r", "gawe"},
+		{"insert after moving left", "ge\x1b[Daw\r", "gawe"},
+		{"home and end", "awe\x1b[Hg\x1b[F!\r", "gawe!"},
+		{"ctrl-a and delete", "xgawe\x01\x1b[3~\r", "gawe"},
+		{"kill to end", "gawe x\x1b[D\x1b[D\x0b\r", "gawe"},
+		{"unicode", "héllo\x7f\r", "héll"},
+	}
+
+	for _, tt := range tests {
+		e := newLineEditor(strings.NewReader(tt.keys), io.Discard, nil)
+		line, err := e.readLine(PROMPT)
+		if err != nil {
+			t.Fatalf("%s: unexpected error %v", tt.name, err)
+		}
+		if line != tt.expected {
+			t.Errorf("%s: expected=%q, got=%q", tt.name, tt.expected, line)
+		}
+	}
+}
+
+func TestLineEditorHistory(t *testing.T) {
+	// up, up, down recalls "b" then "a" then "b"; the edit in progress
+	// comes back after moving past the newest entry
+	keys := "\x1b[A\x1b[A\x1b[B\r" + "new\x1b[A\x1b[B\r" + "\x04"
+	e := newLineEditor(strings.NewReader(keys), io.Discard, []string{"a", "b"})
+
+	if line, _ := e.readLine(PROMPT); line != "b" {
+		t.Errorf("expected history entry %q, got=%q", "b", line)
+	}
+	if line, _ := e.readLine(PROMPT); line != "new" {
+		t.Errorf("expected edited line %q, got=%q", "new", line)
+	}
+	if _, err := e.readLine(PROMPT); err != io.EOF {
+		t.Errorf("expected io.EOF on Ctrl+D, got=%v", err)
+	}
+}
+
+func TestCommands(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "lib.ssk")
+	if err := os.WriteFile(file, []byte("gawe dariFile = 7\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		input    string
+		expected []string
+	}{
+		{"gawe x = 1\ntetep PI = 3\n:vars", []string{"tetep PI = 3\n", "gawe x = 1\n"}},
+		{":ast 1 + x", []string{"InfixExpression Operator=\"+\"\n", "    Left: IntegerLiteral Value=1\n", "    Right: Identifier Value=\"x\"\n"}},
+		{":tokens gawe x", []string{"1:1    GAWE       gawe\n", "1:6    IDENT      x\n"}},
+		{":load " + file + "\ndariFile", []string{"7\n"}},
+		{"gawe x = 1\n:reset\nx", []string{"Sesi diulang", "variabel 'x' belum didefinisikan"}},
+		{":time 2 * 21", []string{"42\n", "Waktu: "}},
+		{":ast", []string{"Penggunaan: :ast <kode>"}},
+		{":apa", []string{"Perintah tidak dikenal: :apa"}},
+	}
+
+	for _, tt := range tests {
+		var out bytes.Buffer
+		Start(strings.NewReader(tt.input+"\n"), &out)
+
+		for _, expected := range tt.expected {
+			if !strings.Contains(out.String(), expected) {
+				t.Errorf("output of %q does not contain %q:\n%s", tt.input, expected, out.String())
+			}
+		}
+	}
+}
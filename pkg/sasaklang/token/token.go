@@ -20,8 +20,16 @@ const (
 	// Identifiers + literals
 	IDENT  TokenType = "IDENT"  // variable names
 	INT    TokenType = "INT"    // 12345
+	FLOAT  TokenType = "FLOAT"  // 3.14
 	STRING TokenType = "STRING" // "hello"
 
+	// Parts of an interpolated string such as "a${x}b${y}c", holding the
+	// text between the interpolations: "a${ is the start, }b${ a middle
+	// part and }c" the end
+	TEMPLATE_START  TokenType = "TEMPLATE_START"
+	TEMPLATE_MIDDLE TokenType = "TEMPLATE_MIDDLE"
+	TEMPLATE_END    TokenType = "TEMPLATE_END"
+
 	// Operators
 	ASSIGN   TokenType = "="
 	PLUS     TokenType = "+"
@@ -30,6 +38,14 @@ const (
 	SLASH    TokenType = "/"
 	MODULO   TokenType = "%"
 
+	PLUS_ASSIGN     TokenType = "+="
+	MINUS_ASSIGN    TokenType = "-="
+	ASTERISK_ASSIGN TokenType = "*="
+	SLASH_ASSIGN    TokenType = "/="
+	MODULO_ASSIGN   TokenType = "%="
+	INCREMENT       TokenType = "++"
+	DECREMENT       TokenType = "--"
+
 	BANG TokenType = "!"
 
 	LT  TokenType = "<"
@@ -46,6 +62,7 @@ const (
 	COMMA     TokenType = ","
 	SEMICOLON TokenType = ";"
 	COLON     TokenType = ":"
+	DOT       TokenType = "."
 	NEWLINE   TokenType = "NEWLINE"
 
 	LPAREN   TokenType = "("
@@ -66,6 +83,17 @@ const (
 	BALIK  TokenType = "BALIK"  // return
 	TIPUQ  TokenType = "TIPUQ"  // break
 	LANJUT TokenType = "LANJUT" // continue
+	DALEM  TokenType = "DALEM"  // in, of ojok ... dalem loops
+
+	// Error handling keywords
+	COBA    TokenType = "COBA"    // try
+	TANGKEP TokenType = "TANGKEP" // catch
+	AKHIRNE TokenType = "AKHIRNE" // finally
+	LEMPAR  TokenType = "LEMPAR"  // throw
+
+	// Module keywords
+	IMPOR   TokenType = "IMPOR"   // import
+	SEBAGAI TokenType = "SEBAGAI" // as
 
 	// Boolean and null literals
 	BENER  TokenType = "BENER"  // true
@@ -85,6 +113,13 @@ var keywords = map[string]TokenType{
 	"tulakan":  BALIK,
 	"mentelah": TIPUQ,
 	"lanjutan": LANJUT,
+	"dalem":    DALEM,
+	"coba":     COBA,
+	"tangkep":  TANGKEP,
+	"akhirne":  AKHIRNE,
+	"lempar":   LEMPAR,
+	"impor":    IMPOR,
+	"sebagai":  SEBAGAI,
 	"kenak":    BENER,
 	"salak":    SALAH,
 	"ndarak":   KOSONG,
@@ -100,3 +135,13 @@ func LookupIdent(ident string) TokenType {
 	}
 	return IDENT
 }
+
+// Keyword returns how a keyword token type is spelled in source
+func Keyword(t TokenType) (string, bool) {
+	for ident, tok := range keywords {
+		if tok == t {
+			return ident, true
+		}
+	}
+	return "", false
+}
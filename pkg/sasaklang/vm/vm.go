@@ -0,0 +1,900 @@
+package vm
+
+import (
+	"math"
+	"path/filepath"
+	"strings"
+
+	"github.com/arjunaayasa/sasaklang/pkg/sasaklang/builtins"
+	"github.com/arjunaayasa/sasaklang/pkg/sasaklang/code"
+	"github.com/arjunaayasa/sasaklang/pkg/sasaklang/compiler"
+	"github.com/arjunaayasa/sasaklang/pkg/sasaklang/evaluator"
+	"github.com/arjunaayasa/sasaklang/pkg/sasaklang/object"
+)
+
+// Limits of the VM
+const (
+	MaxStackSize = 1 << 20
+	MaxFrames    = 1 << 14
+)
+
+const initialStackSize = 1024
+
+// The VM shares the evaluator's singletons so that values can be compared
+// by identity, as the evaluator does
+var (
+	Null  = evaluator.NULL
+	True  = evaluator.TRUE
+	False = evaluator.FALSE
+)
+
+// Small integers are preallocated, so most loop counters and indexes do not
+// allocate
+const (
+	smallIntMin = -128
+	smallIntMax = 1023
+)
+
+var smallInts [smallIntMax - smallIntMin + 1]*object.Integer
+
+func init() {
+	for i := range smallInts {
+		smallInts[i] = &object.Integer{Value: int64(i + smallIntMin)}
+	}
+}
+
+func newInteger(v int64) *object.Integer {
+	if v >= smallIntMin && v <= smallIntMax {
+		return smallInts[v-smallIntMin]
+	}
+	return &object.Integer{Value: v}
+}
+
+var operators = map[code.Opcode]string{
+	code.OpAdd:          "+",
+	code.OpSub:          "-",
+	code.OpMul:          "*",
+	code.OpDiv:          "/",
+	code.OpMod:          "%",
+	code.OpEqual:        "==",
+	code.OpNotEqual:     "!=",
+	code.OpLess:         "<",
+	code.OpLessEqual:    "<=",
+	code.OpGreater:      ">",
+	code.OpGreaterEqual: ">=",
+	code.OpIncrement:    "++",
+	code.OpDecrement:    "--",
+}
+
+// Frame is the activation of a function
+type Frame struct {
+	cl          *object.Closure
+	ip          int
+	basePointer int
+}
+
+// handler is an error handler installed by a coba block
+type handler struct {
+	frame int // index of the frame that installed it
+	ip    int // address of the handler code
+	sp    int // stack pointer to restore
+}
+
+// VM executes compiled bytecode
+type VM struct {
+	globals *object.Globals
+
+	stack []object.Object
+	sp    int // next free slot; the top of the stack is stack[sp-1]
+
+	frames   []*Frame
+	frameLen int
+
+	handlers []handler
+
+	main    *object.Closure
+	runtime *object.Runtime
+	file    string
+}
+
+// New creates a VM for a compiled program, using the process's standard
+// streams
+func New(bytecode *compiler.Bytecode) *VM {
+	return NewWithRuntime(bytecode, object.NewRuntime())
+}
+
+// NewWithRuntime creates a VM for a compiled program that runs with rt
+func NewWithRuntime(bytecode *compiler.Bytecode, rt *object.Runtime) *VM {
+	globals := &object.Globals{
+		Values:   make([]object.Object, len(bytecode.GlobalNames)),
+		Names:    bytecode.GlobalNames,
+		TopLevel: bytecode.TopLevel,
+	}
+
+	return &VM{
+		globals: globals,
+		stack:   make([]object.Object, initialStackSize),
+		main:    &object.Closure{Fn: bytecode.Main, Globals: globals},
+		runtime: rt,
+	}
+}
+
+// SetFile records the source file being run; imports are resolved
+// relative to it
+func (vm *VM) SetFile(file string) {
+	vm.file = file
+	vm.globals.File = file
+}
+
+// SetGlobal gives the top-level variable name a value before the program
+// runs. It reports false if the program does not refer to name.
+func (vm *VM) SetGlobal(name string, val object.Object) bool {
+	idx, ok := vm.globals.TopLevel[name]
+	if ok {
+		vm.globals.Values[idx] = val
+	}
+	return ok
+}
+
+// Run executes the program and returns its result, which is an
+// *object.Error when the program failed
+func (vm *VM) Run() object.Object {
+	vm.sp = 0
+	vm.frameLen = 0
+	vm.handlers = vm.handlers[:0]
+	vm.pushFrame(vm.main, 0)
+	return vm.run(0)
+}
+
+// CallFunction calls fn on behalf of a builtin and returns its result. A
+// function of the program runs to completion on top of the frames of the
+// running program.
+func (vm *VM) CallFunction(fn object.Object, args []object.Object) object.Object {
+	sp, base := vm.sp, vm.frameLen
+	vm.push(fn)
+	for _, arg := range args {
+		vm.push(arg)
+	}
+	if err := vm.callFunction(len(args)); err != nil {
+		vm.sp = sp
+		return err
+	}
+
+	var result object.Object
+	if vm.frameLen > base {
+		result = vm.run(base)
+	} else {
+		// A builtin has already pushed its result
+		result = vm.stack[vm.sp-1]
+	}
+	vm.sp = sp
+	return result
+}
+
+// run executes instructions until the frame at index base returns, and
+// returns its result. Errors not caught within the frames from base up are
+// returned as the result, leaving base frames.
+func (vm *VM) run(base int) object.Object {
+	frame := vm.frames[vm.frameLen-1]
+	ins := frame.cl.Fn.Instructions
+	constants := frame.cl.Fn.Constants
+
+	for {
+		ip := frame.ip
+		op := code.Opcode(ins[ip])
+		frame.ip++
+
+		var err *object.Error
+
+		switch op {
+		case code.OpConstant:
+			idx := code.ReadUint16(ins[ip+1:])
+			frame.ip += 2
+			vm.push(constants[idx])
+
+		case code.OpPop:
+			vm.sp--
+
+		case code.OpDup:
+			vm.push(vm.stack[vm.sp-1])
+
+		case code.OpDup2:
+			a, b := vm.stack[vm.sp-2], vm.stack[vm.sp-1]
+			vm.push(a)
+			vm.push(b)
+
+		case code.OpNip:
+			vm.stack[vm.sp-2] = vm.stack[vm.sp-1]
+			vm.sp--
+
+		case code.OpNull:
+			vm.push(Null)
+		case code.OpTrue:
+			vm.push(True)
+		case code.OpFalse:
+			vm.push(False)
+
+		case code.OpAdd, code.OpSub, code.OpMul, code.OpDiv, code.OpMod,
+			code.OpEqual, code.OpNotEqual, code.OpLess, code.OpLessEqual,
+			code.OpGreater, code.OpGreaterEqual:
+			right := vm.stack[vm.sp-1]
+			left := vm.stack[vm.sp-2]
+			vm.sp -= 2
+			result := vm.executeBinaryOperation(op, left, right)
+			if err = asError(result); err == nil {
+				vm.push(result)
+			}
+
+		case code.OpMinus:
+			result := evaluator.Prefix("-", vm.stack[vm.sp-1])
+			if err = asError(result); err == nil {
+				vm.stack[vm.sp-1] = result
+			}
+
+		case code.OpBang:
+			vm.stack[vm.sp-1] = evaluator.Prefix("!", vm.stack[vm.sp-1])
+
+		case code.OpIncrement, code.OpDecrement:
+			result := vm.executeUpdate(op, vm.stack[vm.sp-1])
+			if err = asError(result); err == nil {
+				vm.stack[vm.sp-1] = result
+			}
+
+		case code.OpJump:
+			target := int(code.ReadUint16(ins[ip+1:]))
+			frame.ip = target
+			// Only loops jump backwards; each time round is a step
+			if target < ip {
+				err = vm.runtime.Step()
+			}
+
+		case code.OpJumpNotTruthy:
+			condition := vm.stack[vm.sp-1]
+			vm.sp--
+			if evaluator.IsTruthy(condition) {
+				frame.ip += 2
+			} else {
+				frame.ip = int(code.ReadUint16(ins[ip+1:]))
+			}
+
+		case code.OpIter:
+			var it *object.Iterator
+			if it, err = evaluator.NewIterator(vm.runtime, vm.stack[vm.sp-1]); err == nil {
+				vm.stack[vm.sp-1] = it
+			}
+
+		case code.OpIterNext:
+			n := int(ins[ip+3])
+			frame.ip += 3
+			// The loop's result slot is on top of the iterator
+			it := vm.stack[vm.sp-2].(*object.Iterator)
+			key, value, ok := it.Next()
+			if !ok {
+				frame.ip = int(code.ReadUint16(ins[ip+1:]))
+				break
+			}
+			if err = asError(value); err == nil {
+				for _, v := range evaluator.LoopValues(it, n, key, value) {
+					vm.push(v)
+				}
+			}
+
+		case code.OpGetGlobal:
+			idx := code.ReadUint16(ins[ip+1:])
+			frame.ip += 2
+			globals := frame.cl.Globals
+			val := globals.Values[idx]
+			if val == nil {
+				// Like the evaluator, fall back to the builtin of that name
+				builtin, ok := builtins.Lookup(globals.Names[idx])
+				if !ok {
+					err = undefinedError(globals.Names[idx])
+					break
+				}
+				val = builtin
+			}
+			vm.push(val)
+
+		case code.OpSetGlobal:
+			idx := code.ReadUint16(ins[ip+1:])
+			frame.ip += 2
+			vm.sp--
+			frame.cl.Globals.Values[idx] = vm.stack[vm.sp]
+
+		case code.OpAssignGlobal:
+			idx := code.ReadUint16(ins[ip+1:])
+			frame.ip += 2
+			globals := frame.cl.Globals
+			if globals.Values[idx] == nil {
+				err = undefinedError(globals.Names[idx])
+				break
+			}
+			vm.sp--
+			globals.Values[idx] = vm.stack[vm.sp]
+
+		case code.OpGetLocal:
+			idx := int(ins[ip+1])
+			frame.ip++
+			val := vm.stack[frame.basePointer+idx]
+			if val == nil {
+				err = undefinedError(frame.cl.Fn.LocalNames[idx])
+				break
+			}
+			vm.push(val)
+
+		case code.OpSetLocal:
+			idx := int(ins[ip+1])
+			frame.ip++
+			vm.sp--
+			vm.stack[frame.basePointer+idx] = vm.stack[vm.sp]
+
+		case code.OpGetCell:
+			idx := int(ins[ip+1])
+			frame.ip++
+			cell, _ := vm.stack[frame.basePointer+idx].(*object.Cell)
+			if cell == nil || cell.Value == nil {
+				err = undefinedError(frame.cl.Fn.LocalNames[idx])
+				break
+			}
+			vm.push(cell.Value)
+
+		case code.OpSetCell:
+			idx := int(ins[ip+1])
+			frame.ip++
+			cell, _ := vm.stack[frame.basePointer+idx].(*object.Cell)
+			if cell == nil || cell.Value == nil {
+				err = undefinedError(frame.cl.Fn.LocalNames[idx])
+				break
+			}
+			vm.sp--
+			cell.Value = vm.stack[vm.sp]
+
+		case code.OpDefineCell:
+			idx := int(ins[ip+1])
+			frame.ip++
+			vm.sp--
+			slot := frame.basePointer + idx
+			if cell, ok := vm.stack[slot].(*object.Cell); ok {
+				cell.Value = vm.stack[vm.sp]
+			} else {
+				vm.stack[slot] = &object.Cell{Value: vm.stack[vm.sp]}
+			}
+
+		case code.OpLoadCell:
+			idx := int(ins[ip+1])
+			frame.ip++
+			slot := frame.basePointer + idx
+			cell, ok := vm.stack[slot].(*object.Cell)
+			if !ok {
+				// The variable is not defined yet, as when a named function
+				// captures itself; the definition fills the cell later
+				cell = &object.Cell{}
+				vm.stack[slot] = cell
+			}
+			vm.push(cell)
+
+		case code.OpMakeCell:
+			idx := int(ins[ip+1])
+			frame.ip++
+			slot := frame.basePointer + idx
+			vm.stack[slot] = &object.Cell{Value: vm.stack[slot]}
+
+		case code.OpClearLocal:
+			idx := int(ins[ip+1])
+			frame.ip++
+			vm.stack[frame.basePointer+idx] = nil
+
+		case code.OpGetFree:
+			idx := int(ins[ip+1])
+			frame.ip++
+			cell := frame.cl.Free[idx]
+			if cell.Value == nil {
+				err = undefinedError(frame.cl.Fn.FreeNames[idx])
+				break
+			}
+			vm.push(cell.Value)
+
+		case code.OpSetFree:
+			idx := int(ins[ip+1])
+			frame.ip++
+			cell := frame.cl.Free[idx]
+			if cell.Value == nil {
+				err = undefinedError(frame.cl.Fn.FreeNames[idx])
+				break
+			}
+			vm.sp--
+			cell.Value = vm.stack[vm.sp]
+
+		case code.OpLoadFree:
+			idx := int(ins[ip+1])
+			frame.ip++
+			vm.push(frame.cl.Free[idx])
+
+		case code.OpArray:
+			n := int(code.ReadUint16(ins[ip+1:]))
+			frame.ip += 2
+			if err = vm.runtime.Allocate(object.ArraySize(n)); err != nil {
+				break
+			}
+			elements := make([]object.Object, n)
+			copy(elements, vm.stack[vm.sp-n:vm.sp])
+			vm.sp -= n
+			vm.push(&object.Array{Elements: elements})
+
+		case code.OpMap:
+			n := int(code.ReadUint16(ins[ip+1:]))
+			frame.ip += 2
+			var m object.Object
+			m, err = vm.buildMap(vm.sp-2*n, vm.sp)
+			if err == nil {
+				vm.sp -= 2 * n
+				vm.push(m)
+			}
+
+		case code.OpTemplate:
+			n := int(code.ReadUint16(ins[ip+1:]))
+			frame.ip += 2
+			result := evaluator.JoinTemplate(vm.runtime, vm.stack[vm.sp-n:vm.sp])
+			if err = asError(result); err == nil {
+				vm.sp -= n
+				vm.push(result)
+			}
+
+		case code.OpIndex:
+			index := vm.stack[vm.sp-1]
+			left := vm.stack[vm.sp-2]
+			vm.sp -= 2
+			result := executeIndex(left, index)
+			if err = asError(result); err == nil {
+				vm.push(result)
+			}
+
+		case code.OpIndexForUpdate:
+			index := vm.stack[vm.sp-1]
+			container := vm.stack[vm.sp-2]
+			vm.sp -= 2
+			result := evaluator.IndexForUpdate(container, index)
+			if err = asError(result); err == nil {
+				vm.push(result)
+			}
+
+		case code.OpSetIndex:
+			val := vm.stack[vm.sp-1]
+			index := vm.stack[vm.sp-2]
+			container := vm.stack[vm.sp-3]
+			vm.sp -= 3
+			result := evaluator.SetIndex(vm.runtime, container, index, val)
+			if err = asError(result); err == nil {
+				vm.push(result)
+			}
+
+		case code.OpPostfixIndex:
+			update := code.OpIncrement
+			if ins[ip+1] == 1 {
+				update = code.OpDecrement
+			}
+			frame.ip++
+			index := vm.stack[vm.sp-1]
+			container := vm.stack[vm.sp-2]
+			vm.sp -= 2
+			var old object.Object
+			old, err = vm.executePostfixIndex(update, container, index)
+			if err == nil {
+				vm.push(old)
+			}
+
+		case code.OpCall:
+			numArgs := int(ins[ip+1])
+			frame.ip++
+			err = vm.callFunction(numArgs)
+			frame = vm.frames[vm.frameLen-1]
+			ins = frame.cl.Fn.Instructions
+			constants = frame.cl.Fn.Constants
+
+		case code.OpReturnValue:
+			returnValue := vm.stack[vm.sp-1]
+			vm.dropHandlers(vm.frameLen - 1)
+			vm.frameLen--
+			if vm.frameLen == base {
+				return returnValue
+			}
+			vm.sp = frame.basePointer - 1
+			vm.push(returnValue)
+			frame = vm.frames[vm.frameLen-1]
+			ins = frame.cl.Fn.Instructions
+			constants = frame.cl.Fn.Constants
+
+		case code.OpClosure:
+			idx := code.ReadUint16(ins[ip+1:])
+			numFree := int(ins[ip+3])
+			frame.ip += 3
+			fn := constants[idx].(*object.CompiledFunction)
+			free := make([]*object.Cell, numFree)
+			for i := range free {
+				free[i] = vm.stack[vm.sp-numFree+i].(*object.Cell)
+			}
+			vm.sp -= numFree
+			vm.push(&object.Closure{Fn: fn, Free: free, Globals: frame.cl.Globals})
+
+		case code.OpTry:
+			target := int(code.ReadUint16(ins[ip+1:]))
+			frame.ip += 2
+			vm.handlers = append(vm.handlers, handler{frame: vm.frameLen - 1, ip: target, sp: vm.sp})
+
+		case code.OpEndTry:
+			vm.handlers = vm.handlers[:len(vm.handlers)-1]
+
+		case code.OpThrow:
+			val := vm.stack[vm.sp-1]
+			vm.sp--
+			if thrown, ok := val.(*object.Error); ok {
+				// Re-raising the pending error after an akhirne block
+				err = thrown
+			} else {
+				line, column := code.FindPosition(frame.cl.Fn.Positions, ip)
+				err = evaluator.Throw(val, line, column)
+			}
+
+		case code.OpCatch:
+			vm.stack[vm.sp-1] = evaluator.ErrorToMap(vm.stack[vm.sp-1].(*object.Error))
+
+		case code.OpRaise:
+			idx := code.ReadUint16(ins[ip+1:])
+			frame.ip += 2
+			err = evaluator.NewError("%s", constants[idx].(*object.String).Value)
+
+		case code.OpImport:
+			idx := code.ReadUint16(ins[ip+1:])
+			frame.ip += 2
+			module := vm.importModule(constants[idx].(*object.String).Value)
+			if err = asError(module); err == nil {
+				vm.push(module)
+			}
+
+		default:
+			def, _ := code.Lookup(byte(op))
+			name := "?"
+			if def != nil {
+				name = def.Name
+			}
+			err = evaluator.NewError("instruksi tidak dikenal: %s", name)
+		}
+
+		if err != nil {
+			if err.Line == 0 {
+				err.Line, err.Column = code.FindPosition(frame.cl.Fn.Positions, ip)
+				err.File = frame.cl.Globals.File
+			}
+			if !vm.handleError(err, base) {
+				return err
+			}
+			frame = vm.frames[vm.frameLen-1]
+			ins = frame.cl.Fn.Instructions
+			constants = frame.cl.Fn.Constants
+		}
+	}
+}
+
+func (vm *VM) push(o object.Object) {
+	if vm.sp >= len(vm.stack) {
+		vm.growStack(vm.sp + 1)
+	}
+	vm.stack[vm.sp] = o
+	vm.sp++
+}
+
+// growStack makes room for at least size values. The stack only overflows
+// through runaway recursion, which pushFrame reports first.
+func (vm *VM) growStack(size int) {
+	newSize := len(vm.stack) * 2
+	for newSize < size {
+		newSize *= 2
+	}
+	stack := make([]object.Object, newSize)
+	copy(stack, vm.stack)
+	vm.stack = stack
+}
+
+func (vm *VM) pushFrame(cl *object.Closure, basePointer int) {
+	if vm.frameLen == len(vm.frames) {
+		vm.frames = append(vm.frames, &Frame{})
+	}
+	frame := vm.frames[vm.frameLen]
+	frame.cl = cl
+	frame.ip = 0
+	frame.basePointer = basePointer
+	vm.frameLen++
+}
+
+// callFunction calls the function below the numArgs arguments on the stack
+func (vm *VM) callFunction(numArgs int) *object.Error {
+	callee := vm.stack[vm.sp-1-numArgs]
+
+	switch callee := callee.(type) {
+	case *object.Closure:
+		fn := callee.Fn
+		// The main program's frame is not a call
+		if vm.frameLen > vm.runtime.CallDepth() || vm.frameLen >= MaxFrames {
+			return object.DepthError()
+		}
+		if err := vm.runtime.Step(); err != nil {
+			return err
+		}
+
+		basePointer := vm.sp - numArgs
+		top := basePointer + fn.NumLocals
+		if top > MaxStackSize {
+			return object.DepthError()
+		}
+		if top > len(vm.stack) {
+			vm.growStack(top)
+		}
+
+		// Missing arguments stay undefined, extra ones are dropped, and the
+		// other locals start out empty
+		first := basePointer + numArgs
+		if numArgs > fn.NumParameters {
+			first = basePointer + fn.NumParameters
+		}
+		for i := first; i < top; i++ {
+			vm.stack[i] = nil
+		}
+
+		vm.pushFrame(callee, basePointer)
+		vm.sp = top
+		return nil
+
+	case *object.Builtin:
+		args := make([]object.Object, numArgs)
+		copy(args, vm.stack[vm.sp-numArgs:vm.sp])
+		vm.sp -= numArgs + 1
+
+		prev := vm.runtime.SetCaller(vm)
+		result := callee.Fn(vm.runtime, args...)
+		vm.runtime.SetCaller(prev)
+		if err := asError(result); err != nil {
+			return err
+		}
+		if result == nil {
+			result = Null
+		}
+		vm.push(result)
+		return nil
+
+	default:
+		return evaluator.NewError("bukan fungsi: %s", callee.Type())
+	}
+}
+
+// handleError transfers control to the innermost coba block, discarding the
+// frames and stack values above it. It reports false when no handler is
+// installed in the frames from base up, which are then left.
+func (vm *VM) handleError(err *object.Error, base int) bool {
+	if n := len(vm.handlers); n == 0 || vm.handlers[n-1].frame < base {
+		vm.unwindFrames(err, base)
+		vm.dropHandlers(base)
+		vm.frameLen = base
+		return false
+	}
+
+	h := vm.handlers[len(vm.handlers)-1]
+	vm.handlers = vm.handlers[:len(vm.handlers)-1]
+
+	vm.unwindFrames(err, h.frame+1)
+	vm.frameLen = h.frame + 1
+	vm.sp = h.sp
+	vm.push(err)
+	vm.frames[h.frame].ip = h.ip
+	return true
+}
+
+// unwindFrames records the calls that err passes out of, from the innermost
+// frame down to frame stop, in err's call stack. The main program's frame
+// is not a call.
+func (vm *VM) unwindFrames(err *object.Error, stop int) {
+	for i := vm.frameLen - 1; i >= stop && i > 0; i-- {
+		caller := vm.frames[i-1]
+		name := vm.frames[i].cl.Fn.Name
+		if name == "" {
+			name = "<anonim>"
+		}
+		line, column := code.FindPosition(caller.cl.Fn.Positions, caller.ip-1)
+		err.Stack = append(err.Stack, object.StackFrame{
+			Function: name,
+			File:     caller.cl.Globals.File,
+			Line:     line,
+			Column:   column,
+		})
+	}
+}
+
+// dropHandlers removes the handlers installed by frame
+func (vm *VM) dropHandlers(frame int) {
+	n := len(vm.handlers)
+	for n > 0 && vm.handlers[n-1].frame >= frame {
+		n--
+	}
+	vm.handlers = vm.handlers[:n]
+}
+
+func (vm *VM) buildMap(start, end int) (object.Object, *object.Error) {
+	m := &object.Map{}
+
+	for i := start; i < end; i += 2 {
+		key := vm.stack[i]
+		value := vm.stack[i+1]
+
+		if _, ok := object.HashOf(key); !ok {
+			return nil, evaluator.NewError("tipe %s tidak bisa digunakan sebagai kunci map", key.Type())
+		}
+		m.Set(key, value)
+	}
+
+	if err := vm.runtime.Allocate(object.MapSize(m.Len())); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// importModule loads the module at path, compiling and running it in a VM
+// of its own the first time it is imported
+func (vm *VM) importModule(path string) object.Object {
+	if errObj := evaluator.CheckImportAllowed(vm.runtime, path); errObj != nil {
+		return errObj
+	}
+
+	absPath, errObj := evaluator.ResolveModulePath(path, vm.file)
+	if errObj != nil {
+		return errObj
+	}
+
+	if module, ok := vm.runtime.Modules.Modules[absPath]; ok {
+		return module
+	}
+
+	if errObj := evaluator.CheckImportCycle(vm.runtime.Modules, absPath); errObj != nil {
+		return errObj
+	}
+
+	program, errObj := evaluator.ParseModule(path, absPath)
+	if errObj != nil {
+		return errObj
+	}
+
+	comp := compiler.New()
+	if err := comp.Compile(program); err != nil {
+		return evaluator.NewError("modul '%s' gagal dikompilasi: %s", filepath.Base(absPath), err)
+	}
+
+	modules := vm.runtime.Modules
+	modules.Loading = append(modules.Loading, absPath)
+	sub := NewWithRuntime(comp.Bytecode(), vm.runtime)
+	sub.SetFile(absPath)
+	result := sub.Run()
+	modules.Loading = modules.Loading[:len(modules.Loading)-1]
+	if err := asError(result); err != nil {
+		return err
+	}
+
+	module := &object.Module{
+		Name:    strings.TrimSuffix(filepath.Base(absPath), filepath.Ext(absPath)),
+		Path:    absPath,
+		Globals: sub.globals,
+	}
+	modules.Modules[absPath] = module
+	return module
+}
+
+func (vm *VM) executeBinaryOperation(op code.Opcode, left, right object.Object) object.Object {
+	if l, ok := left.(*object.Integer); ok {
+		if r, ok := right.(*object.Integer); ok {
+			if result := executeIntegerOperation(op, l.Value, r.Value); result != nil {
+				return result
+			}
+		}
+	}
+	return evaluator.Infix(vm.runtime, operators[op], left, right)
+}
+
+// executeIntegerOperation is the fast path for integer operands. It returns
+// nil for the cases it leaves to the evaluator, such as division by zero
+// and results that overflow int64.
+func executeIntegerOperation(op code.Opcode, l, r int64) object.Object {
+	switch op {
+	case code.OpAdd:
+		if sum := l + r; (sum > l) == (r > 0) {
+			return newInteger(sum)
+		}
+	case code.OpSub:
+		if diff := l - r; (diff < l) == (r > 0) {
+			return newInteger(diff)
+		}
+	case code.OpMul:
+		if l == 0 || r == 0 {
+			return newInteger(0)
+		}
+		if product := l * r; product/r == l && !(l == -1 && r == math.MinInt64) && !(r == -1 && l == math.MinInt64) {
+			return newInteger(product)
+		}
+	case code.OpDiv:
+		if r != 0 && !(l == math.MinInt64 && r == -1) {
+			return newInteger(l / r)
+		}
+	case code.OpMod:
+		if r != 0 {
+			return newInteger(l % r)
+		}
+	case code.OpEqual:
+		return nativeBool(l == r)
+	case code.OpNotEqual:
+		return nativeBool(l != r)
+	case code.OpLess:
+		return nativeBool(l < r)
+	case code.OpLessEqual:
+		return nativeBool(l <= r)
+	case code.OpGreater:
+		return nativeBool(l > r)
+	case code.OpGreaterEqual:
+		return nativeBool(l >= r)
+	}
+	return nil
+}
+
+func (vm *VM) executeUpdate(op code.Opcode, current object.Object) object.Object {
+	if i, ok := current.(*object.Integer); ok {
+		if op == code.OpIncrement && i.Value != math.MaxInt64 {
+			return newInteger(i.Value + 1)
+		}
+		if op == code.OpDecrement && i.Value != math.MinInt64 {
+			return newInteger(i.Value - 1)
+		}
+	}
+	return evaluator.PostfixUpdate(vm.runtime, operators[op], current)
+}
+
+func executeIndex(left, index object.Object) object.Object {
+	if arr, ok := left.(*object.Array); ok {
+		if i, ok := index.(*object.Integer); ok {
+			if i.Value < 0 || i.Value >= int64(len(arr.Elements)) {
+				return Null
+			}
+			return arr.Elements[i.Value]
+		}
+	}
+	return evaluator.Index(left, index)
+}
+
+// executePostfixIndex updates container[index] for ++ or -- and returns the
+// previous value
+func (vm *VM) executePostfixIndex(op code.Opcode, container, index object.Object) (object.Object, *object.Error) {
+	current := evaluator.IndexForUpdate(container, index)
+	if err := asError(current); err != nil {
+		return nil, err
+	}
+
+	val := vm.executeUpdate(op, current)
+	if err := asError(val); err != nil {
+		return nil, err
+	}
+
+	if err := asError(evaluator.SetIndex(vm.runtime, container, index, val)); err != nil {
+		return nil, err
+	}
+	return current, nil
+}
+
+func nativeBool(b bool) *object.Boolean {
+	if b {
+		return True
+	}
+	return False
+}
+
+func undefinedError(name string) *object.Error {
+	return evaluator.NewError("variabel '%s' belum didefinisikan", name)
+}
+
+func asError(obj object.Object) *object.Error {
+	err, _ := obj.(*object.Error)
+	return err
+}
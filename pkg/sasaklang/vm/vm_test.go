@@ -0,0 +1,143 @@
+package vm
+
+import (
+	"testing"
+
+	"github.com/arjunaayasa/sasaklang/pkg/sasaklang/compiler"
+	"github.com/arjunaayasa/sasaklang/pkg/sasaklang/lexer"
+	"github.com/arjunaayasa/sasaklang/pkg/sasaklang/object"
+	"github.com/arjunaayasa/sasaklang/pkg/sasaklang/parser"
+)
+
+func TestClosures(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int64
+	}{
+		{"gawe tambah = fungsi(a) { fungsi(b) { a + b } }; tambah(2)(3)", 5},
+		{`
+gawe buatPenghitung = fungsi() {
+	gawe n = 0
+	fungsi() { n++; n }
+}
+gawe a = buatPenghitung()
+gawe b = buatPenghitung()
+a(); a(); b()
+a() * 10 + b()`, 32},
+		// Nested closures share the captured variable
+		{`
+fungsi luar() {
+	gawe x = 1
+	gawe ubah = fungsi() { gawe dalam = fungsi() { x = x + 10 }; dalam() }
+	ubah()
+	x
+}
+luar()`, 11},
+		// A function stored in a local can call itself
+		{"fungsi f() { gawe hitung = fungsi(n) { lamun (n == 0) { 0 } endah { 1 + hitung(n - 1) } }; hitung(5) }; f()", 5},
+		// Parameters captured by closures
+		{"fungsi buat(n) { fungsi() { n += 1 } }; gawe c = buat(10); c(); c()", 12},
+	}
+
+	for _, tt := range tests {
+		testIntegerObject(t, tt.input, run(t, tt.input), tt.expected)
+	}
+}
+
+func TestRecursion(t *testing.T) {
+	input := `
+fungsi fib(n) {
+	lamun (n < 2) { tulakan n }
+	tulakan fib(n - 1) + fib(n - 2)
+}
+fib(15)`
+	testIntegerObject(t, input, run(t, input), 610)
+
+	result := run(t, "fungsi f(n) { f(n + 1) }; f(0)")
+	errObj, ok := result.(*object.Error)
+	if !ok {
+		t.Fatalf("expected error for unbounded recursion, got=%T (%+v)", result, result)
+	}
+	if errObj.Message != "stack overflow: rekursi terlalu dalam" {
+		t.Errorf("wrong error message. got=%q", errObj.Message)
+	}
+}
+
+func TestErrorsAcrossFrames(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int64
+	}{
+		{"fungsi f() { lempar 1 }; coba { f() } tangkep (e) { e.nilai + 1 }", 2},
+		{"fungsi f() { coba { lempar 1 } tangkep { tulakan 5 } }; f() + 1", 6},
+		{"gawe n = 0; fungsi f() { coba { tulakan 1 } akhirne { n = 9 } }; f() + n", 10},
+		{"gawe n = 0; fungsi f(x) { x / 0 }; coba { f(1) } tangkep { n = 3 }\nn", 3},
+		// The stack is restored after an error deep in an expression
+		{"gawe r = 0; coba { r = 1 + [1, fungsi() { lempar 2 }()][0] } tangkep (e) { r = e.nilai }\nr + 1", 3},
+		// Errors leave functions called back by builtins too
+		{"gawe r = 0; coba { r = 1 + petakan([1], fungsi(x) { lempar 2 })[0] } tangkep (e) { r = e.nilai }\nr + 1", 3},
+		{"fungsi f() { petakan([1], fungsi(x) { coba { lempar 5 } tangkep (e) { e.nilai } }) }; f()[0] + 1", 6},
+	}
+
+	for _, tt := range tests {
+		testIntegerObject(t, tt.input, run(t, tt.input), tt.expected)
+	}
+}
+
+func TestArgumentCount(t *testing.T) {
+	testIntegerObject(t, "extra", run(t, "fungsi f(a) { a }; f(1, 2, 3)"), 1)
+
+	result := run(t, "fungsi f(a, b) { b }; f(1)")
+	errObj, ok := result.(*object.Error)
+	if !ok || errObj.Message != "variabel 'b' belum didefinisikan" {
+		t.Errorf("missing argument should be undefined, got=%+v", result)
+	}
+}
+
+func BenchmarkFibonacci(b *testing.B) {
+	input := `
+fungsi fib(n) {
+	lamun (n < 2) { tulakan n }
+	tulakan fib(n - 1) + fib(n - 2)
+}
+fib(20)`
+	p := parser.New(lexer.New(input))
+	program := p.ParseProgram()
+	c := compiler.New()
+	if err := c.Compile(program); err != nil {
+		b.Fatal(err)
+	}
+	bytecode := c.Bytecode()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		New(bytecode).Run()
+	}
+}
+
+func run(t *testing.T, input string) object.Object {
+	t.Helper()
+	p := parser.New(lexer.New(input))
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		t.Fatalf("parser errors: %v", p.Errors())
+	}
+
+	c := compiler.New()
+	if err := c.Compile(program); err != nil {
+		t.Fatalf("compiler error: %s", err)
+	}
+	return New(c.Bytecode()).Run()
+}
+
+func testIntegerObject(t *testing.T, input string, obj object.Object, expected int64) {
+	t.Helper()
+	result, ok := obj.(*object.Integer)
+	if !ok {
+		t.Errorf("%s: object is not Integer. got=%T (%+v)", input, obj, obj)
+		return
+	}
+	if result.Value != expected {
+		t.Errorf("%s: object has wrong value. got=%d, want=%d", input, result.Value, expected)
+	}
+}